@@ -19,39 +19,46 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"runtime/pprof"
 	"syscall"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/lightningrod"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/logfile"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/version"
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	Version = "1.0.0"
-)
-
 func main() {
+	// Children started with resource limits run through the agent binary
+	proclimit.Main()
+
 	// Parse command line flags
 	configPath := flag.String("config", "/etc/iotronic/iotronic.conf", "Path to configuration file")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
-	version := flag.Bool("version", false, "Print version and exit")
+	settingsPath := flag.String("settings", "", "Path to board settings file (overrides board.settings_file)")
+	showVersion := flag.Bool("version", false, "Print version and exit")
 	flag.Parse()
 
-	if *version {
-		fmt.Printf("Lightning-rod (Go) version %s\n", Version)
+	if *showVersion {
+		info := version.Get()
+		fmt.Printf("Lightning-rod (Go) version %s (commit %s, built %s, %s)\n", info.Version, info.Commit, info.BuiltAt, info.GoVersion)
 		os.Exit(0)
 	}
 
 	// Setup logging
 	setupLogging(*logLevel)
+	configuredLevel := log.GetLevel()
 
 	// Print banner
 	printBanner()
 
 	log.Infof("Lightning-rod:")
-	log.Infof(" - version: %s", Version)
+	log.Infof(" - version: %s (%s)", version.Version, version.Commit)
 	log.Infof(" - PID: %d", os.Getpid())
 	log.Infof(" - Config: %s", *configPath)
 
@@ -61,9 +68,30 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if *settingsPath != "" {
+		cfg.SetSettingsFile(*settingsPath)
+	}
+
 	log.Infof(" - Home: %s", cfg.LightningRod.Home)
+	log.Infof(" - Settings: %s", cfg.SettingsFile())
 	log.Infof(" - Log level: %s", cfg.LightningRod.LogLevel)
 
+	// Make sure home and its state/log directories exist
+	if err := config.EnsureDirs(cfg); err != nil {
+		log.Fatalf("Failed to prepare directories: %v", err)
+	}
+
+	// Mirror logs to the configured log file
+	if cfg.LightningRod.LogFile != "" {
+		logFile, err := logfile.Open(cfg.LightningRod.LogFile)
+		if err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+		defer logFile.Close()
+		log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+		log.Infof(" - Log file: %s", cfg.LightningRod.LogFile)
+	}
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -74,9 +102,9 @@ func main() {
 		log.Fatalf("Failed to create Lightning Rod: %v", err)
 	}
 
-	// Handle OS signals for graceful shutdown
+	// Handle OS signals for graceful shutdown and log level changes
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2)
 
 	// Start Lightning Rod in a goroutine
 	errChan := make(chan error, 1)
@@ -84,15 +112,43 @@ func main() {
 		errChan <- lr.Start(ctx)
 	}()
 
-	// Wait for shutdown signal or error
-	select {
-	case <-sigChan:
-		log.Info("Received shutdown signal, stopping Lightning Rod...")
-		cancel()
-		lr.Stop()
-	case err := <-errChan:
-		if err != nil {
-			log.Fatalf("Lightning Rod error: %v", err)
+	// Wait for shutdown signal or error; the graceful stop runs in the
+	// background so further signals can still escalate it
+	var stopped chan struct{}
+wait:
+	for {
+		select {
+		case sig := <-sigChan:
+			if handleLogSignal(sig, configuredLevel) {
+				continue
+			}
+			switch shutdownAction(sig, stopped != nil) {
+			case shutdownGraceful:
+				log.Infof("Received %v, stopping Lightning Rod (send it again to force exit)...", sig)
+				stopped = make(chan struct{})
+				go func() {
+					cancel()
+					lr.Stop()
+					close(stopped)
+				}()
+			case shutdownForce:
+				log.Warnf("Received %v during shutdown, exiting immediately", sig)
+				os.Exit(1)
+			case shutdownDump:
+				log.Warnf("Received %v, dumping goroutines and exiting immediately", sig)
+				pprof.Lookup("goroutine").WriteTo(os.Stderr, 2)
+				os.Exit(2)
+			}
+		case <-stopped:
+			break wait
+		case err := <-errChan:
+			if err != nil {
+				log.Fatalf("Lightning Rod error: %v", err)
+			}
+			if stopped == nil {
+				break wait
+			}
+			// Start returns once cancelled; wait for the stop to finish
 		}
 	}
 
@@ -118,6 +174,51 @@ func setupLogging(level string) {
 	}
 }
 
+// handleLogSignal applies a log level signal and reports whether sig was
+// one: SIGUSR1 toggles debug logging, SIGUSR2 restores the configured level
+func handleLogSignal(sig os.Signal, configured log.Level) bool {
+	var level log.Level
+	switch sig {
+	case syscall.SIGUSR1:
+		level = log.DebugLevel
+		if log.GetLevel() == log.DebugLevel {
+			level = configured
+			if level == log.DebugLevel {
+				level = log.InfoLevel
+			}
+		}
+	case syscall.SIGUSR2:
+		level = configured
+	default:
+		return false
+	}
+
+	log.SetLevel(level)
+	log.Warnf("Received %v, log level set to %s", sig, level)
+	return true
+}
+
+// Shutdown paths
+const (
+	shutdownGraceful = iota
+	shutdownForce
+	shutdownDump
+)
+
+// shutdownAction picks the shutdown path for sig: SIGQUIT dumps the
+// goroutines and exits at once, while SIGINT and SIGTERM stop gracefully
+// unless a graceful stop is already under way, in which case they force
+// an immediate exit
+func shutdownAction(sig os.Signal, stopping bool) int {
+	if sig == syscall.SIGQUIT {
+		return shutdownDump
+	}
+	if stopping {
+		return shutdownForce
+	}
+	return shutdownGraceful
+}
+
 func printBanner() {
 	banner := `
 ╔═══════════════════════════════════════════════════════════════╗
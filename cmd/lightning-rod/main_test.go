@@ -0,0 +1,80 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestHandleLogSignal(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured log.Level
+		current    log.Level
+		sig        os.Signal
+		want       log.Level
+		handled    bool
+	}{
+		{"usr1 enables debug", log.InfoLevel, log.InfoLevel, syscall.SIGUSR1, log.DebugLevel, true},
+		{"usr1 toggles back", log.WarnLevel, log.DebugLevel, syscall.SIGUSR1, log.WarnLevel, true},
+		{"usr1 leaves debug config", log.DebugLevel, log.DebugLevel, syscall.SIGUSR1, log.InfoLevel, true},
+		{"usr2 restores", log.WarnLevel, log.DebugLevel, syscall.SIGUSR2, log.WarnLevel, true},
+		{"term ignored", log.InfoLevel, log.DebugLevel, syscall.SIGTERM, log.DebugLevel, false},
+	}
+
+	saved := log.GetLevel()
+	t.Cleanup(func() { log.SetLevel(saved) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log.SetLevel(tt.current)
+			if handled := handleLogSignal(tt.sig, tt.configured); handled != tt.handled {
+				t.Errorf("handled = %v, want %v", handled, tt.handled)
+			}
+			if got := log.GetLevel(); got != tt.want {
+				t.Errorf("level = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShutdownAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		sig      os.Signal
+		stopping bool
+		want     int
+	}{
+		{"first term", syscall.SIGTERM, false, shutdownGraceful},
+		{"first interrupt", os.Interrupt, false, shutdownGraceful},
+		{"second term", syscall.SIGTERM, true, shutdownForce},
+		{"second interrupt", os.Interrupt, true, shutdownForce},
+		{"quit", syscall.SIGQUIT, false, shutdownDump},
+		{"quit while stopping", syscall.SIGQUIT, true, shutdownDump},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shutdownAction(tt.sig, tt.stopping); got != tt.want {
+				t.Errorf("shutdownAction(%v, %v) = %d, want %d", tt.sig, tt.stopping, got, tt.want)
+			}
+		})
+	}
+}
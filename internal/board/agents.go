@@ -0,0 +1,105 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// lastAgentKey is the extra entry holding the URL of the last WAMP agent
+// the board connected to
+const lastAgentKey = "last_wamp_agent"
+
+// WampAgents returns the WAMP agents to try, in order: the selected agent
+// and, when it is the main agent, the failover main-agents. The agent
+// last connected to goes first, so restarts do not reshuffle the fleet.
+func (b *Board) WampAgents() []config.WampAgent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.WampConfig == nil {
+		return nil
+	}
+
+	agents := []config.WampAgent{*b.WampConfig}
+	wampCfg := b.settings.Iotronic.WAMP
+	if b.WampConfig == wampCfg.MainAgent {
+		for _, a := range wampCfg.MainAgents {
+			if a != nil && a.URL != "" && !containsAgent(agents, a.URL) {
+				agents = append(agents, *a)
+			}
+		}
+	}
+
+	last, _ := b.Extra[lastAgentKey].(string)
+	for i, a := range agents {
+		if a.URL == last && i > 0 {
+			reordered := append([]config.WampAgent{a}, agents[:i]...)
+			agents = append(reordered, agents[i+1:]...)
+			break
+		}
+	}
+
+	return agents
+}
+
+// SecondaryAgents returns the agents of the secondary clouds the board
+// also reports to; none unless configured
+func (b *Board) SecondaryAgents() []config.WampAgent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var agents []config.WampAgent
+	for _, a := range b.settings.Iotronic.WAMP.SecondaryAgents {
+		if a != nil && a.URL != "" && a.Realm != "" {
+			agents = append(agents, *a)
+		}
+	}
+	return agents
+}
+
+func containsAgent(agents []config.WampAgent, url string) bool {
+	for _, a := range agents {
+		if a.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// SetActiveAgent records agent as the one the board is connected to, for
+// GetWampURL and GetWampRealm, and persists it as the last good agent when
+// it changed. A failure to save is logged: the connection stands anyway.
+func (b *Board) SetActiveAgent(agent config.WampAgent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.activeAgent = &agent
+
+	previous, had := b.Extra[lastAgentKey]
+	if previous == agent.URL {
+		return
+	}
+	if err := b.setExtra(lastAgentKey, agent.URL); err != nil {
+		if had {
+			b.Extra[lastAgentKey] = previous
+		} else {
+			delete(b.Extra, lastAgentKey)
+		}
+		log.Warnf("Failed to persist last WAMP agent %s: %v", agent.URL, err)
+	}
+}
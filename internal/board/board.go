@@ -16,11 +16,13 @@
 package board
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -51,6 +53,9 @@ type Board struct {
 	// WAMP configuration
 	WampConfig *config.WampAgent
 
+	// activeAgent is the agent connected to, if it differs from WampConfig
+	activeAgent *config.WampAgent
+
 	// Configuration
 	cfg      *config.Config
 	settings *config.BoardSettings
@@ -76,12 +81,13 @@ func (b *Board) LoadSettings() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	settings, err := config.LoadBoardSettings(b.cfg.LightningRod.Home)
+	settings, err := config.LoadBoardSettings(b.cfg.SettingsFile(), b.cfg.Board.SettingsReadAttempts)
 	if err != nil {
 		return err
 	}
 
 	b.settings = settings
+	metrics.SetToCurrentTime(metrics.SettingsLoadedTimestamp, "Unix time the board settings were last loaded")
 
 	// Load board configuration
 	boardCfg := settings.Iotronic.Board
@@ -97,33 +103,108 @@ func (b *Board) LoadSettings() error {
 	b.Location = boardCfg.Location
 	b.Extra = boardCfg.Extra
 
+	// settings.json may omit location and extra; keep the maps writable
+	if b.Location == nil {
+		b.Location = make(map[string]any)
+		settings.Iotronic.Board.Location = b.Location
+	}
+	if b.Extra == nil {
+		b.Extra = make(map[string]any)
+		settings.Iotronic.Board.Extra = b.Extra
+	}
+
 	log.Info("Board settings:")
 	log.Infof(" - code: %s", b.Code)
 	log.Infof(" - uuid: %s", b.UUID)
 
+	// Without a registration token, an unregistered board is identified by
+	// its hardware. The derived code is saved so it stays the same even if
+	// the hardware sources change later.
+	if derivesCode(b.Code, b.Status) {
+		b.useHardwareCode()
+	}
+
 	// Load WAMP configuration
 	b.loadWampConfig(settings)
 
 	// Handle first boot
 	if b.Code == "<REGISTRATION-TOKEN>" {
 		log.Info("FIRST BOOT procedure started")
-		b.Status = "first_boot"
+		if err := b.setStatus(StatusFirstBoot); err != nil {
+			log.Warnf("Keeping board status %s: %v", b.Status, err)
+		}
 	}
 
 	return nil
 }
 
+// derivesCode reports whether a board with code and status gets its code
+// from the hardware identifier on load
+func derivesCode(code, status string) bool {
+	return code == "" && (status == "" || status == StatusFirstBoot)
+}
+
+// useHardwareCode sets the board code to its hardware identifier, as a
+// first boot, and saves it (lock held)
+func (b *Board) useHardwareCode() {
+	id, err := b.HardwareID()
+	if err != nil {
+		log.Warnf("Board has no code and no hardware identifier: %v", err)
+		return
+	}
+
+	if err := b.setStatus(StatusFirstBoot); err != nil {
+		log.Warnf("Not deriving the board code from the hardware: %v", err)
+		return
+	}
+
+	log.Infof("Board has no code, using hardware identifier %s", id)
+	b.Code = id
+	b.settings.Iotronic.Board.Code = id
+	b.settings.Iotronic.Board.Status = StatusFirstBoot
+	if err := config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings); err != nil {
+		log.Warnf("Failed to save the board code derived from the hardware: %v", err)
+	}
+}
+
+// selectWampAgent picks the WAMP agent to connect to for the given board
+// status: the main agent when configured, otherwise the registration agent
+// for boards that still have to register. The returned status is the one
+// the board should move to.
+func selectWampAgent(settings *config.BoardSettings, status string) (*config.WampAgent, string, error) {
+	wampCfg := settings.Iotronic.WAMP
+
+	if wampCfg.MainAgent != nil {
+		return wampCfg.MainAgent, status, nil
+	}
+
+	switch status {
+	case "", "registered", "first_boot":
+		if wampCfg.RegistrationAgent == nil {
+			return nil, status, fmt.Errorf("neither main-agent nor registration-agent is configured")
+		}
+		return wampCfg.RegistrationAgent, status, nil
+	default:
+		return nil, "first_boot", fmt.Errorf("no main-agent configured for board in status %q", status)
+	}
+}
+
 func (b *Board) loadWampConfig(settings *config.BoardSettings) {
-	if settings.Iotronic.WAMP.MainAgent != nil {
-		b.WampConfig = settings.Iotronic.WAMP.MainAgent
+	agent, status, err := selectWampAgent(settings, b.Status)
+	if err := b.setStatus(status); err != nil {
+		log.Warnf("Keeping board status %s: %v", b.Status, err)
+	}
+	b.activeAgent = nil
+	if err != nil {
+		log.Errorf("WAMP Agent configuration is wrong (%v)... please check settings.json", err)
+		return
+	}
+
+	b.WampConfig = agent
+	if agent == settings.Iotronic.WAMP.MainAgent {
 		log.Info("WAMP Agent settings:")
-	} else if b.Status == "" || b.Status == "registered" || b.Status == "first_boot" {
-		b.WampConfig = settings.Iotronic.WAMP.RegistrationAgent
-		log.Info("Registration Agent settings:")
 	} else {
-		log.Error("WAMP Agent configuration is wrong... please check settings.json")
-		b.Status = "first_boot"
-		return
+		log.Info("Registration Agent settings:")
 	}
 
 	log.Infof(" - agent: %s", b.Agent)
@@ -131,15 +212,47 @@ func (b *Board) loadWampConfig(settings *config.BoardSettings) {
 	log.Infof(" - realm: %s", b.WampConfig.Realm)
 }
 
-// UpdateStatus updates the board status and saves to file
+// UpdateStatus updates the board status and saves to file, rejecting
+// transitions that are not allowed from the current status
 func (b *Board) UpdateStatus(status string) error {
 	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	b.Status = status
+	if err := b.setStatus(status); err != nil {
+		b.mu.Unlock()
+		return err
+	}
 	b.settings.Iotronic.Board.Status = status
 
-	return config.SaveBoardSettings(b.cfg.LightningRod.Home, b.settings)
+	err := config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings)
+	b.mu.Unlock()
+
+	b.changed("status")
+	return err
+}
+
+// GetType returns the board type
+func (b *Board) GetType() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.Type
+}
+
+// SetType changes the board type and saves the settings, keeping the
+// previous type if they cannot be saved
+func (b *Board) SetType(boardType string) error {
+	b.mu.Lock()
+	old := b.Type
+	b.settings.Iotronic.Board.Type = boardType
+	if err := config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings); err != nil {
+		b.settings.Iotronic.Board.Type = old
+		b.mu.Unlock()
+		return err
+	}
+	b.Type = boardType
+	b.mu.Unlock()
+
+	b.changed("type")
+	return nil
 }
 
 // SetUpdateTime updates the board's updated_at timestamp
@@ -151,42 +264,80 @@ func (b *Board) SetUpdateTime() error {
 	b.UpdatedAt = timestamp
 	b.settings.Iotronic.Board.UpdatedAt = timestamp
 
-	return config.SaveBoardSettings(b.cfg.LightningRod.Home, b.settings)
+	return config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings)
 }
 
-// SetConfig updates the entire board configuration
+// SetConfig updates the entire board configuration. Invalid settings are
+// rejected with a *ValidationError before anything is written.
 func (b *Board) SetConfig(newSettings *config.BoardSettings) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	if err := ValidateSettings(newSettings); err != nil {
+		return err
+	}
 
-	if err := config.SaveBoardSettings(b.cfg.LightningRod.Home, newSettings); err != nil {
+	b.mu.Lock()
+	// A pushed status is a transition like any other; settings that keep
+	// the saved one are not changing it
+	if to := newSettings.Iotronic.Board.Status; to != b.settings.Iotronic.Board.Status {
+		if err := checkTransition(b.Status, to); err != nil {
+			b.mu.Unlock()
+			return &ValidationError{Fields: []FieldError{{Path: "iotronic.board.status", Reason: err.Error()}}}
+		}
+	}
+	err := config.SaveBoardSettings(b.cfg.SettingsFile(), newSettings)
+	b.mu.Unlock()
+	if err != nil {
 		return err
 	}
 
 	// Reload settings
-	b.mu.Unlock()
-	err := b.LoadSettings()
-	b.mu.Lock()
+	if err := b.LoadSettings(); err != nil {
+		return err
+	}
 
-	return err
+	b.changed("settings")
+	return nil
 }
 
-// GetWampURL returns the WAMP connection URL
+// Settings returns a copy of the cached board settings, decoded as JSON
+func (b *Board) Settings() (map[string]any, error) {
+	b.mu.RLock()
+	data, err := json.Marshal(b.settings)
+	b.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// GetWampURL returns the URL of the WAMP agent connected to, or of the
+// selected one before the first connection
 func (b *Board) GetWampURL() string {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	if b.activeAgent != nil {
+		return b.activeAgent.URL
+	}
 	if b.WampConfig != nil {
 		return b.WampConfig.URL
 	}
 	return ""
 }
 
-// GetWampRealm returns the WAMP realm
+// GetWampRealm returns the realm of the WAMP agent connected to, or of the
+// selected one before the first connection
 func (b *Board) GetWampRealm() string {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
+	if b.activeAgent != nil {
+		return b.activeAgent.Realm
+	}
 	if b.WampConfig != nil {
 		return b.WampConfig.Realm
 	}
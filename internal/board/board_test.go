@@ -0,0 +1,168 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
+)
+
+func TestSelectWampAgent(t *testing.T) {
+	main := &config.WampAgent{URL: "wss://main:8181/", Realm: "s4t"}
+	registration := &config.WampAgent{URL: "wss://registration:8181/", Realm: "s4t"}
+
+	tests := []struct {
+		name       string
+		main, reg  *config.WampAgent
+		status     string
+		want       *config.WampAgent
+		wantStatus string
+		wantErr    bool
+	}{
+		{"registered with main agent", main, registration, StatusRegistered, main, StatusRegistered, false},
+		{"online with main agent only", main, nil, StatusOnline, main, StatusOnline, false},
+		{"registered", nil, registration, StatusRegistered, registration, StatusRegistered, false},
+		{"first boot", nil, registration, StatusFirstBoot, registration, StatusFirstBoot, false},
+		{"empty status", nil, registration, "", registration, "", false},
+		{"first boot without agents", nil, nil, StatusFirstBoot, nil, StatusFirstBoot, true},
+		{"empty status without agents", nil, nil, "", nil, "", true},
+		{"online without main agent", nil, registration, StatusOnline, nil, StatusFirstBoot, true},
+		{"invalid status", nil, registration, "bogus", nil, StatusFirstBoot, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &config.BoardSettings{}
+			settings.Iotronic.WAMP.MainAgent = tt.main
+			settings.Iotronic.WAMP.RegistrationAgent = tt.reg
+
+			agent, status, err := selectWampAgent(settings, tt.status)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if agent != tt.want {
+				t.Errorf("agent = %+v, want %+v", agent, tt.want)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAlternateSettingsFile(t *testing.T) {
+	home := t.TempDir()
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = home
+	cfg.Board.SettingsFile = filepath.Join(t.TempDir(), "board.json")
+	cfg.Board.SettingsReadAttempts = 1
+
+	settings := &config.BoardSettings{}
+	settings.Iotronic.Board.UUID = "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11"
+	settings.Iotronic.Board.Code = "TESTCODE"
+	settings.Iotronic.Board.Status = StatusRegistered
+	settings.Iotronic.WAMP.MainAgent = &config.WampAgent{URL: "ws://router.test:8181/", Realm: "s4t"}
+	if err := config.SaveBoardSettings(cfg.Board.SettingsFile, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if b.UUID != settings.Iotronic.Board.UUID {
+		t.Errorf("UUID = %q, want it read from %s", b.UUID, cfg.Board.SettingsFile)
+	}
+
+	if err := b.UpdateStatus(StatusOnline); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	saved, err := config.LoadBoardSettings(cfg.Board.SettingsFile, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Iotronic.Board.Status != StatusOnline {
+		t.Errorf("saved status = %q, want %q", saved.Iotronic.Board.Status, StatusOnline)
+	}
+	if _, err := os.Stat(filepath.Join(home, "settings.json")); !os.IsNotExist(err) {
+		t.Errorf("settings.json written to home: %v", err)
+	}
+}
+
+func TestLoadSettingsInitializesMaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		board     string
+		wantExtra int
+	}{
+		{"absent", `{"uuid": "u1", "code": "C1", "status": "registered"}`, 0},
+		{"null", `{"uuid": "u1", "code": "C1", "status": "registered", "location": null, "extra": null}`, 0},
+		{"present", `{"uuid": "u1", "code": "C1", "status": "registered", "location": {}, "extra": {"rack": "r2"}}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.LightningRod.Home = t.TempDir()
+			cfg.Board.SettingsReadAttempts = 1
+			settings := `{"iotronic": {"board": ` + tt.board + `, "wamp": {"main-agent": {"url": "ws://router.test:8181/", "realm": "s4t"}}}}`
+			if err := os.WriteFile(filepath.Join(cfg.LightningRod.Home, "settings.json"), []byte(settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if b.Location == nil || b.Extra == nil {
+				t.Fatalf("Location = %v, Extra = %v, want non-nil maps", b.Location, b.Extra)
+			}
+			if len(b.Extra) != tt.wantExtra {
+				t.Errorf("Extra = %v, want %d entries", b.Extra, tt.wantExtra)
+			}
+
+			// Writing into the maps must not panic
+			b.Location["latitude"] = 38.19
+			b.Extra["note"] = "test"
+		})
+	}
+}
+
+func TestLoadSettingsSetsTimestamp(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	cfg.Board.SettingsReadAttempts = 1
+	settings := `{"iotronic": {"board": {"uuid": "u1", "code": "C1", "status": "registered"}, "wamp": {"main-agent": {"url": "ws://router.test:8181/", "realm": "s4t"}}}}`
+	if err := os.WriteFile(filepath.Join(cfg.LightningRod.Home, "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	metrics.SetGauge(metrics.SettingsLoadedTimestamp, "", 0)
+	before := float64(time.Now().Unix())
+	if err := b.LoadSettings(); err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if got, _ := metrics.Gauge(metrics.SettingsLoadedTimestamp); got < before {
+		t.Errorf("%s = %v after a reload, want at least %v", metrics.SettingsLoadedTimestamp, got, before)
+	}
+}
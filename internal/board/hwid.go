@@ -0,0 +1,123 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Hardware identifier sources
+const (
+	HardwareIDMachineID = "machine-id"
+	HardwareIDMAC       = "mac"
+	HardwareIDCPUSerial = "cpu-serial"
+)
+
+// Hardware identifier inputs, replaceable for testing
+var (
+	machineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+	cpuInfoFile    = "/proc/cpuinfo"
+	netInterfaces  = net.Interfaces
+)
+
+// HardwareID derives a stable board identifier from the first configured
+// hardware source that yields one
+func (b *Board) HardwareID() (string, error) {
+	return hardwareID(b.cfg.LightningRod.HardwareIDSources)
+}
+
+// hardwareID tries sources in order and returns the first identifier found
+func hardwareID(sources []string) (string, error) {
+	for _, source := range sources {
+		var id string
+		switch source {
+		case HardwareIDMachineID:
+			id = readMachineID()
+		case HardwareIDMAC:
+			id = readMAC()
+		case HardwareIDCPUSerial:
+			id = readCPUSerial()
+		default:
+			return "", fmt.Errorf("unknown hardware id source %q", source)
+		}
+
+		if id != "" {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("no hardware identifier found (sources: %v)", sources)
+}
+
+// readMachineID returns the systemd/dbus machine id
+func readMachineID() string {
+	for _, path := range machineIDFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// readMAC returns the MAC address of the first non-loopback interface in
+// name order, without separators
+func readMAC() string {
+	ifaces, err := netInterfaces()
+	if err != nil {
+		return ""
+	}
+
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].Name < ifaces[j].Name })
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+			continue
+		}
+		return strings.ReplaceAll(iface.HardwareAddr.String(), ":", "")
+	}
+	return ""
+}
+
+// readCPUSerial returns the "Serial" field of /proc/cpuinfo, as exposed on
+// ARM boards such as the Raspberry Pi
+func readCPUSerial() string {
+	f, err := os.Open(cpuInfoFile)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(key) != "Serial" {
+			continue
+		}
+		serial := strings.TrimLeft(strings.TrimSpace(value), "0")
+		if serial != "" {
+			return serial
+		}
+	}
+	return ""
+}
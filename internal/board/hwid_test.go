@@ -0,0 +1,127 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// withHardware replaces the hardware identifier inputs for the test; an
+// empty value makes that source yield nothing
+func withHardware(t *testing.T, machineID, cpuinfo string, ifaces []net.Interface) {
+	t.Helper()
+
+	dir := t.TempDir()
+	origFiles, origCPU, origIfaces := machineIDFiles, cpuInfoFile, netInterfaces
+	t.Cleanup(func() { machineIDFiles, cpuInfoFile, netInterfaces = origFiles, origCPU, origIfaces })
+
+	machineIDFiles = []string{filepath.Join(dir, "machine-id")}
+	if machineID != "" {
+		if err := os.WriteFile(machineIDFiles[0], []byte(machineID+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cpuInfoFile = filepath.Join(dir, "cpuinfo")
+	if err := os.WriteFile(cpuInfoFile, []byte(cpuinfo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	netInterfaces = func() ([]net.Interface, error) { return ifaces, nil }
+}
+
+func TestHardwareID(t *testing.T) {
+	mac, _ := net.ParseMAC("b8:27:eb:12:34:56")
+	ifaces := []net.Interface{
+		{Name: "lo", Flags: net.FlagLoopback, HardwareAddr: net.HardwareAddr{0, 0, 0, 0, 0, 0}},
+		{Name: "wlan0", HardwareAddr: mac},
+	}
+	cpuinfo := "processor\t: 0\nHardware\t: BCM2835\nSerial\t\t: 00000000abcdef12\n"
+
+	tests := []struct {
+		name      string
+		machineID string
+		cpuinfo   string
+		ifaces    []net.Interface
+		sources   []string
+		want      string
+		wantErr   bool
+	}{
+		{"machine id first", "4c4c4544", cpuinfo, ifaces, []string{"machine-id", "mac", "cpu-serial"}, "4c4c4544", false},
+		{"falls back to mac", "", cpuinfo, ifaces, []string{"machine-id", "mac"}, "b827eb123456", false},
+		{"cpu serial", "4c4c4544", cpuinfo, ifaces, []string{"cpu-serial"}, "abcdef12", false},
+		{"loopback only", "", "", ifaces[:1], []string{"machine-id", "mac", "cpu-serial"}, "", true},
+		{"unknown source", "4c4c4544", "", nil, []string{"dmi"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withHardware(t, tt.machineID, tt.cpuinfo, tt.ifaces)
+			got, err := hardwareID(tt.sources)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("hardwareID(%v) = %q, %v; want %q", tt.sources, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHardwareCodeFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		wantCode string
+	}{
+		{"unregistered", "", "4c4c4544"},
+		{"first boot", "first_boot", "4c4c4544"},
+		{"registered", "registered", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withHardware(t, "4c4c4544", "", nil)
+
+			dir := t.TempDir()
+			cfg := &config.Config{}
+			cfg.LightningRod.HardwareIDSources = []string{"machine-id"}
+			cfg.Board.SettingsFile = filepath.Join(dir, "settings.json")
+			settings := strings.NewReplacer(`"TESTCODE"`, `""`, `"registered"`, `"`+tt.status+`"`).Replace(testSettings)
+			if err := os.WriteFile(cfg.Board.SettingsFile, []byte(settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New() = %v", err)
+			}
+			if b.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", b.Code, tt.wantCode)
+			}
+
+			saved, err := config.LoadBoardSettings(cfg.Board.SettingsFile, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Iotronic.Board.Code != tt.wantCode {
+				t.Errorf("saved code = %q, want %q", saved.Iotronic.Board.Code, tt.wantCode)
+			}
+			if tt.wantCode != "" && (b.Status != StatusFirstBoot || saved.Iotronic.Board.Status != StatusFirstBoot) {
+				t.Errorf("status = %q (saved %q), want first_boot", b.Status, saved.Iotronic.Board.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+)
+
+// Info is a consistent copy of the board identity and state
+type Info struct {
+	UUID      string         `json:"uuid"`
+	Code      string         `json:"code"`
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Status    string         `json:"status"`
+	Mobile    bool           `json:"mobile"`
+	Agent     string         `json:"agent"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+	Location  map[string]any `json:"location"`
+	Extra     map[string]any `json:"extra"`
+}
+
+// Info returns a snapshot of the board, safe to use while the board is
+// being modified
+func (b *Board) Info() Info {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return Info{
+		UUID:      b.UUID,
+		Code:      b.Code,
+		Name:      b.Name,
+		Type:      b.Type,
+		Status:    b.Status,
+		Mobile:    b.Mobile,
+		Agent:     b.Agent,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+		Location:  copyMap(b.Location),
+		Extra:     copyMap(b.Extra),
+	}
+}
+
+// copyMap deep-copies the nested maps and slices of a decoded JSON value
+func copyMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = copyValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// changed announces a change of the board info (must be called without
+// the lock held)
+func (b *Board) changed(field string) {
+	eventbus.Publish(eventbus.Default, eventbus.BoardChanged, eventbus.BoardChange{Field: field})
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// MaxNameLen is the longest board name accepted by SetName
+const MaxNameLen = 255
+
+// validateName checks a board name
+func validateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("board name must not be empty")
+	}
+	if len(name) > MaxNameLen {
+		return fmt.Errorf("board name longer than %d bytes", MaxNameLen)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("board name contains control characters")
+	}
+	return nil
+}
+
+// GetName returns the board name
+func (b *Board) GetName() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.Name
+}
+
+// SetName renames the board and saves the settings, keeping the previous
+// name if they cannot be saved. It returns the previous name.
+func (b *Board) SetName(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	old := b.Name
+	b.settings.Iotronic.Board.Name = name
+	if err := config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings); err != nil {
+		b.settings.Iotronic.Board.Name = old
+		b.mu.Unlock()
+		return "", err
+	}
+	b.Name = name
+	b.mu.Unlock()
+
+	b.changed("name")
+	return old, nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"fmt"
+)
+
+// PluginConfigKey is the extra entry holding the plugin settings.
+//
+// Namespaces only keep the keys of different plugins from colliding; they
+// are not an access boundary. Anyone able to call the config RPCs can read
+// and change every namespace, and the settings are stored in clear in
+// settings.json, so plugins must not keep secrets there.
+const PluginConfigKey = "plugin_config"
+
+// validatePluginConfigName checks a plugin namespace or key
+func validatePluginConfigName(kind, name string) error {
+	if len(name) == 0 || len(name) > MaxTagKeyLen || !tagKeyPattern.MatchString(name) {
+		return fmt.Errorf("invalid %s %q: 1-%d letters, digits, '_', '.' or '-'", kind, name, MaxTagKeyLen)
+	}
+	return nil
+}
+
+// pluginNamespace returns the settings of namespace, nil if it has none
+// (lock held)
+func (b *Board) pluginNamespace(namespace string) map[string]any {
+	all, _ := b.Extra[PluginConfigKey].(map[string]any)
+	ns, _ := all[namespace].(map[string]any)
+	return ns
+}
+
+// withPluginNamespace saves the plugin settings with namespace changed by
+// change. The change is applied to a copy, so the settings in memory stay
+// as they were if the save fails (lock held).
+func (b *Board) withPluginNamespace(namespace string, change func(ns map[string]any)) error {
+	all, _ := copyValue(b.Extra[PluginConfigKey]).(map[string]any)
+	if all == nil {
+		all = make(map[string]any)
+	}
+	ns, _ := all[namespace].(map[string]any)
+	if ns == nil {
+		ns = make(map[string]any)
+	}
+
+	change(ns)
+	if len(ns) == 0 {
+		delete(all, namespace)
+	} else {
+		all[namespace] = ns
+	}
+
+	return b.setExtra(PluginConfigKey, all)
+}
+
+// PluginConfig returns a copy of the settings of a plugin namespace
+func (b *Board) PluginConfig(namespace string) (map[string]any, error) {
+	if err := validatePluginConfigName("namespace", namespace); err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	out := make(map[string]any)
+	for k, v := range b.pluginNamespace(namespace) {
+		out[k] = copyValue(v)
+	}
+	return out, nil
+}
+
+// SetPluginConfig sets a key of a plugin namespace and saves the settings
+func (b *Board) SetPluginConfig(namespace, key string, value any) error {
+	if err := validatePluginConfigName("namespace", namespace); err != nil {
+		return err
+	}
+	if err := validatePluginConfigName("key", key); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.withPluginNamespace(namespace, func(ns map[string]any) {
+		ns[key] = value
+	})
+}
+
+// DeletePluginConfig removes a key of a plugin namespace and saves the
+// settings. It reports whether the key existed.
+func (b *Board) DeletePluginConfig(namespace, key string) (bool, error) {
+	if err := validatePluginConfigName("namespace", namespace); err != nil {
+		return false, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.pluginNamespace(namespace)[key]; !ok {
+		return false, nil
+	}
+
+	return true, b.withPluginNamespace(namespace, func(ns map[string]any) {
+		delete(ns, key)
+	})
+}
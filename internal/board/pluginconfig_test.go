@@ -0,0 +1,102 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPluginConfig(t *testing.T) {
+	b, _ := newTestBoard(t)
+
+	if err := b.SetPluginConfig("weather", "interval", float64(60)); err != nil {
+		t.Fatalf("SetPluginConfig() = %v", err)
+	}
+	if err := b.SetPluginConfig("weather", "unit", "C"); err != nil {
+		t.Fatalf("SetPluginConfig() = %v", err)
+	}
+	if err := b.SetPluginConfig("camera", "fps", float64(5)); err != nil {
+		t.Fatalf("SetPluginConfig() = %v", err)
+	}
+
+	// The same key in another namespace does not collide
+	if err := b.SetPluginConfig("camera", "unit", "px"); err != nil {
+		t.Fatalf("SetPluginConfig() = %v", err)
+	}
+	if existed, err := b.DeletePluginConfig("camera", "unit"); err != nil || !existed {
+		t.Fatalf("DeletePluginConfig() = %v, %v", existed, err)
+	}
+
+	got, err := b.PluginConfig("weather")
+	if err != nil || !reflect.DeepEqual(got, map[string]any{"interval": float64(60), "unit": "C"}) {
+		t.Errorf("PluginConfig(weather) = %v, %v", got, err)
+	}
+
+	// Reloading from disk gives the same settings
+	if err := b.LoadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := b.PluginConfig("camera"); !reflect.DeepEqual(got, map[string]any{"fps": float64(5)}) {
+		t.Errorf("PluginConfig(camera) after reload = %v", got)
+	}
+
+	existed, err := b.DeletePluginConfig("camera", "fps")
+	if err != nil || !existed {
+		t.Fatalf("DeletePluginConfig() = %v, %v", existed, err)
+	}
+	if existed, _ := b.DeletePluginConfig("camera", "fps"); existed {
+		t.Error("DeletePluginConfig() found a deleted key")
+	}
+	all, _ := b.Info().Extra[PluginConfigKey].(map[string]any)
+	if _, ok := all["camera"]; ok {
+		t.Error("empty namespace kept after its last key was deleted")
+	}
+
+	for _, name := range []string{"", "bad name", "../x"} {
+		if err := b.SetPluginConfig(name, "k", 1); err == nil {
+			t.Errorf("SetPluginConfig(%q) accepted an invalid namespace", name)
+		}
+		if err := b.SetPluginConfig("ns", name, 1); err == nil {
+			t.Errorf("SetPluginConfig(key %q) accepted an invalid key", name)
+		}
+	}
+}
+
+func TestPluginConfigRollsBackOnSaveFailure(t *testing.T) {
+	b, cfg := newTestBoard(t)
+	if err := b.SetPluginConfig("weather", "unit", "C"); err != nil {
+		t.Fatal(err)
+	}
+	breakSave(t, cfg)
+
+	if err := b.SetPluginConfig("weather", "unit", "F"); err == nil {
+		t.Fatal("SetPluginConfig() succeeded with an unwritable settings file")
+	}
+	if err := b.SetPluginConfig("camera", "fps", 5); err == nil {
+		t.Fatal("SetPluginConfig() succeeded with an unwritable settings file")
+	}
+	if _, err := b.DeletePluginConfig("weather", "unit"); err == nil {
+		t.Fatal("DeletePluginConfig() succeeded with an unwritable settings file")
+	}
+
+	if got, _ := b.PluginConfig("weather"); !reflect.DeepEqual(got, map[string]any{"unit": "C"}) {
+		t.Errorf("PluginConfig(weather) = %v after failed saves", got)
+	}
+	if got, _ := b.PluginConfig("camera"); len(got) != 0 {
+		t.Errorf("PluginConfig(camera) = %v after a failed save", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// Extra keys holding the last reboot triggered by the agent
+const (
+	lastRebootReasonKey = "last_reboot_reason"
+	lastRebootAtKey     = "last_reboot_at"
+)
+
+// Reboot reasons
+const (
+	RebootRPC          = "rpc"
+	RebootWatchdog     = "watchdog"
+	RebootConfigChange = "config_change"
+)
+
+// RebootReasons are the reasons RecordReboot accepts
+var RebootReasons = []string{RebootRPC, RebootWatchdog, RebootConfigChange}
+
+// RebootRecord describes the last reboot triggered by the agent
+type RebootRecord struct {
+	Reason string `json:"reason"`
+	At     string `json:"at"`
+}
+
+// RecordReboot saves the reason of a reboot about to be triggered, so it
+// can be reported after the next boot. The settings are left unchanged if
+// they cannot be saved.
+func (b *Board) RecordReboot(reason string) error {
+	valid := false
+	for _, r := range RebootReasons {
+		valid = valid || r == reason
+	}
+	if !valid {
+		return fmt.Errorf("unknown reboot reason %q (expected one of %v)", reason, RebootReasons)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old := maps.Clone(b.Extra)
+	if b.Extra == nil {
+		b.Extra = make(map[string]any)
+	}
+	b.Extra[lastRebootReasonKey] = reason
+	b.Extra[lastRebootAtKey] = time.Now().Format("2006-01-02T15:04:05.000000")
+	b.settings.Iotronic.Board.Extra = b.Extra
+
+	if err := config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings); err != nil {
+		b.Extra = old
+		b.settings.Iotronic.Board.Extra = old
+		return err
+	}
+	return nil
+}
+
+// LastReboot returns the last reboot triggered by the agent, or nil if
+// none was recorded
+func (b *Board) LastReboot() *RebootRecord {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	reason, _ := b.Extra[lastRebootReasonKey].(string)
+	if reason == "" {
+		return nil
+	}
+	at, _ := b.Extra[lastRebootAtKey].(string)
+	return &RebootRecord{Reason: reason, At: at}
+}
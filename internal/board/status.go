@@ -0,0 +1,106 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Board statuses
+const (
+	StatusFirstBoot   = "first_boot"
+	StatusRegistering = "registering"
+	StatusRegistered  = "registered"
+	StatusOnline      = "online"
+	StatusOffline     = "offline"
+	StatusMaintenance = "maintenance"
+	StatusError       = "error"
+)
+
+// statusTransitions lists the statuses reachable from each status
+var statusTransitions = map[string][]string{
+	"":                {StatusFirstBoot, StatusRegistered},
+	StatusFirstBoot:   {StatusRegistering},
+	StatusRegistering: {StatusRegistered, StatusFirstBoot},
+	StatusRegistered:  {StatusOnline, StatusFirstBoot},
+	StatusOnline:      {StatusOffline, StatusMaintenance, StatusError},
+	StatusOffline:     {StatusOnline, StatusMaintenance, StatusError},
+	StatusMaintenance: {StatusOnline, StatusOffline},
+	StatusError:       {StatusOnline, StatusOffline, StatusFirstBoot},
+}
+
+// checkTransition returns an error if the board may not move from one
+// status to another. Staying in the same status is always allowed.
+func checkTransition(from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	next, known := statusTransitions[from]
+	if !known {
+		// A status this version does not know, e.g. one written by a newer
+		// release, may be left for any known status
+		if _, ok := statusTransitions[to]; ok && to != "" {
+			return nil
+		}
+		return fmt.Errorf("invalid board status transition %q -> %q (allowed: %v)", from, to, knownStatuses())
+	}
+
+	for _, s := range next {
+		if s == to {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid board status transition %q -> %q (allowed: %v)", from, to, next)
+}
+
+// AllowedNextStatuses returns the statuses the board may move to
+func (b *Board) AllowedNextStatuses() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	next, known := statusTransitions[b.Status]
+	if !known {
+		return knownStatuses()
+	}
+	next = append([]string(nil), next...)
+	sort.Strings(next)
+	return next
+}
+
+// knownStatuses returns every status a board may be in, sorted
+func knownStatuses() []string {
+	statuses := make([]string, 0, len(statusTransitions))
+	for s := range statusTransitions {
+		if s != "" {
+			statuses = append(statuses, s)
+		}
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// setStatus moves the board to status if the transition is allowed (lock
+// held)
+func (b *Board) setStatus(status string) error {
+	if err := checkTransition(b.Status, status); err != nil {
+		return err
+	}
+	b.Status = status
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"", StatusFirstBoot, true},
+		{StatusRegistered, StatusOnline, true},
+		{StatusOnline, StatusOnline, true},
+		{StatusOnline, StatusRegistering, false},
+		{StatusFirstBoot, StatusOnline, false},
+		{"retired", StatusOnline, true},
+		{"retired", StatusFirstBoot, true},
+		{"retired", "", false},
+		{"retired", "decommissioned", false},
+		{StatusOnline, "decommissioned", false},
+	}
+	for _, tt := range tests {
+		if err := checkTransition(tt.from, tt.to); (err == nil) != tt.ok {
+			t.Errorf("checkTransition(%q, %q) = %v, want ok %v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+}
+
+// loadStatusBoard loads a test board saved in status
+func loadStatusBoard(t *testing.T, status string) (*Board, *config.Config) {
+	t.Helper()
+	b, cfg := newTestBoard(t)
+	settings := strings.Replace(testSettings, `"registered"`, `"`+status+`"`, 1)
+	if err := os.WriteFile(cfg.SettingsFile(), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.LoadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	return b, cfg
+}
+
+func TestUpdateStatusFromUnknown(t *testing.T) {
+	b, cfg := loadStatusBoard(t, "retired")
+
+	if got := b.AllowedNextStatuses(); !reflect.DeepEqual(got, knownStatuses()) {
+		t.Errorf("AllowedNextStatuses() = %v, want every known status", got)
+	}
+	if err := b.UpdateStatus(StatusOnline); err != nil {
+		t.Fatalf("UpdateStatus(online) from an unknown status = %v", err)
+	}
+
+	saved, err := config.LoadBoardSettings(cfg.SettingsFile(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Iotronic.Board.Status != StatusOnline {
+		t.Errorf("saved status = %q, want online", saved.Iotronic.Board.Status)
+	}
+
+	if err := b.UpdateStatus(StatusRegistering); err == nil {
+		t.Error("UpdateStatus(registering) from online succeeded")
+	}
+	if b.Status != StatusOnline {
+		t.Errorf("status = %q after a rejected transition", b.Status)
+	}
+}
+
+func TestSetConfigChecksStatus(t *testing.T) {
+	b, _ := loadStatusBoard(t, StatusOnline)
+
+	settings, err := config.LoadBoardSettings(b.cfg.SettingsFile(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keeping the saved status is not a transition
+	settings.Iotronic.Board.Name = "renamed"
+	if err := b.SetConfig(settings); err != nil {
+		t.Fatalf("SetConfig() keeping the status = %v", err)
+	}
+
+	settings.Iotronic.Board.Status = StatusFirstBoot
+	err = b.SetConfig(settings)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 1 || invalid.Fields[0].Path != "iotronic.board.status" {
+		t.Fatalf("SetConfig(online -> first_boot) = %v, want a status field error", err)
+	}
+	if b.Status != StatusOnline {
+		t.Errorf("status = %q after a rejected push", b.Status)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// Tag limits
+const (
+	tagsKey        = "tags"
+	MaxTags        = 64
+	MaxTagKeyLen   = 63
+	MaxTagValueLen = 255
+)
+
+var tagKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// validateTag checks a tag key and value
+func validateTag(key, value string) error {
+	if len(key) == 0 || len(key) > MaxTagKeyLen || !tagKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q: 1-%d letters, digits, '_', '.' or '-'", key, MaxTagKeyLen)
+	}
+	if len(value) > MaxTagValueLen {
+		return fmt.Errorf("value of tag %s longer than %d bytes", key, MaxTagValueLen)
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return fmt.Errorf("value of tag %s contains control characters", key)
+	}
+	return nil
+}
+
+// SetExtra sets an entry of the board extra metadata and saves the settings
+func (b *Board) SetExtra(key string, value any) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.setExtra(key, value)
+}
+
+// setExtra sets an extra entry and saves the settings, restoring the
+// previous entry if the save fails (lock held)
+func (b *Board) setExtra(key string, value any) error {
+	if b.Extra == nil {
+		b.Extra = make(map[string]any)
+	}
+	old, existed := b.Extra[key]
+	b.Extra[key] = value
+	b.settings.Iotronic.Board.Extra = b.Extra
+
+	if err := config.SaveBoardSettings(b.cfg.SettingsFile(), b.settings); err != nil {
+		if existed {
+			b.Extra[key] = old
+		} else {
+			delete(b.Extra, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// tags returns a copy of the board tags (lock held)
+func (b *Board) tags() map[string]string {
+	tags := make(map[string]string)
+	raw, _ := b.Extra[tagsKey].(map[string]any)
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			tags[k] = s
+		}
+	}
+	return tags
+}
+
+// Tags returns the board tags
+func (b *Board) Tags() map[string]string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.tags()
+}
+
+// SetTags merges tags into the board tags, or replaces them all if replace
+// is set, and saves the settings. When merging, an empty value removes the
+// tag. It returns the resulting tags.
+func (b *Board) SetTags(tags map[string]string, replace bool) (map[string]string, error) {
+	for k, v := range tags {
+		if err := validateTag(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := make(map[string]string)
+	if !replace {
+		result = b.tags()
+	}
+	for k, v := range tags {
+		if v == "" && !replace {
+			delete(result, k)
+			continue
+		}
+		result[k] = v
+	}
+
+	if len(result) > MaxTags {
+		return nil, fmt.Errorf("too many tags: %d (max %d)", len(result), MaxTags)
+	}
+
+	// Stored as map[string]any, the shape it has after a JSON round trip
+	stored := make(map[string]any, len(result))
+	for k, v := range result {
+		stored[k] = v
+	}
+	if err := b.setExtra(tagsKey, stored); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
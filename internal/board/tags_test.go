@@ -0,0 +1,88 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+const testSettings = `{
+  "iotronic": {
+    "board": {
+      "uuid": "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11",
+      "code": "TESTCODE",
+      "status": "registered",
+      "extra": {"tags": {"site": "lab"}}
+    },
+    "wamp": {"main-agent": {"url": "ws://router.test:8181/", "realm": "s4t"}}
+  }
+}`
+
+// newTestBoard loads a board from a settings file in a temporary directory
+func newTestBoard(t *testing.T) (*Board, *config.Config) {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = dir
+	cfg.Board.SettingsFile = filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(cfg.Board.SettingsFile, []byte(testSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	return b, cfg
+}
+
+// breakSave makes every later save of the settings file fail
+func breakSave(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	path := cfg.SettingsFile()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0750); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetTagsRollsBackOnSaveFailure(t *testing.T) {
+	b, cfg := newTestBoard(t)
+	before := b.Info().Extra
+	breakSave(t, cfg)
+
+	if _, err := b.SetTags(map[string]string{"rack": "r1"}, false); err == nil {
+		t.Fatal("SetTags() succeeded with an unwritable settings file")
+	}
+	if err := b.SetExtra("note", "x"); err == nil {
+		t.Fatal("SetExtra() succeeded with an unwritable settings file")
+	}
+
+	if got := b.Tags(); !reflect.DeepEqual(got, map[string]string{"site": "lab"}) {
+		t.Errorf("Tags() = %v after a failed save", got)
+	}
+	if got := b.Info().Extra; !reflect.DeepEqual(got, before) {
+		t.Errorf("extra = %v after a failed save, want %v", got, before)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// FieldError is a problem with one field of the board settings, located
+// by its JSON path
+type FieldError struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError lists every problem found in rejected board settings
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		problems[i] = f.Path + ": " + f.Reason
+	}
+	return "invalid board settings: " + strings.Join(problems, "; ")
+}
+
+// settingsValidator collects the problems found in board settings
+type settingsValidator struct {
+	fields []FieldError
+}
+
+func (v *settingsValidator) fail(path, format string, args ...any) {
+	v.fields = append(v.fields, FieldError{Path: path, Reason: fmt.Sprintf(format, args...)})
+}
+
+// ValidateSettings checks board settings before they replace the ones in
+// use, returning a *ValidationError listing every problem found
+func ValidateSettings(s *config.BoardSettings) error {
+	if s == nil {
+		return &ValidationError{Fields: []FieldError{{Path: "iotronic", Reason: "missing"}}}
+	}
+
+	var v settingsValidator
+	v.board(&s.Iotronic.Board)
+	v.wamp(&s.Iotronic.WAMP)
+
+	if len(v.fields) > 0 {
+		return &ValidationError{Fields: v.fields}
+	}
+	return nil
+}
+
+func (v *settingsValidator) board(b *config.BoardConfig) {
+	const prefix = "iotronic.board."
+
+	if strings.TrimSpace(b.UUID) == "" {
+		v.fail(prefix+"uuid", "required")
+	}
+	switch {
+	case derivesCode(b.Code, b.Status):
+		// Derived from the hardware identifier on load
+	case strings.TrimSpace(b.Code) == "":
+		v.fail(prefix+"code", "required")
+	}
+	// An empty status is taken as first boot on load
+	if _, known := statusTransitions[b.Status]; !known {
+		v.fail(prefix+"status", "unknown status %q", b.Status)
+	}
+	if b.Name != "" {
+		if err := validateName(b.Name); err != nil {
+			v.fail(prefix+"name", "%v", err)
+		}
+	}
+
+	if raw, ok := b.Extra[tagsKey]; ok {
+		tags, ok := raw.(map[string]any)
+		if !ok {
+			v.fail(prefix+"extra.tags", "expected an object of strings")
+			return
+		}
+		if len(tags) > MaxTags {
+			v.fail(prefix+"extra.tags", "more than %d tags", MaxTags)
+		}
+		for key, value := range tags {
+			s, ok := value.(string)
+			if !ok {
+				v.fail(prefix+"extra.tags."+key, "expected a string")
+				continue
+			}
+			if err := validateTag(key, s); err != nil {
+				v.fail(prefix+"extra.tags."+key, "%v", err)
+			}
+		}
+	}
+}
+
+func (v *settingsValidator) wamp(w *config.WampConfiguration) {
+	const prefix = "iotronic.wamp."
+
+	if w.MainAgent == nil && w.RegistrationAgent == nil {
+		v.fail(prefix+"main-agent", "neither main-agent nor registration-agent is configured")
+	}
+	v.agent(prefix+"main-agent", w.MainAgent)
+	v.agent(prefix+"registration-agent", w.RegistrationAgent)
+	for i, a := range w.MainAgents {
+		v.agent(fmt.Sprintf("%smain-agents[%d]", prefix, i), a)
+	}
+	for i, a := range w.SecondaryAgents {
+		v.agent(fmt.Sprintf("%ssecondary-agents[%d]", prefix, i), a)
+	}
+}
+
+// agent checks a WAMP agent at path; a nil agent is not configured
+func (v *settingsValidator) agent(path string, a *config.WampAgent) {
+	if a == nil {
+		return
+	}
+
+	u, err := url.Parse(a.URL)
+	switch {
+	case a.URL == "":
+		v.fail(path+".url", "required")
+	case err != nil:
+		v.fail(path+".url", "invalid URL: %v", err)
+	case u.Scheme != "ws" && u.Scheme != "wss":
+		v.fail(path+".url", "scheme must be ws or wss, got %q", u.Scheme)
+	case u.Hostname() == "":
+		v.fail(path+".url", "missing host")
+	}
+
+	if strings.TrimSpace(a.Realm) == "" {
+		v.fail(path+".realm", "required")
+	}
+}
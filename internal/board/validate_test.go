@@ -0,0 +1,91 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package board
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestValidateSettingsCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		status string
+		valid  bool
+	}{
+		{"code", "C1", StatusRegistered, true},
+		{"derived on first load", "", "", true},
+		{"derived at first boot", "", StatusFirstBoot, true},
+		{"missing once registered", "", StatusRegistered, false},
+		{"blank", "  ", StatusFirstBoot, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &config.BoardSettings{}
+			s.Iotronic.Board = config.BoardConfig{UUID: "u1", Code: tt.code, Status: tt.status}
+			s.Iotronic.WAMP.MainAgent = &config.WampAgent{URL: "ws://router.test:8181/", Realm: "s4t"}
+
+			err := ValidateSettings(s)
+			if tt.valid {
+				if err != nil {
+					t.Errorf("ValidateSettings = %v, want valid", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Path != "iotronic.board.code" {
+				t.Errorf("ValidateSettings = %v, want only iotronic.board.code rejected", err)
+			}
+		})
+	}
+}
+
+func TestSetConfigDerivedCode(t *testing.T) {
+	withHardware(t, "4c4c4544", "", nil)
+
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.LightningRod.HardwareIDSources = []string{"machine-id"}
+	cfg.Board.SettingsFile = filepath.Join(dir, "settings.json")
+	unregistered := strings.NewReplacer(`"TESTCODE"`, `""`, `"registered"`, `""`).Replace(testSettings)
+	if err := os.WriteFile(cfg.Board.SettingsFile, []byte(unregistered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	// Settings pushed without a code keep relying on the hardware
+	pushed, err := config.LoadBoardSettings(cfg.Board.SettingsFile, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushed.Iotronic.Board.Code = ""
+	pushed.Iotronic.Board.Name = "renamed"
+	if err := b.SetConfig(pushed); err != nil {
+		t.Fatalf("SetConfig without a code at first boot = %v", err)
+	}
+	if info := b.Info(); info.Code != "4c4c4544" || info.Name != "renamed" {
+		t.Errorf("board = %+v, want the hardware code and the new name", info)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package clock measures the offset of the local clock against an NTP
+// server. It only reports the skew, it never adjusts the clock.
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// OffsetGauge is the metric holding the last measured offset in seconds
+const OffsetGauge = "lr_clock_offset_seconds"
+
+const (
+	ntpPort       = "123"
+	ntpPacketSize = 48
+	queryTimeout  = 5 * time.Second
+
+	// Seconds between the NTP epoch (1900) and the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+)
+
+// queryOffset is the offset source, replaceable for testing
+var queryOffset = ntpOffset
+
+// Status is the outcome of the last clock check
+type Status struct {
+	Server    string  `json:"server"`
+	OffsetMs  float64 `json:"offset_ms"`
+	Skewed    bool    `json:"skewed"`
+	CheckedAt string  `json:"checked_at,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Checker periodically measures the clock offset against an NTP server
+type Checker struct {
+	server    string
+	interval  time.Duration
+	threshold time.Duration
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// NewChecker creates a checker for server, measuring every interval and
+// warning when the offset exceeds threshold
+func NewChecker(server string, interval, threshold time.Duration) *Checker {
+	return &Checker{
+		server:    server,
+		interval:  interval,
+		threshold: threshold,
+		status:    Status{Server: server},
+	}
+}
+
+// Run checks the clock immediately and then every interval until ctx is
+// cancelled
+func (c *Checker) Run(ctx context.Context) {
+	c.Check(ctx)
+	if c.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Check measures the clock offset once and records the result
+func (c *Checker) Check(ctx context.Context) Status {
+	status := Status{
+		Server:    c.server,
+		CheckedAt: time.Now().Format("2006-01-02T15:04:05.000000"),
+	}
+
+	offset, err := queryOffset(ctx, c.server)
+	if err != nil {
+		log.Warnf("Clock check against %s failed: %v", c.server, err)
+		status.Error = err.Error()
+	} else {
+		status.OffsetMs = float64(offset.Microseconds()) / 1000
+		status.Skewed = offset.Abs() > c.threshold
+		metrics.SetGauge(OffsetGauge, "Offset of the local clock from the NTP server in seconds", offset.Seconds())
+
+		if status.Skewed {
+			log.Warnf("Clock is off by %v from %s (threshold %v), timestamps may be wrong", offset, c.server, c.threshold)
+		} else {
+			log.Debugf("Clock offset from %s: %v", c.server, offset)
+		}
+	}
+
+	c.mu.Lock()
+	c.status = status
+	c.mu.Unlock()
+
+	return status
+}
+
+// Status returns the result of the last clock check
+func (c *Checker) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.status
+}
+
+// ntpOffset sends an SNTP request to server and returns how far the local
+// clock is behind the server's (negative if ahead)
+func ntpOffset(ctx context.Context, server string) (time.Duration, error) {
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, ntpPort)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// LI 0, version 3, mode 3 (client)
+	req := make([]byte, ntpPacketSize)
+	req[0] = 0x1B
+	t1 := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTPTime(t1))
+
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, ntpPacketSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	t4 := time.Now()
+
+	if n < ntpPacketSize {
+		return 0, fmt.Errorf("short NTP response: %d bytes", n)
+	}
+	if mode := resp[0] & 0x07; mode != 4 {
+		return 0, fmt.Errorf("unexpected NTP mode %d", mode)
+	}
+	if resp[1] == 0 {
+		return 0, fmt.Errorf("NTP server sent kiss-of-death")
+	}
+	if binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]) {
+		return 0, fmt.Errorf("NTP response does not match request")
+	}
+
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+
+	return (t2.Sub(t1) + t3.Sub(t4)) / 2, nil
+}
+
+// toNTPTime converts t to the 64-bit NTP timestamp format
+func toNTPTime(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// fromNTPTime converts a 64-bit NTP timestamp to a time
+func fromNTPTime(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nanos := (ts & 0xffffffff) * uint64(time.Second) >> 32
+	return time.Unix(secs, int64(nanos))
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package clock
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
+)
+
+func TestCheck(t *testing.T) {
+	orig := queryOffset
+	t.Cleanup(func() { queryOffset = orig })
+
+	tests := []struct {
+		name       string
+		offset     time.Duration
+		err        error
+		wantMs     float64
+		wantSkewed bool
+	}{
+		{"in sync", 12 * time.Millisecond, nil, 12, false},
+		{"behind", 3 * time.Second, nil, 3000, true},
+		{"ahead", -2500 * time.Millisecond, nil, -2500, true},
+		{"at threshold", time.Second, nil, 1000, false},
+		{"unreachable", 0, errors.New("i/o timeout"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queried string
+			queryOffset = func(_ context.Context, server string) (time.Duration, error) {
+				queried = server
+				return tt.offset, tt.err
+			}
+			metrics.SetGauge(OffsetGauge, "", 42)
+
+			c := NewChecker("ntp.test", time.Minute, time.Second)
+			status := c.Check(context.Background())
+			if queried != "ntp.test" {
+				t.Errorf("queried %q, want ntp.test", queried)
+			}
+			if status != c.Status() {
+				t.Errorf("Status() = %+v, want the last check %+v", c.Status(), status)
+			}
+			if status.Server != "ntp.test" || status.CheckedAt == "" {
+				t.Errorf("status = %+v", status)
+			}
+			if status.OffsetMs != tt.wantMs || status.Skewed != tt.wantSkewed {
+				t.Errorf("offset = %vms skewed %v, want %vms skewed %v", status.OffsetMs, status.Skewed, tt.wantMs, tt.wantSkewed)
+			}
+
+			gauge, _ := metrics.Gauge(OffsetGauge)
+			if tt.err != nil {
+				if status.Error == "" {
+					t.Error("failed check reported no error")
+				}
+				if gauge != 42 {
+					t.Errorf("gauge = %v after a failed check, want it untouched", gauge)
+				}
+				return
+			}
+			if status.Error != "" {
+				t.Errorf("error = %q", status.Error)
+			}
+			if gauge != tt.offset.Seconds() {
+				t.Errorf("gauge = %v, want %v", gauge, tt.offset.Seconds())
+			}
+		})
+	}
+}
+
+func TestRunChecksPeriodically(t *testing.T) {
+	orig := queryOffset
+	t.Cleanup(func() { queryOffset = orig })
+
+	checks := make(chan struct{}, 8)
+	queryOffset = func(context.Context, string) (time.Duration, error) {
+		checks <- struct{}{}
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewChecker("ntp.test", 10*time.Millisecond, time.Second).Run(ctx)
+		close(done)
+	}()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-checks:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d checks ran", i)
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+// fakeNTPServer answers SNTP requests as a server whose clock is offset
+// from the local one; reply, if not nil, alters each response
+func fakeNTPServer(t *testing.T, offset time.Duration, reply func(resp []byte)) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		req := make([]byte, ntpPacketSize)
+		for {
+			n, addr, err := conn.ReadFrom(req)
+			if err != nil {
+				return
+			}
+			if n < ntpPacketSize {
+				continue
+			}
+			now := toNTPTime(time.Now().Add(offset))
+			resp := make([]byte, ntpPacketSize)
+			resp[0] = 0x1C // version 3, mode 4 (server)
+			resp[1] = 2    // stratum
+			copy(resp[24:32], req[40:48])
+			binary.BigEndian.PutUint64(resp[32:], now)
+			binary.BigEndian.PutUint64(resp[40:], now)
+			if reply != nil {
+				reply(resp)
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestNTPOffset(t *testing.T) {
+	for _, offset := range []time.Duration{0, 2 * time.Second, -90 * time.Second} {
+		server := fakeNTPServer(t, offset, nil)
+		got, err := ntpOffset(context.Background(), server)
+		if err != nil {
+			t.Fatalf("ntpOffset with a %v offset: %v", offset, err)
+		}
+		if diff := (got - offset).Abs(); diff > 100*time.Millisecond {
+			t.Errorf("ntpOffset = %v, want about %v", got, offset)
+		}
+	}
+}
+
+func TestNTPOffsetRejectsBadResponses(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply func(resp []byte)
+	}{
+		{"client mode", func(resp []byte) { resp[0] = 0x1B }},
+		{"kiss of death", func(resp []byte) { resp[1] = 0 }},
+		{"wrong origin", func(resp []byte) { resp[31]++ }},
+	}
+	for _, tt := range tests {
+		server := fakeNTPServer(t, 0, tt.reply)
+		if offset, err := ntpOffset(context.Background(), server); err == nil {
+			t.Errorf("%s: ntpOffset = %v, want an error", tt.name, offset)
+		}
+	}
+
+	// Nobody answers: the query gives up when ctx does
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if offset, err := ntpOffset(ctx, silent.LocalAddr().String()); err == nil {
+		t.Errorf("ntpOffset against a silent server = %v, want an error", offset)
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+	for _, want := range []time.Time{
+		time.Unix(0, 0),
+		time.Date(2024, 5, 1, 10, 30, 0, 250_000_000, time.UTC),
+	} {
+		got := fromNTPTime(toNTPTime(want))
+		if diff := got.Sub(want).Abs(); diff > time.Microsecond {
+			t.Errorf("round trip of %v = %v", want, got)
+		}
+	}
+}
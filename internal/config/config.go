@@ -20,7 +20,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
@@ -34,37 +39,146 @@ type Config struct {
 	Autobahn     AutobahnConfig     `mapstructure:"autobahn"`
 	Services     ServicesConfig     `mapstructure:"services"`
 	WebServices  WebServicesConfig  `mapstructure:"webservices"`
+	Audit        AuditConfig        `mapstructure:"audit"`
+	RPC          RPCConfig          `mapstructure:"rpc"`
+	Board        BoardOptions       `mapstructure:"board"`
+	REST         RESTConfig         `mapstructure:"rest"`
+	Commands     CommandsConfig     `mapstructure:"commands"`
+	Telemetry    TelemetryConfig    `mapstructure:"telemetry"`
+
+	// file is the configuration file path and sources records, for every
+	// key, whether its value came from a flag, the environment, the file or
+	// the defaults
+	file     string
+	settings map[string]any
+	sources  map[string]string
 }
 
 // LightningRodConfig contains core Lightning Rod settings
 type LightningRodConfig struct {
 	Home           string `mapstructure:"home"`
+	RuntimeDir     string `mapstructure:"runtime_dir"`
+	SettingsDir    string `mapstructure:"settings_dir"`
+	StateDir       string `mapstructure:"state_dir"`
 	LogLevel       string `mapstructure:"log_level"`
 	LogFile        string `mapstructure:"log_file"`
 	SkipCertVerify bool   `mapstructure:"skip_cert_verify"`
+
+	HardwareIDSources []string `mapstructure:"hardware_id_sources"`
+	DefaultDeviceType string   `mapstructure:"default_device_type"`
+	StateBackend      string   `mapstructure:"state_backend"`
+
+	NTPServer        string `mapstructure:"ntp_server"`
+	NTPCheckInterval int    `mapstructure:"ntp_check_interval"`
+	MaxClockSkew     int    `mapstructure:"max_clock_skew"`
+
+	FirstBootHook         string `mapstructure:"first_boot_hook"`
+	FirstBootHookTimeout  int    `mapstructure:"first_boot_hook_timeout"`
+	FirstBootHookRequired bool   `mapstructure:"first_boot_hook_required"`
 }
 
 // AutobahnConfig contains WAMP/Autobahn settings
 type AutobahnConfig struct {
 	ConnectionTimer        int `mapstructure:"connection_timer"`
 	AliveTimer             int `mapstructure:"alive_timer"`
+	KeepaliveInterval      int `mapstructure:"keepalive_interval"`
 	RPCAliveTimer          int `mapstructure:"rpc_alive_timer"`
 	ConnectionFailureTimer int `mapstructure:"connection_failure_timer"`
+
+	RPCPrefix string `mapstructure:"rpc_prefix"`
+
+	ClientCert string `mapstructure:"client_cert"`
+	ClientKey  string `mapstructure:"client_key"`
 }
 
 // ServicesConfig contains service manager settings
 type ServicesConfig struct {
-	WstunBin string `mapstructure:"wstun_bin"`
+	WstunBin        string `mapstructure:"wstun_bin"`
+	WstunScheme     string `mapstructure:"wstun_scheme"`
+	StopGracePeriod int    `mapstructure:"stop_grace_period"`
+	Nice            int    `mapstructure:"nice"`
+	RlimitNofile    uint64 `mapstructure:"rlimit_nofile"`
+	RlimitAS        uint64 `mapstructure:"rlimit_as"`
+	HealthProbe     string `mapstructure:"health_probe"`
+	HealthTimeout   int    `mapstructure:"health_timeout"`
+	PublicBaseURL   string `mapstructure:"public_base_url"`
+
+	WstunExtraArgs []string `mapstructure:"wstun_extra_args"`
+	MaxTunnels     int      `mapstructure:"max_tunnels"`
+	SaveDelay      int      `mapstructure:"save_delay"`
+
+	TunnelBackend string `mapstructure:"tunnel_backend"`
+	ChiselBin     string `mapstructure:"chisel_bin"`
+
+	LogDir             string `mapstructure:"log_dir"`
+	LogBudget          int64  `mapstructure:"log_budget"`
+	LogCleanupInterval int    `mapstructure:"log_cleanup_interval"`
 }
 
 // WebServicesConfig contains webservice manager settings
 type WebServicesConfig struct {
-	Proxy string `mapstructure:"proxy"`
+	Proxy      string `mapstructure:"proxy"`
+	Mode       string `mapstructure:"mode"`
+	SharedPort int    `mapstructure:"shared_port"`
+	Staged     bool   `mapstructure:"staged"`
+
+	// PublicPortRange is the "low-high" range public ports are allocated
+	// from when EnableWebService is called without one
+	PublicPortRange string `mapstructure:"public_port_range"`
+}
+
+// BoardOptions contains board settings file options
+type BoardOptions struct {
+	SettingsFile         string `mapstructure:"settings_file"`
+	SettingsReadAttempts int    `mapstructure:"settings_read_attempts"`
+}
+
+// RESTConfig contains REST API server settings (timeouts in seconds)
+type RESTConfig struct {
+	ReadTimeout  int `mapstructure:"read_timeout"`
+	WriteTimeout int `mapstructure:"write_timeout"`
+	IdleTimeout  int `mapstructure:"idle_timeout"`
+
+	EnableUI bool `mapstructure:"enable_ui"`
+	ReadOnly bool `mapstructure:"read_only"`
+
+	APIKey         string   `mapstructure:"api_key"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// CommandsConfig contains ExecCommand settings
+type CommandsConfig struct {
+	AllowlistFile string `mapstructure:"allowlist_file"`
+	Timeout       int    `mapstructure:"timeout"`
+	ResultTopic   string `mapstructure:"result_topic"`
+}
+
+// TelemetryConfig contains periodic telemetry settings
+type TelemetryConfig struct {
+	Interval int  `mapstructure:"interval"`
+	Compress bool `mapstructure:"compress"`
+}
+
+// AuditConfig contains RPC auditing settings
+type AuditConfig struct {
+	Procedures []string `mapstructure:"procedures"`
+}
+
+// RPCConfig contains RPC handling settings. Concurrency limits bound the
+// in-flight invocations of each procedure of a module (0 = unlimited).
+type RPCConfig struct {
+	DeviceConcurrency     int  `mapstructure:"device_concurrency"`
+	ServiceConcurrency    int  `mapstructure:"service_concurrency"`
+	WebServiceConcurrency int  `mapstructure:"webservice_concurrency"`
+	RecoverPanics         bool `mapstructure:"recover_panics"`
+	MaxArgumentSize       int  `mapstructure:"max_argument_size"`
 }
 
 // BoardSettings represents the board configuration from settings.json
 type BoardSettings struct {
 	Iotronic IotronicSettings `json:"iotronic"`
+
+	Unknown UnknownFields `json:"-"`
 }
 
 // IotronicSettings contains IoTronic-specific board settings
@@ -72,6 +186,8 @@ type IotronicSettings struct {
 	Board BoardConfig       `json:"board"`
 	WAMP  WampConfiguration `json:"wamp"`
 	Extra map[string]any    `json:"extra"`
+
+	Unknown UnknownFields `json:"-"`
 }
 
 // BoardConfig contains board identification and status
@@ -87,18 +203,31 @@ type BoardConfig struct {
 	UpdatedAt string         `json:"updated_at"`
 	Location  map[string]any `json:"location"`
 	Extra     map[string]any `json:"extra"`
+
+	Unknown UnknownFields `json:"-"`
 }
 
 // WampConfiguration contains WAMP connection settings
 type WampConfiguration struct {
 	MainAgent         *WampAgent `json:"main-agent,omitempty"`
 	RegistrationAgent *WampAgent `json:"registration-agent,omitempty"`
+
+	// MainAgents are failover agents tried after main-agent
+	MainAgents []*WampAgent `json:"main-agents,omitempty"`
+
+	// SecondaryAgents are further clouds the board reports to alongside
+	// the main one, each with its own connection
+	SecondaryAgents []*WampAgent `json:"secondary-agents,omitempty"`
+
+	Unknown UnknownFields `json:"-"`
 }
 
 // WampAgent represents a WAMP agent connection
 type WampAgent struct {
 	URL   string `json:"url"`
 	Realm string `json:"realm"`
+
+	Unknown UnknownFields `json:"-"`
 }
 
 // Load loads configuration from file
@@ -108,9 +237,14 @@ func Load(configPath string) (*Config, error) {
 	// Set defaults
 	setDefaults(v)
 
+	// Every key can be overridden by IOTRONIC_<SECTION>_<KEY>
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
 	// Set config file path
 	v.SetConfigFile(configPath)
-	v.SetConfigType("ini")
+	v.SetConfigType(configType(configPath))
 
 	// Try to read config file
 	if err := v.ReadInConfig(); err != nil {
@@ -125,18 +259,170 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	metrics.SetToCurrentTime(metrics.ConfigLoadedTimestamp, "Unix time the agent configuration was last loaded")
+
+	config.file = configPath
+	config.settings = v.AllSettings()
+	config.sources = make(map[string]string)
+	for _, key := range v.AllKeys() {
+		switch {
+		case isEnvSet(key):
+			config.sources[key] = SourceEnv
+		case v.InConfig(key):
+			config.sources[key] = SourceFile
+		default:
+			config.sources[key] = SourceDefault
+		}
+	}
+
 	return &config, nil
 }
 
-// LoadBoardSettings loads board settings from settings.json
-func LoadBoardSettings(home string) (*BoardSettings, error) {
-	settingsPath := filepath.Join(home, "settings.json")
-	if home == "" {
-		settingsPath = DefaultSettingsFile
+// envPrefix prefixes the environment variables overriding configuration
+// keys
+const envPrefix = "IOTRONIC"
+
+// isEnvSet reports whether the environment overrides key
+func isEnvSet(key string) bool {
+	_, ok := os.LookupEnv(envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	return ok
+}
+
+// configType returns the format of the config file: JSON for .json files,
+// whose sections are nested objects ({"lightningrod": {"home": ...}}),
+// INI otherwise. Either way keys missing from the file keep their defaults.
+func configType(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return "json"
+	}
+	return "ini"
+}
+
+// RuntimeDir returns the directory of the state rebuilt at runtime
+// (services.json, tunnel logs), defaulting to home
+func (c *Config) RuntimeDir() string {
+	if c.LightningRod.RuntimeDir != "" {
+		return c.LightningRod.RuntimeDir
+	}
+	return c.LightningRod.Home
+}
+
+// SettingsDir returns the directory of the persistent settings
+// (settings.json, commands.allow), defaulting to home
+func (c *Config) SettingsDir() string {
+	if c.LightningRod.SettingsDir != "" {
+		return c.LightningRod.SettingsDir
+	}
+	return c.LightningRod.Home
+}
+
+// StateDir returns the directory for runtime state, defaulting to home/state
+func (c *Config) StateDir() string {
+	if c.LightningRod.StateDir != "" {
+		return c.LightningRod.StateDir
+	}
+	return filepath.Join(c.LightningRod.Home, "state")
+}
+
+// ServiceLogDir returns the directory of the tunnel client logs,
+// defaulting to <runtime_dir>/logs/services
+func (c *Config) ServiceLogDir() string {
+	if c.Services.LogDir != "" {
+		return c.Services.LogDir
+	}
+	return filepath.Join(c.RuntimeDir(), "logs", "services")
+}
+
+// CommandAllowlistFile returns the ExecCommand allowlist path, defaulting
+// to <settings_dir>/commands.allow
+func (c *Config) CommandAllowlistFile() string {
+	if c.Commands.AllowlistFile != "" {
+		return c.Commands.AllowlistFile
+	}
+	return filepath.Join(c.SettingsDir(), "commands.allow")
+}
+
+// ChildLimits returns the niceness and resource limits spawned children
+// run with
+func (c *Config) ChildLimits() proclimit.Limits {
+	return proclimit.Limits{
+		Nice:         c.Services.Nice,
+		RlimitNofile: c.Services.RlimitNofile,
+		RlimitAS:     c.Services.RlimitAS,
+	}
+}
+
+// EnsureDirs creates the home, runtime, settings, state and log
+// directories if missing
+func EnsureDirs(cfg *Config) error {
+	dirs := []string{cfg.LightningRod.Home, cfg.RuntimeDir(), cfg.SettingsDir(), cfg.StateDir()}
+	if cfg.LightningRod.LogFile != "" {
+		dirs = append(dirs, filepath.Dir(cfg.LightningRod.LogFile))
 	}
 
-	data, err := os.ReadFile(settingsPath)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// SettingsFile returns the board settings file, defaulting to
+// settings.json in settings_dir
+func (c *Config) SettingsFile() string {
+	if c.Board.SettingsFile != "" {
+		return c.Board.SettingsFile
+	}
+	if c.SettingsDir() == "" {
+		return DefaultSettingsFile
+	}
+	return filepath.Join(c.SettingsDir(), "settings.json")
+}
+
+// Settings read retry parameters, replaceable for testing
+var (
+	readSettingsFile   = os.ReadFile
+	settingsRetryDelay = 500 * time.Millisecond
+)
+
+// readSettingsWithRetry reads settingsPath, retrying up to attempts times
+// with exponential backoff on transient I/O errors. A missing file or a
+// permission error is returned immediately.
+func readSettingsWithRetry(settingsPath string, attempts int) ([]byte, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := settingsRetryDelay
+	for attempt := 1; ; attempt++ {
+		data, err := readSettingsFile(settingsPath)
+		if err == nil || os.IsNotExist(err) || os.IsPermission(err) || attempt >= attempts {
+			return data, err
+		}
+
+		log.Warnf("Reading %s failed (attempt %d/%d), retrying in %v: %v", settingsPath, attempt, attempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// LoadBoardSettings loads board settings from settingsPath, retrying
+// transient read errors up to attempts times
+func LoadBoardSettings(settingsPath string, attempts int) (*BoardSettings, error) {
+	data, err := readSettingsWithRetry(settingsPath, attempts)
 	if err != nil {
+		if os.IsNotExist(err) {
+			dir := filepath.Dir(settingsPath)
+			if _, statErr := os.Stat(dir); os.IsNotExist(statErr) {
+				return nil, fmt.Errorf("settings directory %s does not exist: %w", dir, err)
+			}
+			return nil, fmt.Errorf("settings file %s not found: %w", settingsPath, err)
+		}
 		return nil, fmt.Errorf("failed to read settings file: %w", err)
 	}
 
@@ -148,41 +434,143 @@ func LoadBoardSettings(home string) (*BoardSettings, error) {
 	return &settings, nil
 }
 
-// SaveBoardSettings saves board settings to settings.json
-func SaveBoardSettings(home string, settings *BoardSettings) error {
-	settingsPath := filepath.Join(home, "settings.json")
-	if home == "" {
-		settingsPath = DefaultSettingsFile
-	}
-
+// SaveBoardSettings saves board settings to settingsPath
+func SaveBoardSettings(settingsPath string, settings *BoardSettings) error {
 	data, err := json.MarshalIndent(settings, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+	if err := writeFileAtomic(settingsPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so readers never see a partially written file. A symlink
+// at path is followed, so the file it points to is replaced rather than
+// the link, and an existing file keeps its mode; perm only applies to new
+// files.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
 func setDefaults(v *viper.Viper) {
 	// Lightning Rod defaults
 	v.SetDefault("lightningrod.home", "/var/lib/iotronic")
+	v.SetDefault("lightningrod.runtime_dir", "")
+	v.SetDefault("lightningrod.settings_dir", "")
+	v.SetDefault("lightningrod.state_dir", "")
 	v.SetDefault("lightningrod.log_level", "info")
 	v.SetDefault("lightningrod.log_file", "")
 	v.SetDefault("lightningrod.skip_cert_verify", true)
+	v.SetDefault("lightningrod.hardware_id_sources", []string{"machine-id", "mac", "cpu-serial"})
+	v.SetDefault("lightningrod.default_device_type", "generic")
+	v.SetDefault("lightningrod.state_backend", "file")
+	v.SetDefault("lightningrod.ntp_server", "")
+	v.SetDefault("lightningrod.ntp_check_interval", 3600)
+	v.SetDefault("lightningrod.max_clock_skew", 5)
+	v.SetDefault("lightningrod.first_boot_hook", "")
+	v.SetDefault("lightningrod.first_boot_hook_timeout", 600)
+	v.SetDefault("lightningrod.first_boot_hook_required", true)
 
 	// Autobahn defaults
 	v.SetDefault("autobahn.connection_timer", 10)
 	v.SetDefault("autobahn.alive_timer", 600)
+	v.SetDefault("autobahn.keepalive_interval", 30)
 	v.SetDefault("autobahn.rpc_alive_timer", 3)
 	v.SetDefault("autobahn.connection_failure_timer", 600)
+	v.SetDefault("autobahn.rpc_prefix", "")
+	v.SetDefault("autobahn.client_cert", "")
+	v.SetDefault("autobahn.client_key", "")
 
 	// Services defaults
 	v.SetDefault("services.wstun_bin", "/usr/bin/wstun")
+	v.SetDefault("services.wstun_scheme", "")
+	v.SetDefault("services.stop_grace_period", 5)
+	v.SetDefault("services.nice", 0)
+	v.SetDefault("services.rlimit_nofile", 0)
+	v.SetDefault("services.rlimit_as", 0)
+	v.SetDefault("services.health_probe", "tcp")
+	v.SetDefault("services.health_timeout", 3)
+	v.SetDefault("services.public_base_url", "")
+	v.SetDefault("services.wstun_extra_args", []string{})
+	v.SetDefault("services.max_tunnels", 0)
+	v.SetDefault("services.save_delay", 2)
+	v.SetDefault("services.tunnel_backend", "wstun")
+	v.SetDefault("services.chisel_bin", "/usr/bin/chisel")
+	v.SetDefault("services.log_dir", "")
+	v.SetDefault("services.log_budget", 50<<20)
+	v.SetDefault("services.log_cleanup_interval", 3600)
 
 	// WebServices defaults
 	v.SetDefault("webservices.proxy", "nginx")
+	v.SetDefault("webservices.mode", "port")
+	v.SetDefault("webservices.shared_port", 80)
+	v.SetDefault("webservices.staged", false)
+	v.SetDefault("webservices.public_port_range", "")
+
+	// Board defaults
+	v.SetDefault("board.settings_file", "")
+	v.SetDefault("board.settings_read_attempts", 5)
+
+	// REST defaults
+	v.SetDefault("rest.read_timeout", 15)
+	v.SetDefault("rest.write_timeout", 30)
+	v.SetDefault("rest.idle_timeout", 60)
+	v.SetDefault("rest.enable_ui", true)
+	v.SetDefault("rest.read_only", true)
+	v.SetDefault("rest.api_key", "")
+	v.SetDefault("rest.trusted_proxies", []string{})
+
+	// Commands defaults
+	v.SetDefault("commands.allowlist_file", "")
+	v.SetDefault("commands.timeout", 30)
+	v.SetDefault("commands.result_topic", "")
+
+	// Telemetry defaults
+	v.SetDefault("telemetry.interval", 0)
+	v.SetDefault("telemetry.compress", false)
+
+	// Audit defaults
+	v.SetDefault("audit.procedures", []string{})
+
+	// RPC defaults
+	v.SetDefault("rpc.device_concurrency", 4)
+	v.SetDefault("rpc.service_concurrency", 2)
+	v.SetDefault("rpc.webservice_concurrency", 2)
+	v.SetDefault("rpc.recover_panics", true)
+	v.SetDefault("rpc.max_argument_size", 1048576)
 }
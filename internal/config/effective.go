@@ -0,0 +1,171 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package config
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Value sources, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// redactedValue replaces sensitive values
+const redactedValue = "********"
+
+// sensitiveKeys are the configuration keys whose values must not be
+// disclosed; a key added to Config that holds a secret or the location of
+// one belongs here
+var sensitiveKeys = []string{
+	"autobahn.client_cert",
+	"autobahn.client_key",
+	"rest.api_key",
+}
+
+// sensitiveNameParts flag the board settings keys, free-form under extra,
+// whose values must not be disclosed
+var sensitiveNameParts = []string{"secret", "password", "token", "api_key", "private_key", "ca_file", "ca_path", "cert_file", "key_file"}
+
+// Setting is a configuration value and where it came from
+type Setting struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// File returns the path the configuration was loaded from
+func (c *Config) File() string {
+	return c.file
+}
+
+// isSensitive reports whether the value of the board settings key must be
+// redacted
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns the effective configuration as section -> key ->
+// setting, with sensitive values and URL passwords redacted
+func (c *Config) Redacted() map[string]map[string]Setting {
+	out := make(map[string]map[string]Setting)
+	for section, values := range c.settings {
+		keys, ok := values.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		settings := make(map[string]Setting, len(keys))
+		for key, value := range keys {
+			if s, ok := value.(string); ok {
+				value = redactURL(s)
+			}
+			if slices.Contains(sensitiveKeys, section+"."+key) && value != "" {
+				value = redactedValue
+			}
+			settings[key] = Setting{
+				Value:  value,
+				Source: c.sources[section+"."+key],
+			}
+		}
+		out[section] = settings
+	}
+
+	return out
+}
+
+// sensitiveSettingKeys are board settings keys whose values must not be
+// disclosed besides the sensitive configuration keys: the board code
+// authenticates the board to Iotronic
+var sensitiveSettingKeys = []string{"code"}
+
+// override sets key, as section.key, to value from source, recording it as
+// the effective value
+func (c *Config) override(key string, value any, source string) {
+	section, name, _ := strings.Cut(key, ".")
+	if c.settings == nil {
+		c.settings = make(map[string]any)
+	}
+	values, ok := c.settings[section].(map[string]any)
+	if !ok {
+		values = make(map[string]any)
+		c.settings[section] = values
+	}
+	values[name] = value
+
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	c.sources[key] = source
+}
+
+// SetSettingsFile overrides board.settings_file, as the --settings flag
+// does
+func (c *Config) SetSettingsFile(path string) {
+	c.Board.SettingsFile = path
+	c.override("board.settings_file", path, SourceFlag)
+}
+
+// RedactSettings redacts, in place, the values of sensitive keys and the
+// passwords of URLs found anywhere in decoded board settings
+func RedactSettings(settings map[string]any) {
+	for key, value := range settings {
+		settings[key] = redactSetting(key, value)
+	}
+}
+
+// redactSetting returns value, stored under key, redacted; the elements
+// of a list are redacted as if stored under its key
+func redactSetting(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		RedactSettings(v)
+	case []any:
+		for i, e := range v {
+			v[i] = redactSetting(key, e)
+		}
+	case string:
+		if v != "" && (isSensitive(key) || slices.Contains(sensitiveSettingKeys, strings.ToLower(key))) {
+			return redactedValue
+		}
+		return redactURL(v)
+	}
+	return value
+}
+
+// redactURL hides the password of a URL with credentials, returning any
+// other string unchanged
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	u.User = url.UserPassword(u.User.Username(), redactedValue)
+	return u.String()
+}
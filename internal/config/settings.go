@@ -0,0 +1,155 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Settings written by newer clouds may carry members these structs do not
+// know yet. Each struct keeps them in Unknown when loaded and writes them
+// back when saved, so a save never strips forward-compatible data.
+
+// UnknownFields holds the JSON object members a settings struct does not
+// know, in their original encoding
+type UnknownFields map[string]json.RawMessage
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *BoardSettings) UnmarshalJSON(data []byte) error {
+	type plain BoardSettings
+	return unmarshalKeepingUnknown(data, (*plain)(s), &s.Unknown)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s BoardSettings) MarshalJSON() ([]byte, error) {
+	type plain BoardSettings
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *IotronicSettings) UnmarshalJSON(data []byte) error {
+	type plain IotronicSettings
+	return unmarshalKeepingUnknown(data, (*plain)(s), &s.Unknown)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s IotronicSettings) MarshalJSON() ([]byte, error) {
+	type plain IotronicSettings
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *BoardConfig) UnmarshalJSON(data []byte) error {
+	type plain BoardConfig
+	return unmarshalKeepingUnknown(data, (*plain)(s), &s.Unknown)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s BoardConfig) MarshalJSON() ([]byte, error) {
+	type plain BoardConfig
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *WampConfiguration) UnmarshalJSON(data []byte) error {
+	type plain WampConfiguration
+	return unmarshalKeepingUnknown(data, (*plain)(s), &s.Unknown)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s WampConfiguration) MarshalJSON() ([]byte, error) {
+	type plain WampConfiguration
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (a *WampAgent) UnmarshalJSON(data []byte) error {
+	type plain WampAgent
+	return unmarshalKeepingUnknown(data, (*plain)(a), &a.Unknown)
+}
+
+// MarshalJSON implements json.Marshaler
+func (a WampAgent) MarshalJSON() ([]byte, error) {
+	type plain WampAgent
+	return marshalWithUnknown(plain(a), a.Unknown)
+}
+
+// unmarshalKeepingUnknown decodes data into v, a pointer to a struct, and
+// stores the members v has no field for in unknown
+func unmarshalKeepingUnknown(data []byte, v any, unknown *UnknownFields) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		// null, which leaves v untouched
+		return nil
+	}
+	for name := range jsonFields(reflect.TypeOf(v).Elem()) {
+		delete(members, name)
+	}
+
+	*unknown = nil
+	if len(members) > 0 {
+		*unknown = members
+	}
+	return nil
+}
+
+// marshalWithUnknown encodes v, a struct, adding the unknown members it
+// has no field for
+func marshalWithUnknown(v any, unknown UnknownFields) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(unknown) == 0 {
+		return data, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	known := jsonFields(reflect.TypeOf(v))
+	for name, value := range unknown {
+		// A known field left out by omitempty stays out
+		if !known[name] {
+			members[name] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+// jsonFields returns the JSON member names of the fields of struct type t
+func jsonFields(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
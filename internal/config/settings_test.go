@@ -0,0 +1,164 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const futureSettings = `{
+  "schema_version": 3,
+  "iotronic": {
+    "board": {
+      "uuid": "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11",
+      "code": "TESTCODE",
+      "status": "registered",
+      "fleet": {"id": "f-1", "zone": "eu"}
+    },
+    "wamp": {
+      "main-agent": {"url": "ws://router.test:8181/", "realm": "s4t", "priority": 10},
+      "registration-agent": {"url": "ws://reg.test:8181/", "realm": "s4t"},
+      "transport": "rawsocket"
+    },
+    "extra": {},
+    "cloud": ["a", "b"]
+  }
+}`
+
+func TestSaveKeepsUnknownSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(futureSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadBoardSettings(path, 1)
+	if err != nil {
+		t.Fatalf("LoadBoardSettings: %v", err)
+	}
+	if settings.Iotronic.Board.Code != "TESTCODE" || settings.Iotronic.WAMP.MainAgent.Realm != "s4t" {
+		t.Fatalf("known fields not loaded: %+v", settings.Iotronic)
+	}
+
+	// Change known fields, including dropping one with omitempty
+	settings.Iotronic.Board.Status = "operative"
+	settings.Iotronic.WAMP.RegistrationAgent = nil
+	if err := SaveBoardSettings(path, settings); err != nil {
+		t.Fatalf("SaveBoardSettings: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved settings are invalid JSON: %v", err)
+	}
+
+	iotronic := saved["iotronic"].(map[string]any)
+	board := iotronic["board"].(map[string]any)
+	wamp := iotronic["wamp"].(map[string]any)
+	mainAgent := wamp["main-agent"].(map[string]any)
+
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"schema_version", saved["schema_version"], 3.0},
+		{"iotronic.cloud", iotronic["cloud"], []any{"a", "b"}},
+		{"board.fleet", board["fleet"], map[string]any{"id": "f-1", "zone": "eu"}},
+		{"board.status", board["status"], "operative"},
+		{"wamp.transport", wamp["transport"], "rawsocket"},
+		{"main-agent.priority", mainAgent["priority"], 10.0},
+		{"main-agent.url", mainAgent["url"], "ws://router.test:8181/"},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if _, ok := wamp["registration-agent"]; ok {
+		t.Error("cleared registration-agent came back on save")
+	}
+
+	// A second round trip is stable
+	again, err := LoadBoardSettings(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(again)
+	want, _ := json.Marshal(settings)
+	if string(got) != string(want) {
+		t.Errorf("reloaded settings = %s, want %s", got, want)
+	}
+}
+
+func TestUnknownFieldsOnlyWhenPresent(t *testing.T) {
+	var settings BoardSettings
+	if err := json.Unmarshal([]byte(`{"iotronic": {"board": {"uuid": "x"}}}`), &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Unknown != nil || settings.Iotronic.Unknown != nil || settings.Iotronic.Board.Unknown != nil {
+		t.Errorf("unexpected unknown fields: %v %v %v",
+			settings.Unknown, settings.Iotronic.Unknown, settings.Iotronic.Board.Unknown)
+	}
+}
+
+func TestWriteFileAtomicKeepsModeAndLink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(target, 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "settings.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic() = %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link replaced by a regular file (%v)", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "new" {
+		t.Errorf("target = %q (%v), want new", data, err)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("target mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	// New files get perm
+	fresh := filepath.Join(dir, "fresh.json")
+	if err := writeFileAtomic(fresh, []byte("x"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(fresh); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("new file mode = %v (%v), want 0640", info.Mode().Perm(), err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package eventbus lets modules publish and subscribe to in-process
+// events, independently of WAMP
+package eventbus
+
+import (
+	"sync"
+)
+
+// Topic names an event stream whose events carry a T
+type Topic[T any] struct {
+	name string
+}
+
+// NewTopic declares a topic
+func NewTopic[T any](name string) Topic[T] {
+	return Topic[T]{name: name}
+}
+
+// Name returns the topic name
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// WAMPState is published when the WAMP session is established or lost
+type WAMPState struct {
+	Connected bool
+	SessionID uint64
+}
+
+// ServiceStatus is published when a tunneled service changes status
+type ServiceStatus struct {
+	Name   string
+	Status string
+	PID    int
+}
+
+// BoardChange is published when a field of the board info changes
+type BoardChange struct {
+	Field string
+}
+
+// Known topics
+var (
+	WAMPStateChanged     = NewTopic[WAMPState]("wamp.state")
+	ServiceStatusChanged = NewTopic[ServiceStatus]("service.status")
+	BoardChanged         = NewTopic[BoardChange]("board.changed")
+)
+
+// Bus delivers published events to the subscribers of their topic
+type Bus struct {
+	mu   sync.RWMutex
+	next int
+	subs map[string]map[int]func(any)
+}
+
+// New creates an empty bus
+func New() *Bus {
+	return &Bus{subs: make(map[string]map[int]func(any))}
+}
+
+// Default is the process-wide bus shared by the modules
+var Default = New()
+
+// Subscribe registers fn for the events of topic on b and returns a
+// function that removes the subscription
+func Subscribe[T any](b *Bus, topic Topic[T], fn func(T)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	if b.subs[topic.name] == nil {
+		b.subs[topic.name] = make(map[int]func(any))
+	}
+	b.subs[topic.name][id] = func(v any) { fn(v.(T)) }
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[topic.name], id)
+	}
+}
+
+// Publish delivers event to every subscriber of topic on b, synchronously
+// and in the publisher's goroutine: subscribers must not block, and
+// publishers must not hold locks a subscriber could need
+func Publish[T any](b *Bus, topic Topic[T], event T) {
+	b.mu.RLock()
+	handlers := make([]func(any), 0, len(b.subs[topic.name]))
+	for _, fn := range b.subs[topic.name] {
+		handlers = append(handlers, fn)
+	}
+	b.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(event)
+	}
+}
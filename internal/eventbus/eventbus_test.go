@@ -0,0 +1,63 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package eventbus
+
+import (
+	"testing"
+)
+
+func TestPublishDeliversToAllSubscribers(t *testing.T) {
+	b := New()
+
+	var first, second []ServiceStatus
+	Subscribe(b, ServiceStatusChanged, func(e ServiceStatus) { first = append(first, e) })
+	Subscribe(b, ServiceStatusChanged, func(e ServiceStatus) { second = append(second, e) })
+
+	// Subscribers of other topics are not called
+	Subscribe(b, WAMPStateChanged, func(e WAMPState) { t.Errorf("unexpected WAMP event %+v", e) })
+
+	event := ServiceStatus{Name: "ssh", Status: "running", PID: 42}
+	Publish(b, ServiceStatusChanged, event)
+
+	for i, got := range [][]ServiceStatus{first, second} {
+		if len(got) != 1 || got[0] != event {
+			t.Errorf("subscriber %d got %+v, want [%+v]", i, got, event)
+		}
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	b := New()
+
+	var kept, dropped int
+	Subscribe(b, WAMPStateChanged, func(WAMPState) { kept++ })
+	unsubscribe := Subscribe(b, WAMPStateChanged, func(WAMPState) { dropped++ })
+
+	Publish(b, WAMPStateChanged, WAMPState{Connected: true})
+	unsubscribe()
+	Publish(b, WAMPStateChanged, WAMPState{Connected: false})
+
+	if kept != 2 {
+		t.Errorf("remaining subscriber called %d times, want 2", kept)
+	}
+	if dropped != 1 {
+		t.Errorf("unsubscribed subscriber called %d times, want 1", dropped)
+	}
+}
+
+func TestPublishWithoutSubscribers(t *testing.T) {
+	Publish(New(), WAMPStateChanged, WAMPState{})
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package lasterror retains the last error of a module so health output
+// can show what went wrong, not just that something did
+package lasterror
+
+import (
+	"sync"
+	"time"
+)
+
+// Error is the last failure of a module
+type Error struct {
+	Operation string    `json:"operation"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// Tracker holds the last error of a module until an operation succeeds.
+// The zero value is ready to use.
+type Tracker struct {
+	mu   sync.Mutex
+	last *Error
+}
+
+// now returns the current time, replaceable for testing
+var now = time.Now
+
+// New returns err as the failure of op, timestamped now
+func New(op string, err error) *Error {
+	return &Error{Operation: op, Message: err.Error(), At: now().UTC()}
+}
+
+// Record stores err as the last error, failing op
+func (t *Tracker) Record(op string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = New(op, err)
+}
+
+// Clear forgets the last error, the module having recovered
+func (t *Tracker) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = nil
+}
+
+// Resolve clears the last error if it is a failure of op, for periodic
+// operations whose success says nothing about the others
+func (t *Tracker) Resolve(op string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last != nil && t.last.Operation == op {
+		t.last = nil
+	}
+}
+
+// Observe records err if op failed and clears the last error otherwise.
+// It returns err unchanged.
+func (t *Tracker) Observe(op string, err error) error {
+	if err != nil {
+		t.Record(op, err)
+	} else {
+		t.Clear()
+	}
+	return err
+}
+
+// Last returns a copy of the last error, or nil if there is none
+func (t *Tracker) Last() *Error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.last == nil {
+		return nil
+	}
+	e := *t.last
+	return &e
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package lasterror
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrackerResolve(t *testing.T) {
+	var tr Tracker
+	tr.Record("telemetry", errors.New("not connected"))
+	tr.Resolve("telemetry")
+	if last := tr.Last(); last != nil {
+		t.Errorf("Last() after Resolve = %+v, want nil", last)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	orig := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = orig })
+
+	var tr Tracker
+	if last := tr.Last(); last != nil {
+		t.Fatalf("Last() = %+v, want nil", last)
+	}
+
+	boom := errors.New("boom")
+	if err := tr.Observe("enable web", boom); err != boom {
+		t.Errorf("Observe returned %v, want %v", err, boom)
+	}
+	want := Error{Operation: "enable web", Message: "boom", At: at}
+	if last := tr.Last(); last == nil || *last != want {
+		t.Fatalf("Last() = %+v, want %+v", last, want)
+	}
+
+	// A later failure replaces the earlier one
+	tr.Record("commit", errors.New("nginx rejected config"))
+	if last := tr.Last(); last == nil || last.Operation != "commit" {
+		t.Errorf("Last() = %+v, want the commit failure", last)
+	}
+
+	// Callers get a copy
+	tr.Last().Message = "changed"
+	if last := tr.Last(); last.Message != "nginx rejected config" {
+		t.Errorf("Last().Message = %q, modified through a copy", last.Message)
+	}
+
+	// Resolving another operation leaves the error in place
+	tr.Resolve("telemetry")
+	if last := tr.Last(); last == nil {
+		t.Fatal("Resolve of another operation cleared the last error")
+	}
+
+	tr.Observe("commit", nil)
+	if last := tr.Last(); last != nil {
+		t.Errorf("Last() after success = %+v, want nil", last)
+	}
+}
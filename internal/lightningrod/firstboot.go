@@ -0,0 +1,109 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package lightningrod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	log "github.com/sirupsen/logrus"
+)
+
+// firstBootMarker is written to the state directory once the first-boot
+// hook has succeeded
+const firstBootMarker = "first_boot_hook.done"
+
+// firstBootHook is a provisioning command run once, before registration
+type firstBootHook struct {
+	path     string
+	marker   string
+	timeout  time.Duration
+	required bool
+	limits   proclimit.Limits
+}
+
+// firstBootHook returns the hook configured for this instance
+func (lr *LightningRod) firstBootHook() firstBootHook {
+	return firstBootHook{
+		path:     lr.cfg.LightningRod.FirstBootHook,
+		marker:   filepath.Join(lr.cfg.StateDir(), firstBootMarker),
+		timeout:  time.Duration(lr.cfg.LightningRod.FirstBootHookTimeout) * time.Second,
+		required: lr.cfg.LightningRod.FirstBootHookRequired,
+		limits:   lr.cfg.ChildLimits(),
+	}
+}
+
+// run executes the hook unless none is configured or it already
+// succeeded. A failure is only returned when the hook is required; the
+// marker is written on success alone, so a failed hook runs again on the
+// next boot.
+func (h firstBootHook) run(ctx context.Context) error {
+	if h.path == "" {
+		return nil
+	}
+	if _, err := os.Stat(h.marker); err == nil {
+		log.Debugf("First boot hook already ran (%s)", h.marker)
+		return nil
+	}
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	log.Infof("Running first boot hook %s", h.path)
+	cmd := exec.CommandContext(ctx, h.path)
+	var output []byte
+	err := proclimit.Apply(cmd, h.limits)
+	if err == nil {
+		output, err = cmd.CombinedOutput()
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		log.Infof("first_boot_hook: %s", scanner.Text())
+	}
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", h.timeout)
+		}
+		if h.required {
+			return fmt.Errorf("first boot hook %s failed: %w", h.path, err)
+		}
+		log.Warnf("First boot hook %s failed, continuing: %v", h.path, err)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.marker), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	stamp := []byte(time.Now().UTC().Format(time.RFC3339) + "\n")
+	if err := os.WriteFile(h.marker, stamp, 0644); err != nil {
+		return fmt.Errorf("failed to write first boot marker: %w", err)
+	}
+
+	log.Info("First boot hook completed")
+	return nil
+}
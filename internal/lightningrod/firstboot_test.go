@@ -0,0 +1,124 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package lightningrod
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeHook writes a shell script that appends to a run log and exits
+// with status code
+func writeHook(t *testing.T, dir string, code int) (hook, runs string) {
+	t.Helper()
+	runs = filepath.Join(dir, "runs")
+	hook = filepath.Join(dir, "hook.sh")
+	script := "#!/bin/sh\necho run >> " + runs + "\necho provisioning\nexit " + strconv.Itoa(code) + "\n"
+	if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return hook, runs
+}
+
+func runCount(t *testing.T, runs string) int {
+	t.Helper()
+	data, err := os.ReadFile(runs)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "run\n")
+}
+
+func TestFirstBootHookRunsOnce(t *testing.T) {
+	dir := t.TempDir()
+	path, runs := writeHook(t, dir, 0)
+	h := firstBootHook{path: path, marker: filepath.Join(dir, "state", firstBootMarker), required: true}
+
+	for i := 0; i < 2; i++ {
+		if err := h.run(context.Background()); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+	if n := runCount(t, runs); n != 1 {
+		t.Errorf("hook ran %d times, want 1", n)
+	}
+	if _, err := os.Stat(h.marker); err != nil {
+		t.Errorf("marker not written: %v", err)
+	}
+}
+
+func TestFirstBootHookFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		wantErr  bool
+	}{
+		{"required", true, true},
+		{"optional", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path, runs := writeHook(t, dir, 3)
+			h := firstBootHook{path: path, marker: filepath.Join(dir, firstBootMarker), required: tt.required}
+
+			if err := h.run(context.Background()); (err != nil) != tt.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat(h.marker); !os.IsNotExist(err) {
+				t.Errorf("marker written after a failed hook")
+			}
+
+			// A failed hook is retried on the next boot
+			_ = h.run(context.Background())
+			if n := runCount(t, runs); n != 2 {
+				t.Errorf("hook ran %d times, want 2", n)
+			}
+		})
+	}
+}
+
+func TestFirstBootHookTimeout(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slow.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	h := firstBootHook{path: path, marker: filepath.Join(dir, firstBootMarker), timeout: 100 * time.Millisecond, required: true}
+
+	err := h.run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("run() error = %v, want timeout", err)
+	}
+}
+
+func TestFirstBootHookUnset(t *testing.T) {
+	h := firstBootHook{marker: filepath.Join(t.TempDir(), firstBootMarker), required: true}
+	if err := h.run(context.Background()); err != nil {
+		t.Errorf("run() = %v", err)
+	}
+	if _, err := os.Stat(h.marker); !os.IsNotExist(err) {
+		t.Error("marker written without a hook")
+	}
+}
@@ -19,9 +19,12 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/clock"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/device"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/rest"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/service"
@@ -32,20 +35,28 @@ import (
 
 // LightningRod is the main application struct
 type LightningRod struct {
-	cfg    *config.Config
-	board  *board.Board
-	wamp   *wamp.Client
-	rest   *rest.Manager
-	device *device.Manager
-	service *service.Manager
+	cfg        *config.Config
+	board      *board.Board
+	wamp       *wamp.Client
+	rest       *rest.Manager
+	device     *device.Manager
+	service    *service.Manager
 	webservice *webservice.Manager
+	clock      *clock.Checker
 
 	mu      sync.Mutex
 	running bool
+
+	// startErrs holds, per module, the error that stopped it from starting
+	startErrs map[string]*lasterror.Error
 }
 
 // New creates a new Lightning Rod instance
 func New(cfg *config.Config) (*LightningRod, error) {
+	if cfg.Autobahn.KeepaliveInterval <= 0 {
+		return nil, fmt.Errorf("invalid autobahn.keepalive_interval %d (must be positive)", cfg.Autobahn.KeepaliveInterval)
+	}
+
 	// Create board instance
 	board, err := board.New(cfg)
 	if err != nil {
@@ -57,15 +68,29 @@ func New(cfg *config.Config) (*LightningRod, error) {
 		board: board,
 	}
 
-	// Initialize WAMP client
+	// Initialize WAMP client, mirrored on any secondary cloud
 	lr.wamp = wamp.NewClient(cfg, board)
+	for _, agent := range board.SecondaryAgents() {
+		log.Infof("Reporting to secondary cloud: %s (realm: %s)", agent.URL, agent.Realm)
+		lr.wamp.AddSecondary(wamp.NewSecondaryClient(cfg, board, agent))
+	}
 
 	// Initialize REST API manager (starts immediately, no WAMP dependency)
-	restMgr, err := rest.NewManager(cfg, board)
+	restMgr, err := rest.NewManager(cfg, board, lr.wamp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create REST manager: %w", err)
 	}
 	lr.rest = restMgr
+	lr.rest.SetModuleLister(lr)
+	lr.rest.SetServiceLogLocator(lr)
+
+	// Clock skew is only reported when an NTP server is configured
+	if server := cfg.LightningRod.NTPServer; server != "" {
+		lr.clock = clock.NewChecker(server,
+			time.Duration(cfg.LightningRod.NTPCheckInterval)*time.Second,
+			time.Duration(cfg.LightningRod.MaxClockSkew)*time.Second)
+		lr.rest.SetClockChecker(lr.clock)
+	}
 
 	return lr, nil
 }
@@ -81,27 +106,45 @@ func (lr *LightningRod) Start(ctx context.Context) error {
 	lr.mu.Unlock()
 
 	log.Info("Starting Lightning Rod...")
+	timer := newStartupTimer()
 
 	// Start REST API server
-	if err := lr.rest.Start(ctx); err != nil {
+	if err := timer.phase("rest", func() error { return lr.rest.Start(ctx) }); err != nil {
 		return fmt.Errorf("failed to start REST API: %w", err)
 	}
 
+	if lr.clock != nil {
+		go lr.clock.Run(ctx)
+	}
+
+	// Provision the board before it registers for the first time
+	if lr.board.IsFirstBoot() {
+		if err := timer.phase("first_boot_hook", func() error { return lr.firstBootHook().run(ctx) }); err != nil {
+			return err
+		}
+	}
+
 	// Connect to WAMP router
 	log.Info("Connecting to WAMP router...")
-	if err := lr.wamp.Connect(); err != nil {
+	if err := timer.phase("wamp_connect", lr.wamp.Connect); err != nil {
 		return fmt.Errorf("failed to connect to WAMP router: %w", err)
 	}
 
+	// Secondary clouds are best effort: one that is down only delays its
+	// own registrations until keep-alive reconnects it
+	go lr.wamp.ConnectSecondaries()
+
 	// Initialize modules that depend on WAMP
-	if err := lr.initializeModules(ctx); err != nil {
+	if err := timer.phase("modules", func() error { return lr.initializeModules(ctx) }); err != nil {
 		return fmt.Errorf("failed to initialize modules: %w", err)
 	}
 
 	// Start keep-alive monitoring
 	go lr.wamp.KeepAlive(ctx)
+	lr.wamp.KeepAliveSecondaries(ctx)
 
 	log.Info("Lightning Rod started successfully")
+	lr.reportStartup(lr.startupSummary(timer))
 
 	// Wait for context cancellation
 	<-ctx.Done()
@@ -109,48 +152,185 @@ func (lr *LightningRod) Start(ctx context.Context) error {
 	return nil
 }
 
-// initializeModules initializes all modules
-func (lr *LightningRod) initializeModules(ctx context.Context) error {
+// initializeModules initializes all modules. If any of them fails, the
+// ones already started are stopped again in reverse order; the WAMP
+// connection is left to the caller.
+func (lr *LightningRod) initializeModules(ctx context.Context) (err error) {
 	log.Info("Initializing modules...")
 
+	var started []lifecycle
+	defer func() {
+		if err != nil {
+			lr.rollbackModules(started)
+		}
+	}()
+
 	// Initialize Device Manager
 	deviceMgr, err := device.NewManager(lr.cfg, lr.board, lr.wamp)
 	if err != nil {
-		return fmt.Errorf("failed to create device manager: %w", err)
+		return lr.startFailed("device", fmt.Errorf("failed to create device manager: %w", err))
 	}
+	lr.mu.Lock()
 	lr.device = deviceMgr
+	lr.mu.Unlock()
 
 	if err := lr.device.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start device manager: %w", err)
+		return lr.startFailed("device", fmt.Errorf("failed to start device manager: %w", err))
 	}
+	started = append(started, deviceMgr)
 
 	// Initialize Service Manager
 	serviceMgr, err := service.NewManager(lr.cfg, lr.board, lr.wamp)
 	if err != nil {
-		return fmt.Errorf("failed to create service manager: %w", err)
+		return lr.startFailed("service", fmt.Errorf("failed to create service manager: %w", err))
 	}
+	lr.mu.Lock()
 	lr.service = serviceMgr
+	lr.mu.Unlock()
 
 	if err := lr.service.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start service manager: %w", err)
+		return lr.startFailed("service", fmt.Errorf("failed to start service manager: %w", err))
 	}
+	started = append(started, serviceMgr)
 
 	// Initialize WebService Manager
 	webserviceMgr, err := webservice.NewManager(lr.cfg, lr.board, lr.wamp)
 	if err != nil {
-		return fmt.Errorf("failed to create webservice manager: %w", err)
+		return lr.startFailed("webservice", fmt.Errorf("failed to create webservice manager: %w", err))
 	}
+	lr.mu.Lock()
 	lr.webservice = webserviceMgr
+	lr.mu.Unlock()
 
 	if err := lr.webservice.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start webservice manager: %w", err)
+		return lr.startFailed("webservice", fmt.Errorf("failed to start webservice manager: %w", err))
 	}
 
+	lr.mu.Lock()
+	lr.startErrs = nil
+	lr.mu.Unlock()
+
 	log.Info("All modules initialized successfully")
 
 	return nil
 }
 
+// startFailed records err as the reason module did not start, for the
+// health output, and returns it
+func (lr *LightningRod) startFailed(module string, err error) error {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if lr.startErrs == nil {
+		lr.startErrs = make(map[string]*lasterror.Error)
+	}
+	lr.startErrs[module] = lasterror.New("start", err)
+	return err
+}
+
+// lifecycle is a manager that can be stopped once started
+type lifecycle interface {
+	Name() string
+	Stop() error
+}
+
+// rollbackModules stops the started managers in reverse order, withdraws
+// the procedures any manager registered, including the one that failed
+// part way, and forgets all managers, so a later Stop does not stop them
+// twice
+func (lr *LightningRod) rollbackModules(started []lifecycle) {
+	for i := len(started) - 1; i >= 0; i-- {
+		log.Warnf("Stopping %s manager after failed initialization", started[i].Name())
+		if err := started[i].Stop(); err != nil {
+			log.Errorf("Error stopping %s manager: %v", started[i].Name(), err)
+		}
+	}
+
+	for _, module := range []string{"webservice", "service", "device"} {
+		if err := lr.wamp.UnregisterModule(module); err != nil {
+			log.Errorf("Error unregistering the %s procedures: %v", module, err)
+		}
+	}
+
+	lr.mu.Lock()
+	lr.device = nil
+	lr.service = nil
+	lr.webservice = nil
+	lr.mu.Unlock()
+}
+
+// ServiceLogPath locates the tunnel client log of service name for the
+// REST API; false while the service manager is not running
+func (lr *LightningRod) ServiceLogPath(name string) (string, bool) {
+	lr.mu.Lock()
+	svc := lr.service
+	lr.mu.Unlock()
+
+	if svc == nil {
+		return "", false
+	}
+	return svc.ServiceLogPath(name)
+}
+
+// module is the common interface of the WAMP-backed managers
+type module interface {
+	Name() string
+	Healthy() bool
+	Ready() (bool, string)
+	RPCCount() int
+	LastError() *lasterror.Error
+}
+
+// namedModule is a manager slot; mod is nil when the manager is not running
+type namedModule struct {
+	name string
+	mod  module
+}
+
+// Modules returns the state of the WAMP-backed managers
+func (lr *LightningRod) Modules() []rest.ModuleInfo {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	// Typed nil pointers must not end up as non-nil interface values
+	entries := []namedModule{
+		{"device", nil},
+		{"service", nil},
+		{"webservice", nil},
+	}
+	if lr.device != nil {
+		entries[0].mod = lr.device
+	}
+	if lr.service != nil {
+		entries[1].mod = lr.service
+	}
+	if lr.webservice != nil {
+		entries[2].mod = lr.webservice
+	}
+
+	return moduleInfos(entries, lr.startErrs)
+}
+
+// moduleInfos describes entries, reporting the start failure of the
+// modules that are not running
+func moduleInfos(entries []namedModule, startErrs map[string]*lasterror.Error) []rest.ModuleInfo {
+	modules := make([]rest.ModuleInfo, 0, len(entries))
+	for _, e := range entries {
+		info := rest.ModuleInfo{Name: e.name, LastError: startErrs[e.name]}
+		if e.mod != nil {
+			info.Name = e.mod.Name()
+			info.Enabled = true
+			info.Healthy = e.mod.Healthy()
+			info.Ready, info.Reason = e.mod.Ready()
+			info.RPCCount = e.mod.RPCCount()
+			info.LastError = e.mod.LastError()
+		}
+		modules = append(modules, info)
+	}
+
+	return modules
+}
+
 // Stop stops the Lightning Rod
 func (lr *LightningRod) Stop() {
 	lr.mu.Lock()
@@ -181,8 +361,9 @@ func (lr *LightningRod) Stop() {
 		}
 	}
 
-	// Stop WAMP connection
+	// Stop WAMP connections
 	if lr.wamp != nil {
+		lr.wamp.StopSecondaries()
 		lr.wamp.Stop()
 	}
 
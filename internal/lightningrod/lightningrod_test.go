@@ -0,0 +1,156 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package lightningrod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	stdlog "log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/rest"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/router"
+)
+
+func TestNewRejectsKeepaliveInterval(t *testing.T) {
+	for _, interval := range []int{0, -5} {
+		cfg := &config.Config{}
+		cfg.Autobahn.KeepaliveInterval = interval
+
+		_, err := New(cfg)
+		if err == nil || !strings.Contains(err.Error(), "autobahn.keepalive_interval") {
+			t.Errorf("keepalive_interval %d: New error = %v, want an invalid keepalive_interval", interval, err)
+		}
+	}
+}
+
+// stubModule is a module in a fixed state
+type stubModule struct {
+	name    string
+	healthy bool
+	ready   bool
+	reason  string
+	rpcs    int
+	err     *lasterror.Error
+}
+
+func (s stubModule) Name() string                { return s.name }
+func (s stubModule) Healthy() bool               { return s.healthy }
+func (s stubModule) Ready() (bool, string)       { return s.ready, s.reason }
+func (s stubModule) RPCCount() int               { return s.rpcs }
+func (s stubModule) LastError() *lasterror.Error { return s.err }
+
+func TestModuleInfos(t *testing.T) {
+	nginxErr := lasterror.New("enable web", errors.New("nginx reload failed"))
+	startErr := lasterror.New("start", errors.New("wstun not found"))
+
+	entries := []namedModule{
+		{"device", stubModule{name: "device", healthy: true, ready: true, rpcs: 20}},
+		{"service", nil},
+		{"webservice", stubModule{name: "webservice", reason: "nginx not installed", rpcs: 6, err: nginxErr}},
+	}
+	got := moduleInfos(entries, map[string]*lasterror.Error{"service": startErr})
+
+	want := []rest.ModuleInfo{
+		{Name: "device", Enabled: true, Healthy: true, Ready: true, RPCCount: 20},
+		{Name: "service", LastError: startErr},
+		{Name: "webservice", Enabled: true, Reason: "nginx not installed", RPCCount: 6, LastError: nginxErr},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("moduleInfos = %+v, want %+v", got, want)
+	}
+}
+
+func TestModulesRegisterProcedures(t *testing.T) {
+	r, err := router.NewRouter(&router.Config{
+		RealmConfigs: []*router.RealmConfig{{URI: "s4t", AnonymousAuth: true}},
+	}, stdlog.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	server := httptest.NewServer(router.NewWebsocketServer(r))
+	t.Cleanup(server.Close)
+
+	home := t.TempDir()
+	settings := strings.Replace(testSettings, "ws://router.test:8181/", "ws"+strings.TrimPrefix(server.URL, "http")+"/", 1)
+	if err := os.WriteFile(filepath.Join(home, "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	confFile := filepath.Join(home, "iotronic.conf")
+	if err := os.WriteFile(confFile, []byte("[lightningrod]\nhome = "+home+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(confFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(lr.Stop)
+
+	if err := lr.wamp.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := lr.initializeModules(context.Background()); err != nil {
+		t.Fatalf("initializeModules: %v", err)
+	}
+
+	byModule := lr.wamp.ListRegisteredByModule()
+	for module, procedure := range map[string]string{
+		"device":     "ListProcedures",
+		"service":    "ExposeService",
+		"webservice": "EnableWebService",
+	} {
+		if !slices.Contains(byModule[module], lr.wamp.Procedure(procedure)) {
+			t.Errorf("%s procedures = %v, want %s among them", module, byModule[module], procedure)
+		}
+	}
+
+	// ListProcedures reports the same grouping to callers
+	caller, err := client.ConnectLocal(r, client.Config{Realm: "s4t", Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+	res, err := caller.Call(context.Background(), lr.wamp.Procedure("ListProcedures"), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("ListProcedures: %v", err)
+	}
+	var reply struct {
+		Data map[string][]string `json:"data"`
+	}
+	raw, _ := json.Marshal(res.Arguments[0])
+	if err := json.Unmarshal(raw, &reply); err != nil {
+		t.Fatalf("ListProcedures reply %s: %v", raw, err)
+	}
+	if !reflect.DeepEqual(reply.Data, byModule) {
+		t.Errorf("ListProcedures data = %v, want %v", reply.Data, byModule)
+	}
+}
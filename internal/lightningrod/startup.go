@@ -0,0 +1,136 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package lightningrod
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/rest"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/version"
+	log "github.com/sirupsen/logrus"
+)
+
+// StartupPhase is one timed step of Start
+type StartupPhase struct {
+	Name       string  `json:"name"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// StartupSummary is the consolidated record of what Start did
+type StartupSummary struct {
+	Board      string            `json:"board"`
+	Version    version.Info      `json:"version"`
+	Versions   map[string]string `json:"versions"`
+	WampURL    string            `json:"wamp_url"`
+	WampRealm  string            `json:"wamp_realm"`
+	Modules    []rest.ModuleInfo `json:"modules"`
+	Phases     []StartupPhase    `json:"phases"`
+	DurationMS float64           `json:"duration_ms"`
+}
+
+// versioner is a manager that reports the versions of its dependencies
+type versioner interface {
+	Versions() map[string]string
+}
+
+// startupTimer times the phases of Start
+type startupTimer struct {
+	started time.Time
+	phases  []StartupPhase
+}
+
+func newStartupTimer() *startupTimer {
+	return &startupTimer{started: time.Now()}
+}
+
+// phase runs fn and records how long it took under name
+func (t *startupTimer) phase(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	t.phases = append(t.phases, StartupPhase{Name: name, DurationMS: milliseconds(time.Since(start))})
+	return err
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// startupSummary assembles the summary of a completed Start
+func (lr *LightningRod) startupSummary(t *startupTimer) StartupSummary {
+	summary := StartupSummary{
+		Board:      lr.board.UUID,
+		Version:    version.Get(),
+		Versions:   map[string]string{},
+		WampURL:    lr.board.GetWampURL(),
+		WampRealm:  lr.board.GetWampRealm(),
+		Modules:    lr.Modules(),
+		Phases:     t.phases,
+		DurationMS: milliseconds(time.Since(t.started)),
+	}
+
+	lr.mu.Lock()
+	var versioners []versioner
+	if lr.service != nil {
+		versioners = append(versioners, lr.service)
+	}
+	lr.mu.Unlock()
+	for _, v := range versioners {
+		for name, ver := range v.Versions() {
+			summary.Versions[name] = ver
+		}
+	}
+
+	return summary
+}
+
+// startupTopic returns the topic the startup summary is published to
+func (lr *LightningRod) startupTopic() string {
+	return fmt.Sprintf("iotronic.board.%s.startup", lr.board.UUID)
+}
+
+// reportStartup logs the startup summary as a single entry and publishes
+// it to the startup topic
+func (lr *LightningRod) reportStartup(summary StartupSummary) {
+	fields := log.Fields{
+		"board":       summary.Board,
+		"version":     summary.Version.Version,
+		"wamp_url":    summary.WampURL,
+		"wamp_realm":  summary.WampRealm,
+		"duration_ms": summary.DurationMS,
+	}
+	for name, ver := range summary.Versions {
+		fields[name+"_version"] = ver
+	}
+	for _, mod := range summary.Modules {
+		state := "disabled"
+		switch {
+		case mod.Enabled && mod.Ready:
+			state = fmt.Sprintf("ready/%d rpcs", mod.RPCCount)
+		case mod.Enabled:
+			state = fmt.Sprintf("not ready (%s)/%d rpcs", mod.Reason, mod.RPCCount)
+		}
+		fields["module_"+mod.Name] = state
+	}
+	for _, p := range summary.Phases {
+		fields["phase_"+p.Name+"_ms"] = p.DurationMS
+	}
+	log.WithFields(fields).Info("Startup summary")
+
+	if err := lr.wamp.Publish(lr.startupTopic(), []any{summary}, nil); err != nil {
+		log.Warnf("Failed to publish startup summary: %v", err)
+	}
+}
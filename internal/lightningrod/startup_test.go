@@ -0,0 +1,125 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package lightningrod
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/device"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/service"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/modules/webservice"
+	log "github.com/sirupsen/logrus"
+)
+
+const testSettings = `{
+  "iotronic": {
+    "board": {"uuid": "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11", "code": "TESTCODE", "status": "registered", "type": "server"},
+    "wamp": {"main-agent": {"url": "ws://router.test:8181/", "realm": "s4t"}}
+  }
+}`
+
+// newTestLightningRod returns a Lightning Rod for a test board, with the
+// default configuration rooted in a temporary home
+func newTestLightningRod(t *testing.T) *LightningRod {
+	t.Helper()
+
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, "settings.json"), []byte(testSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	confFile := filepath.Join(home, "iotronic.conf")
+	if err := os.WriteFile(confFile, []byte("[lightningrod]\nhome = "+home+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(confFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(lr.wamp.Stop)
+	return lr
+}
+
+func TestStartupSummary(t *testing.T) {
+	lr := newTestLightningRod(t)
+
+	timer := newStartupTimer()
+	timer.phase("wamp_connect", func() error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+
+	// The managers initializeModules would have started
+	var err error
+	if lr.device, err = device.NewManager(lr.cfg, lr.board, lr.wamp); err != nil {
+		t.Fatal(err)
+	}
+	if lr.service, err = service.NewManager(lr.cfg, lr.board, lr.wamp); err != nil {
+		t.Fatal(err)
+	}
+	if lr.webservice, err = webservice.NewManager(lr.cfg, lr.board, lr.wamp); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := lr.startupSummary(timer)
+	if summary.Board != lr.board.UUID || summary.WampURL != "ws://router.test:8181/" || summary.WampRealm != "s4t" {
+		t.Errorf("summary = %+v", summary)
+	}
+	modules := map[string]bool{}
+	for _, mod := range summary.Modules {
+		modules[mod.Name] = mod.Enabled
+	}
+	for _, name := range []string{"device", "service", "webservice"} {
+		if !modules[name] {
+			t.Errorf("summary has no entry for started module %s: %+v", name, summary.Modules)
+		}
+	}
+	if len(summary.Phases) != 1 || summary.Phases[0].Name != "wamp_connect" || summary.Phases[0].DurationMS < 2 {
+		t.Errorf("phases = %+v, want wamp_connect taking at least 2ms", summary.Phases)
+	}
+	if summary.DurationMS < summary.Phases[0].DurationMS {
+		t.Errorf("total %vms shorter than its phases", summary.DurationMS)
+	}
+
+	// One log entry carries the whole summary
+	var buf bytes.Buffer
+	out := log.StandardLogger().Out
+	log.SetOutput(&buf)
+	defer log.SetOutput(out)
+	lr.reportStartup(summary)
+
+	var entry string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "Startup summary") {
+			entry = line
+		}
+	}
+	for _, want := range []string{"module_device=", "module_service=", "module_webservice=", "phase_wamp_connect_ms=", "wamp_url="} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("startup log entry %q lacks %s", entry, want)
+		}
+	}
+}
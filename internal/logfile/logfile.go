@@ -0,0 +1,179 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package logfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultLines is the number of lines returned when none is requested
+	DefaultLines = 100
+	// MaxLines caps the number of lines a single tail may return
+	MaxLines = 1000
+	// MaxBytes caps how much of the file a single tail may scan, which
+	// only matters when a level filter skips most lines
+	MaxBytes = 64 << 20
+	// MaxLineBytes caps the length of a returned line; longer lines keep
+	// their end
+	MaxLineBytes = 64 << 10
+)
+
+// chunkSize is how much of the file is read at a time, walking backwards
+// from the end, replaceable for testing
+var chunkSize = 64 << 10
+
+// ErrFileLoggingDisabled is returned when no log file is configured
+var ErrFileLoggingDisabled = errors.New("file logging is not enabled (lightningrod.log_file is empty)")
+
+// ErrInvalidLevel is returned when the level filter is not a log level
+var ErrInvalidLevel = errors.New("invalid level filter")
+
+// Open opens the agent log file for appending, creating it if needed
+func Open(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+}
+
+// Tail returns the last n lines of the log file at path. When level is set,
+// only lines at that level or more severe are returned.
+//
+// The file is read backwards in chunks, so memory use is bounded by the
+// lines returned whatever the size of the file. Data appended while
+// reading is ignored.
+func Tail(path string, n int, level string) ([]string, error) {
+	if path == "" {
+		return nil, ErrFileLoggingDisabled
+	}
+
+	if n <= 0 {
+		n = DefaultLines
+	}
+	if n > MaxLines {
+		n = MaxLines
+	}
+
+	keep := func(string) bool { return true }
+	if level != "" {
+		minLevel, err := log.ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidLevel, err)
+		}
+		keep = func(line string) bool { return matchesLevel(line, minLevel) }
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	lines, err := tail(f, info.Size(), n, keep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return lines, nil
+}
+
+// tail returns the last n non-empty lines kept by keep among the first
+// size bytes of r, oldest first
+func tail(r io.ReaderAt, size int64, n int, keep func(string) bool) ([]string, error) {
+	// Lines are collected newest first
+	var found []string
+	add := func(line []byte) {
+		if len(line) > MaxLineBytes {
+			line = line[len(line)-MaxLineBytes:]
+		}
+		if len(line) > 0 && keep(string(line)) {
+			found = append(found, string(line))
+		}
+	}
+
+	buf := make([]byte, chunkSize)
+	// partial is the start of the file region already read, up to its
+	// first newline: the end of a line whose beginning is not read yet
+	var partial []byte
+	end := size
+	for end > 0 && len(found) < n && size-end < MaxBytes {
+		start := max(end-int64(chunkSize), 0)
+		chunk := buf[:end-start]
+		if _, err := r.ReadAt(chunk, start); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			// Truncated while reading, e.g. by rotation: keep what was found
+			break
+		}
+
+		for len(found) < n {
+			i := bytes.LastIndexByte(chunk, '\n')
+			if i < 0 {
+				break
+			}
+			add(append(chunk[i+1:len(chunk):len(chunk)], partial...))
+			partial = partial[:0]
+			chunk = chunk[:i]
+		}
+		partial = append(append(make([]byte, 0, len(chunk)+len(partial)), chunk...), partial...)
+		if len(partial) > MaxLineBytes {
+			partial = partial[len(partial)-MaxLineBytes:]
+		}
+		end = start
+	}
+
+	// The first line of the file is complete; a line cut by MaxBytes is not
+	if end == 0 && len(found) < n {
+		add(partial)
+	}
+
+	lines := make([]string, len(found))
+	for i, line := range found {
+		lines[len(found)-1-i] = line
+	}
+	return lines, nil
+}
+
+// matchesLevel reports whether a logrus text line is at least as severe
+// as min
+func matchesLevel(line string, min log.Level) bool {
+	i := strings.Index(line, "level=")
+	if i < 0 {
+		return false
+	}
+
+	field := line[i+len("level="):]
+	if j := strings.IndexByte(field, ' '); j >= 0 {
+		field = field[:j]
+	}
+
+	lvl, err := log.ParseLevel(strings.Trim(field, `"`))
+	if err != nil {
+		return false
+	}
+
+	return lvl <= min
+}
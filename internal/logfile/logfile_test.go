@@ -0,0 +1,201 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package logfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// writeLog writes a log file of count lines, every tenth at error level
+func writeLog(t *testing.T, count int) (string, []string) {
+	t.Helper()
+
+	lines := make([]string, count)
+	var sb strings.Builder
+	for i := range lines {
+		level := "info"
+		if i%10 == 0 {
+			level = "error"
+		}
+		lines[i] = fmt.Sprintf(`time="2024-01-01T00:00:00Z" level=%s msg="line %d"`, level, i)
+		sb.WriteString(lines[i])
+		sb.WriteByte('\n')
+	}
+
+	path := filepath.Join(t.TempDir(), "lightning-rod.log")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, lines
+}
+
+// lastLines is the reference implementation of Tail
+func lastLines(lines []string, n int, level string) []string {
+	var kept []string
+	for _, line := range lines {
+		if level == "" || matchesLevel(line, log.ErrorLevel) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) > n {
+		kept = kept[len(kept)-n:]
+	}
+	if kept == nil {
+		kept = []string{}
+	}
+	return kept
+}
+
+func TestTailChunks(t *testing.T) {
+	path, lines := writeLog(t, 57)
+	defer func() { chunkSize = 64 << 10 }()
+
+	// Chunks smaller than, equal to and larger than a line, and larger
+	// than the whole file
+	for _, size := range []int{7, len(lines[0]) + 1, 100, 1 << 20} {
+		chunkSize = size
+		for _, n := range []int{1, 5, 56, 57, 100} {
+			for _, level := range []string{"", "error"} {
+				got, err := Tail(path, n, level)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := lastLines(lines, n, level); !reflect.DeepEqual(got, want) {
+					t.Errorf("chunk %d, n %d, level %q: got %d lines %q..., want %d", size, n, level, len(got), got, len(want))
+				}
+			}
+		}
+	}
+}
+
+func TestTailNoTrailingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightning-rod.log")
+	if err := os.WriteFile(path, []byte("one\n\ntwo\nthree"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Tail(path, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tail = %q, want %q", got, want)
+	}
+}
+
+func TestTailLongLine(t *testing.T) {
+	long := strings.Repeat("a", MaxLineBytes) + strings.Repeat("b", 100)
+	path := filepath.Join(t.TempDir(), "lightning-rod.log")
+	if err := os.WriteFile(path, []byte("first\n"+long+"\nlast\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Tail(path, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0] != "first" || got[2] != "last" {
+		t.Fatalf("Tail = %d lines, want first, the long line and last", len(got))
+	}
+	if len(got[1]) != MaxLineBytes || !strings.HasSuffix(got[1], "b") {
+		t.Errorf("long line: %d bytes, want its last %d", len(got[1]), MaxLineBytes)
+	}
+}
+
+func TestTailErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lightning-rod.log")
+	if err := os.WriteFile(path, []byte("level=info msg=started\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Tail("", 10, ""); !errors.Is(err, ErrFileLoggingDisabled) {
+		t.Errorf("Tail without a file = %v, want ErrFileLoggingDisabled", err)
+	}
+	if _, err := Tail(path, 10, "loud"); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("Tail with level loud = %v, want ErrInvalidLevel", err)
+	}
+}
+
+// growingFile appends to the log file on every read, like a busy agent
+type growingFile struct {
+	*os.File
+	appender *os.File
+}
+
+func (g growingFile) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := g.appender.WriteString("appended while reading\n"); err != nil {
+		return 0, err
+	}
+	return g.File.ReadAt(p, off)
+}
+
+func TestTailGrowingFile(t *testing.T) {
+	path, lines := writeLog(t, 200)
+	chunkSize = 256
+	defer func() { chunkSize = 64 << 10 }()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	appender, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer appender.Close()
+
+	info, _ := f.Stat()
+	got, err := tail(growingFile{f, appender}, info.Size(), 50, func(string) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := lastLines(lines, 50, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("tail of a growing file = %q, want %q", got, want)
+	}
+}
+
+func TestTailLargeFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes a large file")
+	}
+	path, lines := writeLog(t, 400000) // about 25 MB
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	got, err := Tail(path, MaxLines, "")
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := lastLines(lines, MaxLines, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d lines ending %q, want the last %d", len(got), got[len(got)-1], MaxLines)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 4<<20 {
+		t.Errorf("Tail allocated %d bytes for a %d line tail", alloc, MaxLines)
+	}
+}
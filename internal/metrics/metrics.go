@@ -0,0 +1,99 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package metrics keeps process-wide gauges and renders them in the
+// Prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ContentType is the content type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Gauge names
+const (
+	SettingsLoadedTimestamp = "lr_settings_loaded_timestamp"
+	ConfigLoadedTimestamp   = "lr_config_loaded_timestamp"
+	AgentRSSBytes           = "lr_agent_rss_bytes"
+	AgentHeapAllocBytes     = "lr_agent_heap_alloc_bytes"
+	AgentGoroutines         = "lr_agent_goroutines"
+	AgentGCPauseSeconds     = "lr_agent_gc_pause_seconds"
+)
+
+type gauge struct {
+	help  string
+	value float64
+}
+
+var registry = struct {
+	mu     sync.Mutex
+	gauges map[string]*gauge
+}{gauges: make(map[string]*gauge)}
+
+// SetGauge sets the value of the gauge name, creating it if needed
+func SetGauge(name, help string, value float64) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.gauges[name] = &gauge{help: help, value: value}
+}
+
+// SetToCurrentTime sets the gauge name to the current Unix time
+func SetToCurrentTime(name, help string) {
+	SetGauge(name, help, float64(time.Now().Unix()))
+}
+
+// Gauge returns the value of the gauge name and whether it is set
+func Gauge(name string) (float64, bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	g, ok := registry.gauges[name]
+	if !ok {
+		return 0, false
+	}
+	return g.value, true
+}
+
+// Write renders all gauges, sorted by name, in the text exposition format
+func Write(w io.Writer) error {
+	registry.mu.Lock()
+	names := make([]string, 0, len(registry.gauges))
+	for name := range registry.gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	gauges := make([]gauge, len(names))
+	for i, name := range names {
+		gauges[i] = *registry.gauges[name]
+	}
+	registry.mu.Unlock()
+
+	for i, name := range names {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n",
+			name, gauges[i].help, name, name, strconv.FormatFloat(gauges[i].value, 'g', -1, 64))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
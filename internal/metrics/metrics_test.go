@@ -0,0 +1,51 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	SetGauge("lr_test_b", "Second test gauge", 0.5)
+	SetGauge("lr_test_a", "First test gauge", 3)
+	SetGauge("lr_test_a", "First test gauge", 42)
+
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP lr_test_a First test gauge\n# TYPE lr_test_a gauge\nlr_test_a 42\n" +
+		"# HELP lr_test_b Second test gauge\n# TYPE lr_test_b gauge\nlr_test_b 0.5\n"
+	if !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("Write =\n%s\nwant it to contain\n%s", buf.String(), want)
+	}
+}
+
+func TestSetToCurrentTime(t *testing.T) {
+	if _, ok := Gauge("lr_test_unset"); ok {
+		t.Error("unset gauge reported as set")
+	}
+
+	before := float64(time.Now().Unix())
+	SetToCurrentTime("lr_test_timestamp", "Test timestamp")
+	got, ok := Gauge("lr_test_timestamp")
+	if !ok || got < before || got > float64(time.Now().Unix()) {
+		t.Errorf("Gauge = %v, %v, want the current Unix time", got, ok)
+	}
+}
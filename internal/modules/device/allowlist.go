@@ -0,0 +1,161 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// allowlistPollInterval is how often the allowlist file is checked for
+// changes in the background
+const allowlistPollInterval = 5 * time.Second
+
+// allowlist holds the executables ExecCommand may run, loaded from a file
+// with one absolute path per line; blank lines and lines starting with #
+// are ignored. The file is re-read whenever its size or modification time
+// changes, so edits take effect without a restart.
+type allowlist struct {
+	path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	size     int64
+	commands map[string]string // executable name and path -> path
+}
+
+// newAllowlist returns an allowlist backed by path, empty until loaded
+func newAllowlist(path string) *allowlist {
+	return &allowlist{path: path, commands: make(map[string]string)}
+}
+
+// parseAllowlist reads allowlist entries from data. Malformed lines are
+// skipped and reported in the returned error slice.
+func parseAllowlist(data []byte) (map[string]string, []error) {
+	commands := make(map[string]string)
+	var errs []error
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := validateAllowlistEntry(line); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", n, err))
+			continue
+		}
+		commands[line] = line
+		commands[filepath.Base(line)] = line
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return commands, errs
+}
+
+// validateAllowlistEntry checks that entry is a clean absolute path
+func validateAllowlistEntry(entry string) error {
+	if !filepath.IsAbs(entry) {
+		return fmt.Errorf("%q is not an absolute path", entry)
+	}
+	if filepath.Clean(entry) != entry {
+		return fmt.Errorf("%q is not a clean path", entry)
+	}
+	if strings.ContainsFunc(entry, func(r rune) bool { return r < ' ' || r == ' ' || r == 0x7f }) {
+		return fmt.Errorf("%q contains spaces or control characters", entry)
+	}
+	return nil
+}
+
+// reload re-reads the file if it changed since the last load. A missing
+// file empties the allowlist.
+func (a *allowlist) reload() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.path)
+	if errors.Is(err, os.ErrNotExist) {
+		if len(a.commands) > 0 {
+			log.Infof("Command allowlist %s removed, no commands allowed", a.path)
+		}
+		a.commands = make(map[string]string)
+		a.modTime, a.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(a.modTime) && info.Size() == a.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return err
+	}
+	commands, errs := parseAllowlist(data)
+	for _, err := range errs {
+		log.Warnf("Command allowlist %s: ignoring %v", a.path, err)
+	}
+
+	a.commands = commands
+	a.modTime, a.size = info.ModTime(), info.Size()
+	log.Infof("Loaded command allowlist %s", a.path)
+	return nil
+}
+
+// lookup returns the path of command if it is allowed, reloading the
+// allowlist first so the latest edit always applies
+func (a *allowlist) lookup(command string) (string, bool) {
+	if err := a.reload(); err != nil {
+		log.Warnf("Failed to reload command allowlist %s: %v", a.path, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path, ok := a.commands[command]
+	return path, ok
+}
+
+// watch reloads the allowlist on changes until ctx is done
+func (a *allowlist) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.reload(); err != nil {
+				log.Warnf("Failed to reload command allowlist %s: %v", a.path, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestParseAllowlist(t *testing.T) {
+	data := []byte("# comment\n\n/bin/true\nrelative/cmd\n/usr/bin/../bin/env\n/bin/with space\n  /usr/bin/uptime  \n")
+
+	commands, errs := parseAllowlist(data)
+	if len(errs) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(errs), errs)
+	}
+	for _, name := range []string{"/bin/true", "true", "/usr/bin/uptime", "uptime"} {
+		if _, ok := commands[name]; !ok {
+			t.Errorf("%s not allowed", name)
+		}
+	}
+	for _, name := range []string{"relative/cmd", "env", "/bin/with space"} {
+		if _, ok := commands[name]; ok {
+			t.Errorf("malformed entry %s allowed", name)
+		}
+	}
+}
+
+func TestExecCommandAllowlistReload(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo not available")
+	}
+	echo, _ = filepath.Abs(echo)
+
+	path := filepath.Join(t.TempDir(), "commands.allow")
+	cfg := &config.Config{}
+	cfg.Commands.Timeout = 5
+	m := &Manager{cfg: cfg, allowlist: newAllowlist(path)}
+
+	// No file: nothing is allowed
+	if _, err := m.execCommand(context.Background(), "echo", []string{"hi"}); !errors.Is(err, errCommandNotAllowed) {
+		t.Fatalf("execCommand() error = %v, want not allowed", err)
+	}
+
+	if err := os.WriteFile(path, []byte("/bin/true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.execCommand(context.Background(), "echo", []string{"hi"}); !errors.Is(err, errCommandNotAllowed) {
+		t.Fatalf("execCommand() error = %v, want not allowed", err)
+	}
+
+	// The newly added command becomes runnable without a restart
+	if err := os.WriteFile(path, []byte("/bin/true\n"+echo+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := m.execCommand(context.Background(), "echo", []string{"hi"})
+	if err != nil {
+		t.Fatalf("execCommand() error = %v", err)
+	}
+	if res.ExitCode != 0 || res.Output != "hi\n" || res.Command != echo {
+		t.Errorf("execCommand() = %+v", res)
+	}
+
+	// Removing the file revokes every command
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.execCommand(context.Background(), "echo", nil); !errors.Is(err, errCommandNotAllowed) {
+		t.Errorf("execCommand() error = %v, want not allowed", err)
+	}
+}
+
+func TestExecCommandExitStatus(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	sh, _ = filepath.Abs(sh)
+
+	path := filepath.Join(t.TempDir(), "commands.allow")
+	if err := os.WriteFile(path, []byte(sh+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{cfg: &config.Config{}, allowlist: newAllowlist(path)}
+
+	res, err := m.execCommand(context.Background(), "sh", []string{"-c", "echo oops; exit 4"})
+	if err != nil {
+		t.Fatalf("execCommand() error = %v", err)
+	}
+	if res.ExitCode != 4 || res.Output != "oops\n" {
+		t.Errorf("execCommand() = %+v", res)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 4}
+	b.Write([]byte("ab"))
+	b.Write([]byte("cdef"))
+	b.Write([]byte("g"))
+	if string(b.buf) != "abcd" || !b.truncated {
+		t.Errorf("buf = %q, truncated = %v", b.buf, b.truncated)
+	}
+}
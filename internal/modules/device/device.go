@@ -19,10 +19,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/logfile"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/sysinfo"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/wamp"
 	gammazero "github.com/gammazero/nexus/v3/client"
 	nexuswamp "github.com/gammazero/nexus/v3/wamp"
@@ -34,14 +40,28 @@ type Manager struct {
 	board      *board.Board
 	cfg        *config.Config
 	wampClient *wamp.Client
-	device     Device
+
+	mu     sync.RWMutex
+	device Device
+
+	allowlist *allowlist
+	cancel    context.CancelFunc
+
+	// publish sends command results and telemetry, replaceable for testing
+	publish func(topic string, args []any, kwargs map[string]any) error
+
+	// errs holds the last failed device operation
+	errs lasterror.Tracker
+
+	started  atomic.Bool
+	rpcCount atomic.Int32
 }
 
 // Device interface for device-specific implementations
 type Device interface {
 	GetType() string
 	GetInfo() (map[string]any, error)
-	GetStatus() (map[string]any, error)
+	GetStatus(ctx context.Context) (map[string]any, error)
 }
 
 // GenericDevice represents a generic device implementation
@@ -55,12 +75,19 @@ func NewManager(cfg *config.Config, board *board.Board, wampClient *wamp.Client)
 		board:      board,
 		cfg:        cfg,
 		wampClient: wampClient,
+		allowlist:  newAllowlist(cfg.CommandAllowlistFile()),
+		publish:    wampClient.Publish,
 	}
 
 	// Initialize device based on board type
-	m.device = &GenericDevice{deviceType: board.Type}
+	boardType := board.GetType()
+	if boardType == "" && cfg.LightningRod.DefaultDeviceType != "" {
+		boardType = cfg.LightningRod.DefaultDeviceType
+		log.Infof("Board has no type, using the default device type: %s", boardType)
+	}
+	m.device = newDevice(boardType)
 
-	log.Infof("Device Manager initialized for type: %s", board.Type)
+	log.Infof("Device Manager initialized for type: %s", boardType)
 
 	return m, nil
 }
@@ -74,6 +101,15 @@ func (m *Manager) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to register RPCs: %w", err)
 	}
 
+	if err := m.allowlist.reload(); err != nil {
+		log.Warnf("Failed to load command allowlist %s: %v", m.allowlist.path, err)
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	go m.allowlist.watch(watchCtx, allowlistPollInterval)
+	go m.runTelemetry(watchCtx)
+
+	m.started.Store(true)
 	log.Info("Device Manager started successfully")
 	return nil
 }
@@ -81,24 +117,88 @@ func (m *Manager) Start(ctx context.Context) error {
 // Stop shuts down the device manager
 func (m *Manager) Stop() error {
 	log.Info("Stopping Device Manager...")
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.started.Store(false)
 	return nil
 }
 
+// Name returns the module name
+func (m *Manager) Name() string {
+	return "device"
+}
+
+// Healthy reports whether the manager is started
+func (m *Manager) Healthy() bool {
+	return m.started.Load()
+}
+
+// Ready reports whether the manager's dependencies are available; the
+// device manager has none
+func (m *Manager) Ready() (bool, string) {
+	return true, ""
+}
+
+// LastError returns the last failed operation of the manager, cleared
+// once one succeeds
+func (m *Manager) LastError() *lasterror.Error {
+	return m.errs.Last()
+}
+
+// RPCCount returns the number of RPC procedures registered by the manager
+func (m *Manager) RPCCount() int {
+	return int(m.rpcCount.Load())
+}
+
 // registerRPCs registers device-related RPC procedures
 func (m *Manager) registerRPCs() error {
 	procedures := map[string]func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult{
-		fmt.Sprintf("iotronic.%s.%s.DevicePing", m.board.SessionID, m.board.UUID):   m.handleDevicePing,
-		fmt.Sprintf("iotronic.%s.%s.DeviceInfo", m.board.SessionID, m.board.UUID):   m.handleDeviceInfo,
-		fmt.Sprintf("iotronic.%s.%s.DeviceStatus", m.board.SessionID, m.board.UUID): m.handleDeviceStatus,
+		m.wampClient.Procedure("DevicePing"):        m.handleDevicePing,
+		m.wampClient.Procedure("DeviceInfo"):        m.handleDeviceInfo,
+		m.wampClient.Procedure("DeviceStatus"):      m.handleDeviceStatus,
+		m.wampClient.Procedure("NetworkInterfaces"): m.handleNetworkInterfaces,
+		m.wampClient.Procedure("LogsTail"):          m.handleLogsTail,
+		m.wampClient.Procedure("HostInfo"):          m.handleHostInfo,
+		m.wampClient.Procedure("ListProcedures"):    m.handleListProcedures,
+		m.wampClient.Procedure("RpcStats"):          m.handleRPCStats,
+		m.wampClient.Procedure("GetTags"):           m.handleGetTags,
+		m.wampClient.Procedure("SetTags"):           m.handleSetTags,
+		m.wampClient.Procedure("SetName"):           m.handleSetName,
+		m.wampClient.Procedure("SetType"):           m.handleSetType,
+		m.wampClient.Procedure("ConfigGet"):         m.handleConfigGet,
+		m.wampClient.Procedure("ConfigSet"):         m.handleConfigSet,
+		m.wampClient.Procedure("ConfigDelete"):      m.handleConfigDelete,
+		m.wampClient.Procedure("SetConfig"):         m.handleSetConfig,
+		m.wampClient.Procedure("ExecCommand"):       m.handleExecCommand,
+		m.wampClient.Procedure("SetMaintenance"):    m.handleSetMaintenance,
+		m.wampClient.Procedure("Reboot"):            m.handleReboot,
+	}
+
+	// Procedures rejected in maintenance mode
+	mutating := map[string]bool{
+		m.wampClient.Procedure("SetTags"):      true,
+		m.wampClient.Procedure("SetName"):      true,
+		m.wampClient.Procedure("SetType"):      true,
+		m.wampClient.Procedure("ConfigSet"):    true,
+		m.wampClient.Procedure("ConfigDelete"): true,
+		m.wampClient.Procedure("SetConfig"):    true,
+		m.wampClient.Procedure("ExecCommand"):  true,
+		m.wampClient.Procedure("Reboot"):       true,
 	}
 
 	for proc, handler := range procedures {
-		if err := m.wampClient.Register(proc, handler); err != nil {
+		opts := []wamp.RegisterOption{wamp.WithModule(m.Name()), wamp.WithConcurrencyLimit(m.cfg.RPC.DeviceConcurrency)}
+		if mutating[proc] {
+			opts = append(opts, wamp.Mutating())
+		}
+		if err := m.wampClient.Register(proc, handler, opts...); err != nil {
 			return fmt.Errorf("failed to register %s: %w", proc, err)
 		}
 		log.Infof("Registered RPC: %s", proc)
 	}
 
+	m.rpcCount.Store(int32(len(procedures)))
 	return nil
 }
 
@@ -113,58 +213,107 @@ func (m *Manager) handleDevicePing(ctx context.Context, inv *nexuswamp.Invocatio
 
 	message := fmt.Sprintf("%s @ %s", hostname, time.Now().Format("2006-01-02T15:04:05.000000"))
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": message,
-		}},
-	}
+	return rpc.Success(message, nil)
 }
 
 // handleDeviceInfo handles the DeviceInfo RPC
 func (m *Manager) handleDeviceInfo(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
 	log.Info("RPC DeviceInfo called")
 
-	info, err := m.device.GetInfo()
+	info, err := m.currentDevice().GetInfo()
 	if err != nil {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": err.Error(),
-			}},
-		}
+		return rpc.Error(err.Error())
 	}
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": "Device info retrieved",
-			"data":    info,
-		}},
+	return rpc.Success("Device info retrieved", info)
+}
+
+// handleHostInfo handles the HostInfo RPC
+func (m *Manager) handleHostInfo(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC HostInfo called")
+
+	info, err := sysinfo.Host()
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to get host info: %v", err))
 	}
+
+	return rpc.SuccessObject("Host info retrieved", struct {
+		*sysinfo.HostInfo
+		LastReboot *board.RebootRecord `json:"last_reboot,omitempty"`
+	}{info, m.board.LastReboot()})
+}
+
+// handleListProcedures handles the ListProcedures RPC
+func (m *Manager) handleListProcedures(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ListProcedures called")
+
+	return rpc.SuccessObject("Registered procedures retrieved", m.wampClient.ListRegisteredByModule())
+}
+
+// handleRPCStats handles the RpcStats RPC, returning how often each
+// procedure was invoked and when it was last called
+func (m *Manager) handleRPCStats(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC RpcStats called")
+
+	return rpc.Success("Procedure statistics retrieved", map[string]any{"procedures": m.wampClient.RPCStats()})
 }
 
 // handleDeviceStatus handles the DeviceStatus RPC
 func (m *Manager) handleDeviceStatus(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
 	log.Info("RPC DeviceStatus called")
 
-	status, err := m.device.GetStatus()
+	status, err := m.currentDevice().GetStatus(ctx)
+	if err != nil {
+		return rpc.Error(err.Error())
+	}
+
+	return rpc.Success("Device status retrieved", status)
+}
+
+// handleNetworkInterfaces handles the NetworkInterfaces RPC
+func (m *Manager) handleNetworkInterfaces(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC NetworkInterfaces called")
+
+	includeLoopback, _ := inv.ArgumentsKw["include_loopback"].(bool)
+	if len(inv.Arguments) > 0 {
+		includeLoopback, _ = inv.Arguments[0].(bool)
+	}
+
+	ifaces, err := listInterfaces(includeLoopback)
 	if err != nil {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": err.Error(),
-			}},
+		return rpc.Error(err.Error())
+	}
+
+	gateway, gatewayIface := defaultGateway()
+
+	return rpc.Success("Network interfaces retrieved", map[string]any{
+		"interfaces":        ifaces,
+		"gateway":           gateway,
+		"gateway_interface": gatewayIface,
+	})
+}
+
+// handleLogsTail handles the LogsTail RPC
+func (m *Manager) handleLogsTail(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC LogsTail called")
+
+	lines := logfile.DefaultLines
+	if len(inv.Arguments) > 0 {
+		if n, ok := inv.Arguments[0].(float64); ok {
+			lines = int(n)
 		}
 	}
+	level, _ := inv.ArgumentsKw["level"].(string)
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": "Device status retrieved",
-			"data":    status,
-		}},
+	tail, err := logfile.Tail(m.cfg.LightningRod.LogFile, lines, level)
+	if err != nil {
+		return rpc.Error(err.Error())
 	}
+
+	return rpc.Success("Log lines retrieved", map[string]any{
+		"file":  m.cfg.LightningRod.LogFile,
+		"lines": tail,
+	})
 }
 
 // GenericDevice implementation
@@ -179,12 +328,22 @@ func (d *GenericDevice) GetInfo() (map[string]any, error) {
 	return map[string]any{
 		"type":     d.deviceType,
 		"hostname": hostname,
+		"hardware": sysinfo.Hardware(),
 	}, nil
 }
 
-func (d *GenericDevice) GetStatus() (map[string]any, error) {
-	return map[string]any{
-		"status": "online",
-		"uptime": time.Now().Unix(),
-	}, nil
+func (d *GenericDevice) GetStatus(ctx context.Context) (map[string]any, error) {
+	status := map[string]any{
+		"status":       "online",
+		"uptime":       time.Now().Unix(),
+		"temperatures": readTemperatures(),
+		"load_average": sysinfo.Load(),
+	}
+
+	if usage, err := sysinfo.CPU(ctx, sysinfo.SampleInterval); err == nil {
+		status["cpu_percent"] = usage.Percent
+		status["cpu_per_core"] = usage.PerCore
+	}
+
+	return status, nil
 }
@@ -0,0 +1,161 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxCommandOutput bounds the output returned by ExecCommand
+	maxCommandOutput = 64 * 1024
+
+	// maxPublishedOutput bounds the output published to the result topic
+	maxPublishedOutput = 4 * 1024
+)
+
+// limitedBuffer keeps the first max bytes written to it
+type limitedBuffer struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// CommandResult is the outcome of an allowlisted command
+type CommandResult struct {
+	Command   string   `json:"command"`
+	Args      []string `json:"args"`
+	ExitCode  int      `json:"exit_code"`
+	Output    string   `json:"output"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// errCommandNotAllowed is returned for commands missing from the allowlist
+var errCommandNotAllowed = errors.New("command not allowed")
+
+// execCommand runs an allowlisted command without a shell. A non-zero
+// exit status is reported in the result, not as an error.
+func (m *Manager) execCommand(ctx context.Context, command string, args []string) (*CommandResult, error) {
+	path, ok := m.allowlist.lookup(command)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errCommandNotAllowed, command)
+	}
+
+	if timeout := m.cfg.Commands.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	out := &limitedBuffer{max: maxCommandOutput}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := proclimit.Apply(cmd, m.cfg.ChildLimits()); err != nil {
+		return nil, err
+	}
+
+	err := cmd.Run()
+	res := &CommandResult{Command: path, Args: args, Output: string(out.buf), Truncated: out.truncated}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%s: %w", path, ctx.Err())
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// handleExecCommand handles the ExecCommand RPC: a command name or path
+// followed by its arguments
+func (m *Manager) handleExecCommand(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ExecCommand called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: command required")
+	}
+	args := make([]string, len(inv.Arguments))
+	for i, a := range inv.Arguments {
+		s, ok := a.(string)
+		if !ok {
+			return rpc.Error(fmt.Sprintf("Invalid argument %d: string required", i))
+		}
+		args[i] = s
+	}
+
+	res, err := m.execCommand(ctx, args[0], args[1:])
+	if errors.Is(err, errCommandNotAllowed) {
+		return rpc.ErrorCode("NOT_ALLOWED", err.Error())
+	}
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to run command: %v", err))
+	}
+	m.publishResult(res)
+
+	return rpc.SuccessObject(fmt.Sprintf("Command exited with status %d", res.ExitCode), res)
+}
+
+// publishResult publishes a completed command run to commands.result_topic,
+// if set; the output is cut to maxPublishedOutput
+func (m *Manager) publishResult(res *CommandResult) {
+	topic := m.cfg.Commands.ResultTopic
+	if topic == "" {
+		return
+	}
+
+	output, truncated := res.Output, res.Truncated
+	if len(output) > maxPublishedOutput {
+		output, truncated = output[:maxPublishedOutput], true
+	}
+	event := map[string]any{
+		"board":     m.board.UUID,
+		"command":   res.Command,
+		"args":      res.Args,
+		"exit_code": res.ExitCode,
+		"output":    output,
+		"truncated": truncated,
+		"timestamp": time.Now().Format("2006-01-02T15:04:05.000000"),
+	}
+
+	if err := m.publish(topic, []any{event}, nil); err != nil {
+		log.Warnf("Failed to publish the result of %s to %s: %v", res.Command, topic, err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// resultOf returns the result field of an RPC reply
+func resultOf(res gammazero.InvokeResult) any {
+	if len(res.Args) == 0 {
+		return nil
+	}
+	reply, _ := res.Args[0].(map[string]any)
+	return reply["result"]
+}
+
+type published struct {
+	topic string
+	event map[string]any
+}
+
+// newResultTestManager returns a manager allowed to run sh that records
+// what it publishes
+func newResultTestManager(t *testing.T, topic string) (*Manager, *[]published) {
+	t.Helper()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	sh, _ = filepath.Abs(sh)
+
+	path := filepath.Join(t.TempDir(), "commands.allow")
+	if err := os.WriteFile(path, []byte(sh+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.Commands.ResultTopic = topic
+
+	var events []published
+	m := &Manager{
+		cfg:       cfg,
+		board:     &board.Board{UUID: "board-1"},
+		allowlist: newAllowlist(path),
+		publish: func(topic string, args []any, _ map[string]any) error {
+			events = append(events, published{topic, args[0].(map[string]any)})
+			return nil
+		},
+	}
+	return m, &events
+}
+
+func TestExecCommandPublishesResult(t *testing.T) {
+	m, events := newResultTestManager(t, "iotronic.commands.results")
+
+	inv := &nexuswamp.Invocation{Arguments: nexuswamp.List{"sh", "-c", "head -c 5000 /dev/zero | tr '\\0' x; exit 3"}}
+	if res := m.handleExecCommand(context.Background(), inv); resultOf(res) != rpc.ResultSuccess {
+		t.Fatalf("ExecCommand failed: %v", res.Args)
+	}
+
+	if len(*events) != 1 {
+		t.Fatalf("published %d events, want 1", len(*events))
+	}
+	got := (*events)[0]
+	if got.topic != "iotronic.commands.results" {
+		t.Errorf("topic = %q", got.topic)
+	}
+	if got.event["board"] != "board-1" || !strings.HasSuffix(got.event["command"].(string), "/sh") || got.event["exit_code"] != 3 {
+		t.Errorf("event = %v", got.event)
+	}
+	if out := got.event["output"].(string); len(out) != maxPublishedOutput || got.event["truncated"] != true {
+		t.Errorf("output of %d bytes, truncated %v; want %d bytes, truncated", len(out), got.event["truncated"], maxPublishedOutput)
+	}
+}
+
+func TestExecCommandResultTopicUnset(t *testing.T) {
+	m, events := newResultTestManager(t, "")
+
+	inv := &nexuswamp.Invocation{Arguments: nexuswamp.List{"sh", "-c", "exit 0"}}
+	if res := m.handleExecCommand(context.Background(), inv); resultOf(res) != rpc.ResultSuccess {
+		t.Fatalf("ExecCommand failed: %v", res.Args)
+	}
+	if len(*events) != 0 {
+		t.Errorf("published %v with no result_topic", *events)
+	}
+}
+
+func TestExecCommandNotAllowedNotPublished(t *testing.T) {
+	m, events := newResultTestManager(t, "iotronic.commands.results")
+
+	inv := &nexuswamp.Invocation{Arguments: nexuswamp.List{"rm", "-rf", "/"}}
+	if res := m.handleExecCommand(context.Background(), inv); resultOf(res) != rpc.ResultError {
+		t.Fatal("ExecCommand of a command not allowed succeeded")
+	}
+	if len(*events) != 0 {
+		t.Errorf("published %v for a rejected command", *events)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleSetMaintenance handles the SetMaintenance RPC, which turns
+// maintenance mode on or off; while on, procedures that change board state
+// are rejected and read-only ones keep working
+func (m *Manager) handleSetMaintenance(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC SetMaintenance called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: on required")
+	}
+	on, ok := inv.Arguments[0].(bool)
+	if !ok {
+		return rpc.Error("Invalid on type: boolean required")
+	}
+
+	was := m.wampClient.SetMaintenance(on)
+
+	message := "Maintenance mode off"
+	if on {
+		message = "Maintenance mode on"
+	}
+	return rpc.Success(message, map[string]any{
+		"maintenance":          on,
+		"previous_maintenance": was,
+	})
+}
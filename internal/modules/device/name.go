@@ -0,0 +1,53 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleSetName handles the SetName RPC
+func (m *Manager) handleSetName(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC SetName called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: name required")
+	}
+
+	name, ok := inv.Arguments[0].(string)
+	if !ok {
+		return rpc.Error("Invalid name type")
+	}
+
+	old, err := m.board.SetName(name)
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to rename board: %v", err))
+	}
+	log.Infof("Board renamed from %q to %q", old, name)
+
+	// The WAMP client announces the new board info
+	return rpc.Success(fmt.Sprintf("Board renamed to %s", name), map[string]any{
+		"uuid":     m.board.UUID,
+		"name":     name,
+		"old_name": old,
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestSetName(t *testing.T) {
+	tests := []struct {
+		name       string
+		arg        any
+		wantResult string
+	}{
+		{"valid", "greenhouse-7", rpc.ResultSuccess},
+		{"with spaces", "Greenhouse 7", rpc.ResultSuccess},
+		{"empty", "", rpc.ResultError},
+		{"whitespace only", "   ", rpc.ResultError},
+		{"too long", strings.Repeat("x", board.MaxNameLen+1), rpc.ResultError},
+		{"control character", "board\n7", rpc.ResultError},
+		{"not a string", 7.0, rpc.ResultError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, cfg, _ := newRebootTestManager(t)
+			changes := make(chan string, 4)
+			unsub := eventbus.Subscribe(eventbus.Default, eventbus.BoardChanged, func(e eventbus.BoardChange) {
+				changes <- e.Field
+			})
+			t.Cleanup(unsub)
+
+			res := m.handleSetName(context.Background(), &nexuswamp.Invocation{Arguments: nexuswamp.List{tt.arg}})
+			reply := res.Args[0].(map[string]any)
+			if reply["result"] != tt.wantResult {
+				t.Fatalf("SetName(%v) = %v", tt.arg, reply)
+			}
+
+			if tt.wantResult != rpc.ResultSuccess {
+				if got := loadBoard(t, cfg).GetName(); got != "" {
+					t.Errorf("saved name = %q after a rejected SetName", got)
+				}
+				select {
+				case field := <-changes:
+					t.Errorf("board change %q published for a rejected SetName", field)
+				default:
+				}
+				return
+			}
+
+			if got := m.board.GetName(); got != tt.arg {
+				t.Errorf("name = %q, want %v", got, tt.arg)
+			}
+			if got := loadBoard(t, cfg).GetName(); got != tt.arg {
+				t.Errorf("name after reload = %q, want %v", got, tt.arg)
+			}
+			select {
+			case field := <-changes:
+				if field != "name" {
+					t.Errorf("board change = %q, want name", field)
+				}
+			case <-time.After(5 * time.Second):
+				t.Error("no board change published")
+			}
+		})
+	}
+}
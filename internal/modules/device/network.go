@@ -0,0 +1,115 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"os"
+	"strings"
+)
+
+// routeFile is the kernel IPv4 routing table
+var routeFile = "/proc/net/route"
+
+// NetworkInterface describes a network interface of the board
+type NetworkInterface struct {
+	Name  string   `json:"name"`
+	MAC   string   `json:"mac"`
+	Up    bool     `json:"up"`
+	IPv4  []string `json:"ipv4"`
+	IPv6  []string `json:"ipv6"`
+	Flags string   `json:"flags"`
+}
+
+// listInterfaces enumerates the board's network interfaces, skipping
+// loopback unless includeLoopback is set
+func listInterfaces(includeLoopback bool) ([]NetworkInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]NetworkInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && !includeLoopback {
+			continue
+		}
+
+		ni := NetworkInterface{
+			Name:  iface.Name,
+			MAC:   iface.HardwareAddr.String(),
+			Up:    iface.Flags&net.FlagUp != 0,
+			IPv4:  []string{},
+			IPv6:  []string{},
+			Flags: iface.Flags.String(),
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ipNet.IP.To4() != nil {
+				ni.IPv4 = append(ni.IPv4, ipNet.String())
+			} else {
+				ni.IPv6 = append(ni.IPv6, ipNet.String())
+			}
+		}
+
+		result = append(result, ni)
+	}
+
+	return result, nil
+}
+
+// defaultGateway returns the IPv4 default gateway and its interface, or
+// empty strings when no default route is present
+func defaultGateway() (string, string) {
+	f, err := os.Open(routeFile)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+
+		// The gateway is a little-endian hex encoded IPv4 address
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+
+		return ip.String(), fields[0]
+	}
+
+	return "", ""
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// findLoopback returns the loopback interface in ifaces, if any
+func findLoopback(ifaces []NetworkInterface) (NetworkInterface, bool) {
+	for _, ni := range ifaces {
+		if strings.Contains(ni.Flags, "loopback") {
+			return ni, true
+		}
+	}
+	return NetworkInterface{}, false
+}
+
+func TestListInterfaces(t *testing.T) {
+	ifaces, err := listInterfaces(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo, ok := findLoopback(ifaces)
+	if !ok {
+		t.Skipf("no loopback interface in %+v", ifaces)
+	}
+	if lo.Name == "" || !lo.Up || lo.IPv4 == nil || lo.IPv6 == nil {
+		t.Errorf("loopback = %+v, want a named interface that is up", lo)
+	}
+	if len(lo.IPv4)+len(lo.IPv6) == 0 {
+		t.Errorf("loopback %s has no addresses", lo.Name)
+	}
+	for _, addr := range lo.IPv4 {
+		if !strings.Contains(addr, "/") || strings.Contains(addr, ":") {
+			t.Errorf("IPv4 address %q is not an IPv4 CIDR", addr)
+		}
+	}
+
+	ifaces, err = listInterfaces(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lo, ok := findLoopback(ifaces); ok {
+		t.Errorf("loopback %s listed by default", lo.Name)
+	}
+}
+
+func TestNetworkInterfacesRPC(t *testing.T) {
+	m := &Manager{}
+	res := m.handleNetworkInterfaces(context.Background(), &nexuswamp.Invocation{
+		ArgumentsKw: nexuswamp.Dict{"include_loopback": true},
+	})
+	reply, _ := res.Args[0].(map[string]any)
+	data, _ := reply["data"].(map[string]any)
+	ifaces, ok := data["interfaces"].([]NetworkInterface)
+	if reply["result"] != "SUCCESS" || !ok {
+		t.Fatalf("NetworkInterfaces = %v", reply)
+	}
+	if _, ok := findLoopback(ifaces); !ok && len(ifaces) > 0 {
+		t.Errorf("loopback missing with include_loopback: %+v", ifaces)
+	}
+	if _, ok := data["gateway"]; !ok {
+		t.Error("reply has no gateway key")
+	}
+}
+
+func TestDefaultGateway(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		gateway string
+		iface   string
+	}{
+		{
+			"default route",
+			"Iface\tDestination\tGateway\tFlags\n" +
+				"eth0\t0001A8C0\t00000000\t0001\n" +
+				"eth0\t00000000\t0101A8C0\t0003\n",
+			"192.168.1.1", "eth0",
+		},
+		{
+			"no default route",
+			"Iface\tDestination\tGateway\tFlags\n" +
+				"wlan0\t0000000A\t00000000\t0001\n",
+			"", "",
+		},
+		{"no table", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "route")
+			if tt.table != "" {
+				if err := os.WriteFile(path, []byte(tt.table), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			orig := routeFile
+			routeFile = path
+			t.Cleanup(func() { routeFile = orig })
+
+			gateway, iface := defaultGateway()
+			if gateway != tt.gateway || iface != tt.iface {
+				t.Errorf("defaultGateway = %q %q, want %q %q", gateway, iface, tt.gateway, tt.iface)
+			}
+		})
+	}
+}
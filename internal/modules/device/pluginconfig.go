@@ -0,0 +1,122 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// stringArgs returns the first len(names) positional arguments as strings
+func stringArgs(inv *nexuswamp.Invocation, names ...string) ([]string, error) {
+	if len(inv.Arguments) < len(names) {
+		return nil, fmt.Errorf("Missing argument: %v required", names)
+	}
+
+	out := make([]string, len(names))
+	for i, name := range names {
+		s, ok := inv.Arguments[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("Invalid %s type", name)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// The Config RPCs keep plugin settings in per-plugin namespaces. These
+// only stop the keys of different plugins from colliding: the RPCs carry
+// no plugin identity, so every caller can read and change every namespace.
+
+// handleConfigGet handles the ConfigGet RPC: the value of a key, or every
+// key of the namespace when no key is given
+func (m *Manager) handleConfigGet(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ConfigGet called")
+
+	args, err := stringArgs(inv, "namespace")
+	if err != nil {
+		return rpc.Error(err.Error())
+	}
+	namespace := args[0]
+
+	values, err := m.board.PluginConfig(namespace)
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to get plugin config: %v", err))
+	}
+
+	if len(inv.Arguments) < 2 {
+		return rpc.Success(fmt.Sprintf("Plugin config of %s retrieved", namespace), values)
+	}
+
+	args, err = stringArgs(inv, "namespace", "key")
+	if err != nil {
+		return rpc.Error(err.Error())
+	}
+	value, ok := values[args[1]]
+	if !ok {
+		return rpc.Error(fmt.Sprintf("key %s not found in %s", args[1], namespace))
+	}
+
+	return rpc.Success(fmt.Sprintf("Plugin config %s.%s retrieved", namespace, args[1]), map[string]any{
+		"namespace": namespace,
+		"key":       args[1],
+		"value":     value,
+	})
+}
+
+// handleConfigSet handles the ConfigSet RPC
+func (m *Manager) handleConfigSet(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ConfigSet called")
+
+	args, err := stringArgs(inv, "namespace", "key")
+	if err != nil {
+		return rpc.Error(err.Error())
+	}
+	if len(inv.Arguments) < 3 {
+		return rpc.Error("Missing argument: value required")
+	}
+
+	if err := m.board.SetPluginConfig(args[0], args[1], inv.Arguments[2]); err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to set plugin config: %v", err))
+	}
+
+	return rpc.Success(fmt.Sprintf("Plugin config %s.%s set", args[0], args[1]), nil)
+}
+
+// handleConfigDelete handles the ConfigDelete RPC
+func (m *Manager) handleConfigDelete(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ConfigDelete called")
+
+	args, err := stringArgs(inv, "namespace", "key")
+	if err != nil {
+		return rpc.Error(err.Error())
+	}
+
+	existed, err := m.board.DeletePluginConfig(args[0], args[1])
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to delete plugin config: %v", err))
+	}
+	if !existed {
+		return rpc.Error(fmt.Sprintf("key %s not found in %s", args[1], args[0]))
+	}
+
+	return rpc.Success(fmt.Sprintf("Plugin config %s.%s deleted", args[0], args[1]), nil)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestPluginConfigRPCs(t *testing.T) {
+	m, _, _ := newRebootTestManager(t)
+	ctx := context.Background()
+	invoke := func(args ...any) *nexuswamp.Invocation {
+		return &nexuswamp.Invocation{Arguments: nexuswamp.List(args)}
+	}
+
+	if res := m.handleConfigSet(ctx, invoke("weather", "unit", "C")); resultOf(res) != rpc.ResultSuccess {
+		t.Fatalf("ConfigSet = %v", res.Args)
+	}
+	reply, _ := m.handleConfigGet(ctx, invoke("weather", "unit")).Args[0].(map[string]any)
+	if data, _ := reply["data"].(map[string]any); reply["result"] != rpc.ResultSuccess || data["value"] != "C" {
+		t.Errorf("ConfigGet(weather, unit) = %v, want value C", reply)
+	}
+
+	tests := []struct {
+		name    string
+		handler func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult
+		args    []any
+		want    string
+	}{
+		{"get namespace", m.handleConfigGet, []any{"weather"}, rpc.ResultSuccess},
+		{"get missing key", m.handleConfigGet, []any{"weather", "wind"}, rpc.ResultError},
+		{"get without namespace", m.handleConfigGet, nil, rpc.ResultError},
+		{"set without value", m.handleConfigSet, []any{"weather", "wind"}, rpc.ResultError},
+		{"set invalid namespace", m.handleConfigSet, []any{"a b", "k", 1}, rpc.ResultError},
+		{"delete", m.handleConfigDelete, []any{"weather", "unit"}, rpc.ResultSuccess},
+		{"delete again", m.handleConfigDelete, []any{"weather", "unit"}, rpc.ResultError},
+	}
+	for _, tt := range tests {
+		if got := resultOf(tt.handler(ctx, invoke(tt.args...))); got != tt.want {
+			t.Errorf("%s: result = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
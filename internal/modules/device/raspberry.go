@@ -0,0 +1,116 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Raspberry Pi firmware files, replaceable for testing
+var (
+	throttledFile = "/sys/devices/platform/soc/soc:firmware/get_throttled"
+	serialFile    = "/sys/firmware/devicetree/base/serial-number"
+)
+
+// RaspberryDevice is a Raspberry Pi: a generic device that also reports
+// the board serial number and the firmware throttling state
+type RaspberryDevice struct {
+	GenericDevice
+}
+
+func newRaspberryDevice(boardType string) Device {
+	return &RaspberryDevice{GenericDevice{deviceType: boardType}}
+}
+
+// Throttling is the firmware power and thermal state: each flag holds now
+// and the matching Occurred flag has held at some point since boot
+type Throttling struct {
+	UnderVoltage    bool `json:"under_voltage"`
+	FrequencyCapped bool `json:"frequency_capped"`
+	Throttled       bool `json:"throttled"`
+	SoftTempLimit   bool `json:"soft_temp_limit"`
+
+	UnderVoltageOccurred    bool `json:"under_voltage_occurred"`
+	FrequencyCappedOccurred bool `json:"frequency_capped_occurred"`
+	ThrottledOccurred       bool `json:"throttled_occurred"`
+	SoftTempLimitOccurred   bool `json:"soft_temp_limit_occurred"`
+}
+
+// parseThrottled decodes the hex bit field reported by get_throttled
+func parseThrottled(s string) (*Throttling, error) {
+	bits, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "0x"), 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	set := func(bit uint) bool { return bits&(1<<bit) != 0 }
+
+	return &Throttling{
+		UnderVoltage:            set(0),
+		FrequencyCapped:         set(1),
+		Throttled:               set(2),
+		SoftTempLimit:           set(3),
+		UnderVoltageOccurred:    set(16),
+		FrequencyCappedOccurred: set(17),
+		ThrottledOccurred:       set(18),
+		SoftTempLimitOccurred:   set(19),
+	}, nil
+}
+
+// readThrottling returns the throttling state, or nil where the firmware
+// does not expose it
+func readThrottling() *Throttling {
+	data, err := os.ReadFile(throttledFile)
+	if err != nil {
+		return nil
+	}
+	t, err := parseThrottled(string(data))
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+func (d *RaspberryDevice) GetInfo() (map[string]any, error) {
+	info, err := d.GenericDevice.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	// Device tree strings are NUL-terminated
+	if data, err := os.ReadFile(serialFile); err == nil {
+		if serial := strings.TrimSpace(strings.TrimRight(string(data), "\x00")); serial != "" {
+			info["serial"] = serial
+		}
+	}
+
+	return info, nil
+}
+
+func (d *RaspberryDevice) GetStatus(ctx context.Context) (map[string]any, error) {
+	status, err := d.GenericDevice.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := readThrottling(); t != nil {
+		status["throttling"] = t
+	}
+
+	return status, nil
+}
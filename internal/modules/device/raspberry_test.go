@@ -0,0 +1,89 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseThrottled(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *Throttling
+		wantErr bool
+	}{
+		{"0\n", &Throttling{}, false},
+		{"50005\n", &Throttling{UnderVoltage: true, Throttled: true, UnderVoltageOccurred: true, ThrottledOccurred: true}, false},
+		{"0x80008", &Throttling{SoftTempLimit: true, SoftTempLimitOccurred: true}, false},
+		{"20000", &Throttling{FrequencyCappedOccurred: true}, false},
+		{"throttled", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseThrottled(tt.in)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseThrottled(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestRaspberryDevice(t *testing.T) {
+	origThrottled, origSerial := throttledFile, serialFile
+	t.Cleanup(func() { throttledFile, serialFile = origThrottled, origSerial })
+
+	dir := t.TempDir()
+	throttledFile = filepath.Join(dir, "get_throttled")
+	serialFile = filepath.Join(dir, "serial-number")
+	if err := os.WriteFile(throttledFile, []byte("50000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(serialFile, []byte("00000000a1b2c3d4\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dev := newDevice("raspberry")
+	info, err := dev.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["type"] != "raspberry" || info["serial"] != "00000000a1b2c3d4" {
+		t.Errorf("GetInfo = %v, want the raspberry type and serial", info)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	status, err := dev.GetStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Throttling{UnderVoltageOccurred: true, ThrottledOccurred: true}
+	if got, _ := status["throttling"].(*Throttling); !reflect.DeepEqual(got, want) {
+		t.Errorf("throttling = %+v, want %+v", status["throttling"], want)
+	}
+
+	// Without the firmware files the generic fields are still reported
+	os.Remove(throttledFile)
+	os.Remove(serialFile)
+	if info, _ := dev.GetInfo(); info["serial"] != nil {
+		t.Errorf("serial = %v without a serial-number file", info["serial"])
+	}
+	if status, _ := dev.GetStatus(ctx); status["throttling"] != nil || status["status"] != "online" {
+		t.Errorf("GetStatus = %v without get_throttled, want generic status only", status)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reboot hooks, replaceable for testing
+var (
+	// rebootDelay lets the RPC reply reach the caller before the reboot
+	rebootDelay = 3 * time.Second
+
+	// runReboot issues the reboot
+	runReboot = func() error {
+		return exec.Command("/sbin/reboot").Run()
+	}
+)
+
+// Reboot records reason and reboots the board after rebootDelay. Nothing
+// is issued if the reason cannot be recorded.
+func (m *Manager) Reboot(reason string) error {
+	if err := m.board.RecordReboot(reason); err != nil {
+		err = fmt.Errorf("failed to record reboot reason: %w", err)
+		m.errs.Record("reboot", err)
+		return err
+	}
+
+	log.Warnf("Rebooting in %v (reason: %s)", rebootDelay, reason)
+	go func() {
+		time.Sleep(rebootDelay)
+		if err := runReboot(); err != nil {
+			log.Errorf("Reboot failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// handleReboot handles the Reboot RPC, with an optional reason argument
+// defaulting to rpc
+func (m *Manager) handleReboot(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC Reboot called")
+
+	reason := board.RebootRPC
+	if len(inv.Arguments) > 0 {
+		s, ok := inv.Arguments[0].(string)
+		if !ok {
+			return rpc.Error("Invalid reason type: string required")
+		}
+		reason = s
+	}
+
+	if err := m.Reboot(reason); err != nil {
+		return rpc.Error(err.Error())
+	}
+	return rpc.Success(fmt.Sprintf("Rebooting in %v", rebootDelay), map[string]any{"reason": reason})
+}
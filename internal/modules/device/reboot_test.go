@@ -0,0 +1,149 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+const testSettings = `{
+  "iotronic": {
+    "board": {"uuid": "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11", "code": "TESTCODE", "status": "registered", "type": "server"},
+    "wamp": {"main-agent": {"url": "ws://router.test:8181/", "realm": "s4t"}}
+  }
+}`
+
+// loadBoard loads the board of the settings file in cfg
+func loadBoard(t *testing.T, cfg *config.Config) *board.Board {
+	t.Helper()
+	b, err := board.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to load board: %v", err)
+	}
+	return b
+}
+
+// newRebootTestManager returns a manager for a test board whose reboots
+// report the reboot reason saved when they are issued
+func newRebootTestManager(t *testing.T) (*Manager, *config.Config, <-chan string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = dir
+	cfg.Board.SettingsFile = filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(cfg.Board.SettingsFile, []byte(testSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := make(chan string, 1)
+	origDelay, origRun := rebootDelay, runReboot
+	rebootDelay = 0
+	runReboot = func() error {
+		var settings config.BoardSettings
+		data, _ := os.ReadFile(cfg.Board.SettingsFile)
+		json.Unmarshal(data, &settings)
+		reason, _ := settings.Iotronic.Board.Extra["last_reboot_reason"].(string)
+		saved <- reason
+		return nil
+	}
+	t.Cleanup(func() { rebootDelay, runReboot = origDelay, origRun })
+
+	return &Manager{cfg: cfg, board: loadBoard(t, cfg)}, cfg, saved
+}
+
+func TestRebootRecordsReasonFirst(t *testing.T) {
+	m, cfg, saved := newRebootTestManager(t)
+
+	inv := &nexuswamp.Invocation{Arguments: nexuswamp.List{board.RebootWatchdog}}
+	if res := m.handleReboot(context.Background(), inv); resultOf(res) != rpc.ResultSuccess {
+		t.Fatalf("Reboot failed: %v", res.Args)
+	}
+
+	select {
+	case reason := <-saved:
+		if reason != board.RebootWatchdog {
+			t.Errorf("reason saved when the reboot was issued = %q, want %q", reason, board.RebootWatchdog)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reboot was not issued")
+	}
+
+	// After the reboot the reason is reported
+	last := loadBoard(t, cfg).LastReboot()
+	if last == nil || last.Reason != board.RebootWatchdog || last.At == "" {
+		t.Errorf("LastReboot after reload = %+v", last)
+	}
+}
+
+func TestRebootDefaultReason(t *testing.T) {
+	m, _, saved := newRebootTestManager(t)
+
+	if res := m.handleReboot(context.Background(), &nexuswamp.Invocation{}); resultOf(res) != rpc.ResultSuccess {
+		t.Fatalf("Reboot failed: %v", res.Args)
+	}
+	if reason := <-saved; reason != board.RebootRPC {
+		t.Errorf("reason = %q, want %q", reason, board.RebootRPC)
+	}
+}
+
+func TestRebootUnknownReason(t *testing.T) {
+	m, _, saved := newRebootTestManager(t)
+
+	inv := &nexuswamp.Invocation{Arguments: nexuswamp.List{"bored"}}
+	if res := m.handleReboot(context.Background(), inv); resultOf(res) != rpc.ResultError {
+		t.Fatalf("Reboot with an unknown reason succeeded: %v", res.Args)
+	}
+	select {
+	case <-saved:
+		t.Error("reboot issued for an unknown reason")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if last := m.board.LastReboot(); last != nil {
+		t.Errorf("LastReboot = %+v, want none", last)
+	}
+}
+
+func TestRebootNotIssuedWhenUnsaved(t *testing.T) {
+	m, cfg, saved := newRebootTestManager(t)
+
+	// The settings directory goes away, so the reason cannot be saved
+	if err := os.RemoveAll(filepath.Dir(cfg.Board.SettingsFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Reboot(board.RebootConfigChange); err == nil {
+		t.Fatal("Reboot succeeded without saving its reason")
+	}
+	select {
+	case <-saved:
+		t.Error("reboot issued without a saved reason")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if last := m.board.LastReboot(); last != nil {
+		t.Errorf("LastReboot = %+v, want the failed record rolled back", last)
+	}
+}
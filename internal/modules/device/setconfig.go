@@ -0,0 +1,64 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleSetConfig handles the SetConfig RPC, replacing the board settings
+// with the settings object argument. Rejected settings are answered with
+// an INVALID_CONFIG error whose data lists each problem by path.
+func (m *Manager) handleSetConfig(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC SetConfig called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: settings required")
+	}
+	if _, ok := inv.Arguments[0].(map[string]any); !ok {
+		return rpc.Error("Invalid settings type: object required")
+	}
+
+	raw, err := json.Marshal(inv.Arguments[0])
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Invalid settings: %v", err))
+	}
+	var settings config.BoardSettings
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return rpc.ErrorData("INVALID_CONFIG", "Invalid settings", []board.FieldError{{Path: "iotronic", Reason: err.Error()}})
+	}
+
+	if err := m.board.SetConfig(&settings); err != nil {
+		var invalid *board.ValidationError
+		if errors.As(err, &invalid) {
+			return rpc.ErrorData("INVALID_CONFIG", fmt.Sprintf("Settings rejected: %d invalid fields", len(invalid.Fields)), invalid.Fields)
+		}
+		return rpc.Error(fmt.Sprintf("Failed to set config: %v", err))
+	}
+	log.Info("Board settings replaced")
+
+	return rpc.Success("Board settings updated", nil)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// setConfig invokes SetConfig with the settings decoded from doc
+func setConfig(t *testing.T, m *Manager, doc string) map[string]any {
+	t.Helper()
+
+	var settings map[string]any
+	if err := json.Unmarshal([]byte(doc), &settings); err != nil {
+		t.Fatal(err)
+	}
+	res := m.handleSetConfig(context.Background(), &nexuswamp.Invocation{Arguments: nexuswamp.List{settings}})
+	reply, _ := res.Args[0].(map[string]any)
+	return reply
+}
+
+func TestSetConfigFieldErrors(t *testing.T) {
+	m, cfg, _ := newRebootTestManager(t)
+	before, err := os.ReadFile(cfg.Board.SettingsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := setConfig(t, m, `{
+  "iotronic": {
+    "board": {"uuid": "", "code": "TESTCODE", "status": "bogus", "extra": {"tags": {"bad key": "x"}}},
+    "wamp": {
+      "main-agent": {"url": "http://router.test:8181/", "realm": ""},
+      "secondary-agents": [{"url": "ws://", "realm": "s4t"}]
+    }
+  }
+}`)
+
+	if reply["result"] != rpc.ResultError || reply["code"] != "INVALID_CONFIG" {
+		t.Fatalf("SetConfig = %v, want an INVALID_CONFIG error", reply)
+	}
+	fields, ok := reply["data"].([]board.FieldError)
+	if !ok {
+		t.Fatalf("data = %T, want []board.FieldError", reply["data"])
+	}
+
+	got := make(map[string]string)
+	for _, f := range fields {
+		got[f.Path] = f.Reason
+	}
+	for _, path := range []string{
+		"iotronic.board.uuid",
+		"iotronic.board.status",
+		"iotronic.board.extra.tags.bad key",
+		"iotronic.wamp.main-agent.url",
+		"iotronic.wamp.main-agent.realm",
+		"iotronic.wamp.secondary-agents[0].url",
+	} {
+		if got[path] == "" {
+			t.Errorf("no problem reported for %s (got %v)", path, fields)
+		}
+	}
+	if len(fields) != 6 {
+		t.Errorf("%d problems reported, want 6: %v", len(fields), fields)
+	}
+
+	// Nothing is written
+	after, err := os.ReadFile(cfg.Board.SettingsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("rejected settings were saved")
+	}
+}
+
+func TestSetConfigValid(t *testing.T) {
+	m, _, _ := newRebootTestManager(t)
+
+	reply := setConfig(t, m, `{
+  "iotronic": {
+    "board": {"uuid": "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11", "code": "TESTCODE", "status": "registered", "name": "renamed"},
+    "wamp": {"main-agent": {"url": "wss://router.test:8181/", "realm": "s4t"}}
+  }
+}`)
+	if reply["result"] != rpc.ResultSuccess {
+		t.Fatalf("SetConfig = %v, want success", reply)
+	}
+	if got := m.board.GetName(); got != "renamed" {
+		t.Errorf("board name = %q, want renamed", got)
+	}
+	if got := m.board.GetWampURL(); got != "wss://router.test:8181/" {
+		t.Errorf("WAMP URL = %q, want the new main agent", got)
+	}
+}
+
+func TestSetConfigMissingArgument(t *testing.T) {
+	m, _, _ := newRebootTestManager(t)
+
+	for _, args := range []nexuswamp.List{nil, {"not an object"}} {
+		res := m.handleSetConfig(context.Background(), &nexuswamp.Invocation{Arguments: args})
+		if resultOf(res) != rpc.ResultError {
+			t.Errorf("SetConfig(%v) = %v, want an error", args, res.Args)
+		}
+	}
+}
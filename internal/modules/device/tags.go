@@ -0,0 +1,66 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleGetTags handles the GetTags RPC
+func (m *Manager) handleGetTags(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC GetTags called")
+
+	return rpc.SuccessObject("Board tags retrieved", m.board.Tags())
+}
+
+// handleSetTags handles the SetTags RPC. Tags are merged into the existing
+// ones unless the "replace" kwarg is set.
+func (m *Manager) handleSetTags(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC SetTags called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: tags required")
+	}
+
+	raw, ok := inv.Arguments[0].(map[string]any)
+	if !ok {
+		return rpc.Error("Invalid tags type: expected a map of strings")
+	}
+
+	tags := make(map[string]string, len(raw))
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return rpc.Error(fmt.Sprintf("Invalid value for tag %s: expected a string", k))
+		}
+		tags[k] = s
+	}
+
+	replace, _ := inv.ArgumentsKw["replace"].(bool)
+
+	result, err := m.board.SetTags(tags, replace)
+	if err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to set tags: %v", err))
+	}
+
+	return rpc.SuccessObject("Board tags updated", result)
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// telemetryEncoding names the format of compressed telemetry data
+const telemetryEncoding = "gzip+base64"
+
+// telemetryTopic returns the topic the device status is published to
+func (m *Manager) telemetryTopic() string {
+	return fmt.Sprintf("iotronic.board.%s.telemetry", m.board.UUID)
+}
+
+// encodeTelemetry returns the message carrying status: status itself,
+// flagged as uncompressed, or its gzipped JSON encoded in base64
+func encodeTelemetry(status map[string]any, compress bool) (map[string]any, error) {
+	if !compress {
+		msg := make(map[string]any, len(status)+1)
+		for k, v := range status {
+			msg[k] = v
+		}
+		msg["compressed"] = false
+		return msg, nil
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"compressed": true,
+		"encoding":   telemetryEncoding,
+		"data":       base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// publishTelemetry publishes the current device status
+func (m *Manager) publishTelemetry(ctx context.Context) error {
+	status, err := m.currentDevice().GetStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get device status: %w", err)
+	}
+	status["timestamp"] = time.Now().Format("2006-01-02T15:04:05.000000")
+
+	msg, err := encodeTelemetry(status, m.cfg.Telemetry.Compress)
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry: %w", err)
+	}
+	return m.publish(m.telemetryTopic(), []any{msg}, nil)
+}
+
+// runTelemetry publishes the device status every telemetry.interval until
+// ctx is done
+func (m *Manager) runTelemetry(ctx context.Context) {
+	interval := time.Duration(m.cfg.Telemetry.Interval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.publishTelemetry(ctx); err != nil {
+				m.errs.Record("publish telemetry", err)
+				log.Warnf("Failed to publish telemetry: %v", err)
+				continue
+			}
+			m.errs.Resolve("publish telemetry")
+		}
+	}
+}
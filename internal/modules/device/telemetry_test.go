@@ -0,0 +1,142 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// inflateTelemetry decodes a compressed telemetry message as the cloud does
+func inflateTelemetry(t *testing.T, msg map[string]any) map[string]any {
+	t.Helper()
+
+	if msg["compressed"] != true || msg["encoding"] != telemetryEncoding {
+		t.Fatalf("message %v is not flagged as compressed", msg)
+	}
+	raw, err := base64.StdEncoding.DecodeString(msg["data"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status map[string]any
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+// asJSON returns v as decoded from its JSON encoding
+func asJSON(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+var sampleStatus = map[string]any{
+	"status":       "online",
+	"uptime":       int64(1700000000),
+	"load_average": []float64{0.5, 0.25, 0.1},
+	"temperatures": map[string]float64{"cpu": 48.5},
+	"cpu_percent":  12.5,
+}
+
+func TestTelemetryCompressedRoundTrip(t *testing.T) {
+	msg, err := encodeTelemetry(sampleStatus, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := inflateTelemetry(t, msg), asJSON(t, sampleStatus); !reflect.DeepEqual(got, want) {
+		t.Errorf("inflated telemetry = %v, want %v", got, want)
+	}
+}
+
+func TestTelemetryUncompressed(t *testing.T) {
+	msg, err := encodeTelemetry(sampleStatus, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg["compressed"] != false {
+		t.Errorf("compressed = %v, want false", msg["compressed"])
+	}
+	delete(msg, "compressed")
+	if !reflect.DeepEqual(msg, sampleStatus) {
+		t.Errorf("telemetry = %v, want the status as is", msg)
+	}
+	if _, ok := sampleStatus["compressed"]; ok {
+		t.Error("encoding modified the status")
+	}
+}
+
+// fixedDevice reports a fixed status
+type fixedDevice struct{ *GenericDevice }
+
+func (fixedDevice) GetStatus(context.Context) (map[string]any, error) {
+	return map[string]any{"status": "online", "uptime": 42}, nil
+}
+
+func TestPublishTelemetry(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Telemetry.Compress = true
+
+	var topic string
+	var msg map[string]any
+	m := &Manager{
+		cfg:    cfg,
+		board:  &board.Board{UUID: "board-1"},
+		device: fixedDevice{&GenericDevice{}},
+		publish: func(tp string, args []any, _ map[string]any) error {
+			topic, msg = tp, args[0].(map[string]any)
+			return nil
+		},
+	}
+
+	if err := m.publishTelemetry(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if topic != "iotronic.board.board-1.telemetry" {
+		t.Errorf("topic = %q", topic)
+	}
+	status := inflateTelemetry(t, msg)
+	if status["status"] != "online" || status["uptime"] != 42.0 || status["timestamp"] == nil {
+		t.Errorf("published status = %v", status)
+	}
+}
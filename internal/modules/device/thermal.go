@@ -0,0 +1,90 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/host"
+	log "github.com/sirupsen/logrus"
+)
+
+// thermalRoot is the sysfs directory exposing the kernel thermal zones
+var thermalRoot = "/sys/class/thermal"
+
+// Temperature is a single sensor reading in degrees Celsius
+type Temperature struct {
+	Name    string  `json:"name"`
+	Celsius float64 `json:"celsius"`
+}
+
+// readTemperatures returns the board temperatures from the thermal zones,
+// falling back to gopsutil sensors. An empty slice means no sensors.
+func readTemperatures() []Temperature {
+	temps := readThermalZones(thermalRoot)
+	if len(temps) > 0 {
+		return temps
+	}
+
+	sensors, err := host.SensorsTemperatures()
+	if err != nil && len(sensors) == 0 {
+		log.Debugf("No temperature sensors available: %v", err)
+		return []Temperature{}
+	}
+
+	temps = make([]Temperature, 0, len(sensors))
+	for _, s := range sensors {
+		temps = append(temps, Temperature{Name: s.SensorKey, Celsius: s.Temperature})
+	}
+
+	return temps
+}
+
+// readThermalZones reads every thermal_zone*/temp file below root
+func readThermalZones(root string) []Temperature {
+	zones, err := filepath.Glob(filepath.Join(root, "thermal_zone*"))
+	if err != nil {
+		return nil
+	}
+
+	var temps []Temperature
+	for _, zone := range zones {
+		data, err := os.ReadFile(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+
+		// Values are reported in millidegrees Celsius
+		milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			continue
+		}
+
+		name := filepath.Base(zone)
+		if t, err := os.ReadFile(filepath.Join(zone, "type")); err == nil {
+			if s := strings.TrimSpace(string(t)); s != "" {
+				name = s
+			}
+		}
+
+		temps = append(temps, Temperature{Name: name, Celsius: milli / 1000})
+	}
+
+	return temps
+}
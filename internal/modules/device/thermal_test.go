@@ -0,0 +1,92 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeZone adds a fake thermal zone below root; empty values are omitted
+func writeZone(t *testing.T, root, zone, zoneType, temp string) {
+	t.Helper()
+	dir := filepath.Join(root, zone)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if zoneType != "" {
+		if err := os.WriteFile(filepath.Join(dir, "type"), []byte(zoneType+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if temp != "" {
+		if err := os.WriteFile(filepath.Join(dir, "temp"), []byte(temp+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadThermalZones(t *testing.T) {
+	root := t.TempDir()
+	writeZone(t, root, "thermal_zone0", "cpu-thermal", "45123")
+	writeZone(t, root, "thermal_zone1", "", "38000")
+	writeZone(t, root, "thermal_zone2", "gpu-thermal", "n/a")
+	writeZone(t, root, "thermal_zone3", "board", "")
+	writeZone(t, root, "cooling_device0", "fan", "1")
+
+	want := []Temperature{
+		{Name: "cpu-thermal", Celsius: 45.123},
+		{Name: "thermal_zone1", Celsius: 38},
+	}
+	if got := readThermalZones(root); !reflect.DeepEqual(got, want) {
+		t.Errorf("readThermalZones = %+v, want %+v", got, want)
+	}
+
+	if got := readThermalZones(filepath.Join(root, "missing")); len(got) != 0 {
+		t.Errorf("readThermalZones without sysfs = %+v, want none", got)
+	}
+}
+
+func TestStatusTemperatures(t *testing.T) {
+	root := t.TempDir()
+	writeZone(t, root, "thermal_zone0", "cpu-thermal", "51000")
+	orig := thermalRoot
+	thermalRoot = root
+	t.Cleanup(func() { thermalRoot = orig })
+
+	status, err := (&GenericDevice{}).GetStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Temperature{{Name: "cpu-thermal", Celsius: 51}}
+	if got := status["temperatures"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("temperatures = %+v, want %+v", got, want)
+	}
+}
+
+func TestTemperaturesWithoutSensors(t *testing.T) {
+	orig := thermalRoot
+	thermalRoot = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() { thermalRoot = orig })
+
+	// Whatever gopsutil finds, the key is a list and never nil
+	if temps := readTemperatures(); temps == nil {
+		t.Error("readTemperatures = nil, want an empty list")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// deviceTypes maps the known board types to their Device implementation
+var deviceTypes = map[string]func(boardType string) Device{
+	"generic":   newGenericDevice,
+	"gateway":   newGenericDevice,
+	"server":    newGenericDevice,
+	"raspberry": newRaspberryDevice,
+}
+
+func newGenericDevice(boardType string) Device {
+	return &GenericDevice{deviceType: boardType}
+}
+
+// knownTypes returns the known board types in sorted order
+func knownTypes() []string {
+	types := make([]string, 0, len(deviceTypes))
+	for t := range deviceTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// validateType checks that boardType is a known board type
+func validateType(boardType string) error {
+	if boardType == "" {
+		return fmt.Errorf("board type is empty")
+	}
+	if _, ok := deviceTypes[boardType]; !ok {
+		return fmt.Errorf("unknown board type %q, known types: %s", boardType, strings.Join(knownTypes(), ", "))
+	}
+	return nil
+}
+
+// newDevice creates the Device implementation for boardType: the one
+// registered for it, the generic one for a board saved with an unknown
+// type, so the board still starts
+func newDevice(boardType string) Device {
+	if factory, ok := deviceTypes[boardType]; ok {
+		return factory(boardType)
+	}
+	if boardType != "" {
+		log.Warnf("Unknown board type %q, using the generic device", boardType)
+	}
+	return newGenericDevice(boardType)
+}
+
+// implementation names the Device implementation serving dev
+func implementation(dev Device) string {
+	switch dev.(type) {
+	case *GenericDevice:
+		return "generic"
+	case *RaspberryDevice:
+		return "raspberry"
+	}
+	return dev.GetType()
+}
+
+// currentDevice returns the Device implementation in use
+func (m *Manager) currentDevice() Device {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.device
+}
+
+// setType persists the board type and switches to its Device
+// implementation
+func (m *Manager) setType(boardType string) error {
+	if err := validateType(boardType); err != nil {
+		return err
+	}
+	dev := newDevice(boardType)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.board.SetType(boardType); err != nil {
+		return err
+	}
+	m.device = dev
+
+	return nil
+}
+
+// handleSetType handles the SetType RPC
+func (m *Manager) handleSetType(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC SetType called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: type required")
+	}
+
+	boardType, ok := inv.Arguments[0].(string)
+	if !ok {
+		return rpc.Error("Invalid type argument: expected a string")
+	}
+
+	oldDev := m.currentDevice()
+	if err := m.errs.Observe("set type", m.setType(boardType)); err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to set board type: %v", err))
+	}
+	dev := m.currentDevice()
+	log.Infof("Board type changed from %q to %q (%s implementation)", oldDev.GetType(), boardType, implementation(dev))
+
+	return rpc.Success(fmt.Sprintf("Board type set to %s", boardType), map[string]any{
+		"type":                   boardType,
+		"old_type":               oldDev.GetType(),
+		"implementation":         implementation(dev),
+		"implementation_changed": implementation(dev) != implementation(oldDev),
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package device
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestDefaultDeviceType(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    string
+		defaultType string
+		want        string
+	}{
+		{"typeless board", strings.Replace(testSettings, `, "type": "server"`, "", 1), "raspberry", "raspberry"},
+		{"typed board", testSettings, "raspberry", "server"},
+		{"no default", strings.Replace(testSettings, `, "type": "server"`, "", 1), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &config.Config{}
+			cfg.LightningRod.Home = dir
+			cfg.LightningRod.DefaultDeviceType = tt.defaultType
+			cfg.Board.SettingsFile = filepath.Join(dir, "settings.json")
+			if err := os.WriteFile(cfg.Board.SettingsFile, []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := NewManager(cfg, loadBoard(t, cfg), nil)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+			if got := m.device.GetType(); got != tt.want {
+				t.Errorf("device type = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetType(t *testing.T) {
+	tests := []struct {
+		name        string
+		boardType   any
+		wantResult  string
+		wantImpl    string
+		wantChanged bool
+		wantError   string
+	}{
+		{"raspberry", "raspberry", rpc.ResultSuccess, "raspberry", true, ""},
+		{"generic type", "gateway", rpc.ResultSuccess, "generic", false, ""},
+		{"unknown type", "arduino", rpc.ResultError, "", false, `unknown board type "arduino", known types: gateway, generic, raspberry, server`},
+		{"empty", "", rpc.ResultError, "", false, "board type is empty"},
+		{"not a string", 7.0, rpc.ResultError, "", false, "expected a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm, cfg, _ := newRebootTestManager(t)
+			m, err := NewManager(cfg, rm.board, nil)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			res := m.handleSetType(context.Background(), &nexuswamp.Invocation{Arguments: nexuswamp.List{tt.boardType}})
+			reply := res.Args[0].(map[string]any)
+			if reply["result"] != tt.wantResult {
+				t.Fatalf("SetType(%v) = %v", tt.boardType, reply)
+			}
+			if tt.wantResult != rpc.ResultSuccess {
+				if msg, _ := reply["message"].(string); !strings.Contains(msg, tt.wantError) {
+					t.Errorf("message = %q, want it to contain %q", msg, tt.wantError)
+				}
+				if got := m.currentDevice().GetType(); got != "server" {
+					t.Errorf("device type = %q after a rejected SetType, want server", got)
+				}
+				if got := loadBoard(t, cfg).GetType(); got != "server" {
+					t.Errorf("saved type = %q after a rejected SetType, want server", got)
+				}
+				return
+			}
+
+			data := reply["data"].(map[string]any)
+			if data["implementation"] != tt.wantImpl || data["implementation_changed"] != tt.wantChanged || data["old_type"] != "server" {
+				t.Errorf("data = %v", data)
+			}
+			if got := implementation(m.currentDevice()); got != tt.wantImpl || m.currentDevice().GetType() != tt.boardType {
+				t.Errorf("device = %#v (%s implementation) after SetType(%v)", m.currentDevice(), got, tt.boardType)
+			}
+			if got := loadBoard(t, cfg).GetType(); got != tt.boardType {
+				t.Errorf("saved type = %q, want %v", got, tt.boardType)
+			}
+		})
+	}
+}
+
+func TestUnknownSavedType(t *testing.T) {
+	// A board saved with a type no longer known still starts
+	if dev := newDevice("arduino"); implementation(dev) != "generic" || dev.GetType() != "arduino" {
+		t.Errorf("newDevice(arduino) = %#v, want a generic device keeping the type", dev)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// authorized reports whether the request carries the configured API key;
+// it is always false when no key is configured
+func (m *Manager) authorized(c *gin.Context) bool {
+	key := m.cfg.REST.APIKey
+	if key == "" {
+		return false
+	}
+
+	got := c.GetHeader("X-API-Key")
+	if got == "" {
+		got, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(key)) == 1
+}
+
+// handleSettings returns the board settings in use, with secrets redacted
+// unless include_secrets is set. Only reachable with rest.api_key.
+func (m *Manager) handleSettings(c *gin.Context) {
+	includeSecrets, _ := strconv.ParseBool(c.Query("include_secrets"))
+
+	settings, err := m.board.Settings()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !includeSecrets {
+		config.RedactSettings(settings)
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
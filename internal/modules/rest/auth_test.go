@@ -0,0 +1,65 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestSettings(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKey        string
+		target        string
+		sent          string
+		wantCode      int
+		wantBoardCode string
+	}{
+		{"no key configured", "", "/api/settings", "", http.StatusForbidden, ""},
+		{"no key configured, secrets", "", "/api/settings?include_secrets=true", "", http.StatusForbidden, ""},
+		{"missing key", testAPIKey, "/api/settings", "", http.StatusUnauthorized, ""},
+		{"wrong key", testAPIKey, "/api/settings", "nope", http.StatusUnauthorized, ""},
+		{"redacted", testAPIKey, "/api/settings", testAPIKey, http.StatusOK, "********"},
+		{"include secrets", testAPIKey, "/api/settings?include_secrets=true", testAPIKey, http.StatusOK, "TESTCODE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, func(cfg *config.Config) { cfg.REST.APIKey = tt.apiKey })
+
+			var body struct {
+				Iotronic struct {
+					Board struct {
+						Code string `json:"code"`
+						Name string `json:"name"`
+					} `json:"board"`
+				} `json:"iotronic"`
+			}
+			code := serve(t, m, newRequest(http.MethodGet, tt.target, "", tt.sent), &body)
+			if code != tt.wantCode {
+				t.Fatalf("GET %s = %d, want %d", tt.target, code, tt.wantCode)
+			}
+			if code != http.StatusOK {
+				return
+			}
+			if body.Iotronic.Board.Code != tt.wantBoardCode || body.Iotronic.Board.Name != "board-1" {
+				t.Errorf("board = %+v, want code %q", body.Iotronic.Board, tt.wantBoardCode)
+			}
+		})
+	}
+}
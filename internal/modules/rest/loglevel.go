@@ -0,0 +1,86 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// logLevels are the levels accepted by lightningrod.log_level
+var logLevels = map[string]log.Level{
+	"debug": log.DebugLevel,
+	"info":  log.InfoLevel,
+	"warn":  log.WarnLevel,
+	"error": log.ErrorLevel,
+}
+
+// levelName returns the configuration name of level
+func levelName(level log.Level) string {
+	for name, l := range logLevels {
+		if l == level {
+			return name
+		}
+	}
+	return level.String()
+}
+
+// requireAuth rejects requests without the configured API key, and every
+// request when no key is configured
+func (m *Manager) requireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.cfg.REST.APIKey == "" {
+			abortWithError(c, http.StatusForbidden, "this endpoint requires rest.api_key to be set")
+			return
+		}
+		if !m.authorized(c) {
+			abortWithError(c, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+		c.Next()
+	}
+}
+
+// handleGetLogLevel returns the current log level
+func (m *Manager) handleGetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": levelName(log.GetLevel())})
+}
+
+// handleSetLogLevel changes the log level until the next restart or log
+// level signal
+func (m *Manager) handleSetLogLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	level, ok := logLevels[req.Level]
+	if !ok {
+		abortWithError(c, http.StatusBadRequest, "unknown log level "+req.Level+", use debug, info, warn or error")
+		return
+	}
+
+	previous := log.GetLevel()
+	log.SetLevel(level)
+	log.Warnf("Log level changed from %s to %s via REST API", previous, level)
+
+	c.JSON(http.StatusOK, gin.H{"level": levelName(level), "previous_level": levelName(previous)})
+}
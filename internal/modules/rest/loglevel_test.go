@@ -0,0 +1,102 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// withLogLevel restores the log level when the test ends
+func withLogLevel(t *testing.T, level log.Level) {
+	t.Helper()
+	orig := log.GetLevel()
+	log.SetLevel(level)
+	t.Cleanup(func() { log.SetLevel(orig) })
+}
+
+func writable(cfg *config.Config) {
+	cfg.REST.ReadOnly = false
+	cfg.REST.APIKey = testAPIKey
+}
+
+func TestGetLogLevel(t *testing.T) {
+	withLogLevel(t, log.WarnLevel)
+	m := newTestManager(t, nil)
+
+	var body map[string]string
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/loglevel", "", ""), &body); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if body["level"] != "warn" {
+		t.Errorf("level = %q, want warn", body["level"])
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	withLogLevel(t, log.InfoLevel)
+	m := newTestManager(t, writable)
+
+	var body map[string]string
+	code := serve(t, m, newRequest(http.MethodPut, "/api/loglevel", `{"level":"debug"}`, testAPIKey), &body)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body %v", code, body)
+	}
+	if log.GetLevel() != log.DebugLevel {
+		t.Errorf("log level = %s, want debug", log.GetLevel())
+	}
+	if body["level"] != "debug" || body["previous_level"] != "info" {
+		t.Errorf("body = %v", body)
+	}
+
+	code = serve(t, m, newRequest(http.MethodGet, "/api/loglevel", "", ""), &body)
+	if code != http.StatusOK || body["level"] != "debug" {
+		t.Errorf("GET after PUT: %d %v", code, body)
+	}
+}
+
+func TestSetLogLevelRejected(t *testing.T) {
+	withLogLevel(t, log.InfoLevel)
+
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+		body      string
+		apiKey    string
+		want      int
+	}{
+		{"unknown level", writable, `{"level":"verbose"}`, testAPIKey, http.StatusBadRequest},
+		{"malformed body", writable, `{"level":`, testAPIKey, http.StatusBadRequest},
+		{"missing key", writable, `{"level":"debug"}`, "", http.StatusUnauthorized},
+		{"wrong key", writable, `{"level":"debug"}`, "nope", http.StatusUnauthorized},
+		{"no key configured", func(cfg *config.Config) { cfg.REST.ReadOnly = false }, `{"level":"debug"}`, "", http.StatusForbidden},
+		{"read only", func(cfg *config.Config) { cfg.REST.ReadOnly = true; cfg.REST.APIKey = testAPIKey }, `{"level":"debug"}`, testAPIKey, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, tt.configure)
+			if code := serve(t, m, newRequest(http.MethodPut, "/api/loglevel", tt.body, tt.apiKey), nil); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+			if log.GetLevel() != log.InfoLevel {
+				t.Errorf("log level changed to %s", log.GetLevel())
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLen bounds ids taken from clients, as they end up in logs
+	maxRequestIDLen = 128
+)
+
+type requestIDKey struct{}
+
+// requestIDMiddleware tags every request with an id, taken from the
+// X-Request-ID header when the client sends a usable one, and echoes it
+// in the response; the id travels in the request context so downstream
+// calls made with it can log it too
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(requestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts printable ASCII without spaces, so a client id
+// cannot break log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128 bit id in hex
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDFrom returns the request id carried by ctx, if any
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLog returns a logger tagged with the id of the request
+func requestLog(c *gin.Context) *log.Entry {
+	return log.WithField("request_id", requestIDFrom(c.Request.Context()))
+}
+
+// abortWithError aborts the request with a JSON error carrying the
+// request id, so a failing call can be matched to its log lines
+func abortWithError(c *gin.Context, status int, msg string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":      msg,
+		"request_id": requestIDFrom(c.Request.Context()),
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// captureLog redirects the standard logger to a buffer at debug level for
+// the duration of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	out, level := log.StandardLogger().Out, log.GetLevel()
+	log.SetOutput(&buf)
+	log.SetLevel(log.DebugLevel)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetLevel(level)
+	})
+	return &buf
+}
+
+func TestRequestIDHonored(t *testing.T) {
+	m := newTestManager(t, nil)
+	logs := captureLog(t)
+
+	req := newRequest(http.MethodGet, "/api/version", "", "")
+	req.Header.Set(requestIDHeader, "trace-42")
+	rec := httptest.NewRecorder()
+	m.router.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(requestIDHeader); got != "trace-42" {
+		t.Errorf("response %s = %q, want trace-42", requestIDHeader, got)
+	}
+	line := logs.String()
+	if !strings.Contains(line, "request_id=trace-42") || !strings.Contains(line, "/api/version") {
+		t.Errorf("log line does not carry the request id: %q", line)
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	m := newTestManager(t, nil)
+	logs := captureLog(t)
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("x", maxRequestIDLen+1)} {
+		req := newRequest(http.MethodGet, "/api/version", "", "")
+		if incoming != "" {
+			req.Header.Set(requestIDHeader, incoming)
+		}
+		rec := httptest.NewRecorder()
+		m.router.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(requestIDHeader)
+		if len(id) != 32 {
+			t.Errorf("incoming %q: generated id %q, want 32 hex characters", incoming, id)
+		}
+		if !strings.Contains(logs.String(), "request_id="+id) {
+			t.Errorf("incoming %q: log does not carry id %q", incoming, id)
+		}
+	}
+}
+
+func TestRequestIDInErrorResponse(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	req := newRequest(http.MethodPut, "/api/loglevel", `{"level":"debug"}`, "")
+	req.Header.Set(requestIDHeader, "trace-43")
+	rec := httptest.NewRecorder()
+	m.router.ServeHTTP(rec, req)
+
+	var body struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusForbidden || body.Error == "" {
+		t.Fatalf("PUT /api/loglevel = %d %q, want 403 with an error", rec.Code, rec.Body.String())
+	}
+	if body.RequestID != "trace-43" {
+		t.Errorf("error request_id = %q, want trace-43", body.RequestID)
+	}
+}
@@ -18,16 +18,25 @@ package rest
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"html/template"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/clock"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/logfile"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/sysinfo"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/version"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/wamp"
 	"github.com/gin-gonic/gin"
-	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/mem"
 	log "github.com/sirupsen/logrus"
 )
@@ -42,26 +51,64 @@ const defaultPort = "8080"
 
 // Manager handles the REST API server
 type Manager struct {
-	board  *board.Board
-	cfg    *config.Config
-	server *http.Server
-	router *gin.Engine
+	board      *board.Board
+	cfg        *config.Config
+	wampClient *wamp.Client
+	server     *http.Server
+	router     *gin.Engine
+
+	modules     ModuleLister
+	clock       *clock.Checker
+	serviceLogs ServiceLogLocator
+}
+
+// ModuleInfo describes the state of a Lightning Rod module
+type ModuleInfo struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Healthy  bool   `json:"healthy"`
+	Ready    bool   `json:"ready"`
+	Reason   string `json:"reason,omitempty"`
+	RPCCount int    `json:"rpc_count"`
+
+	// LastError is the module's last failure, until it recovers
+	LastError *lasterror.Error `json:"last_error,omitempty"`
+}
+
+// ModuleLister provides the state of the loaded modules
+type ModuleLister interface {
+	Modules() []ModuleInfo
+}
+
+// ServiceLogLocator finds the tunnel client log of an exposed service
+type ServiceLogLocator interface {
+	ServiceLogPath(name string) (string, bool)
 }
 
 // NewManager creates a new REST manager
-func NewManager(cfg *config.Config, board *board.Board) (*Manager, error) {
+func NewManager(cfg *config.Config, board *board.Board, wampClient *wamp.Client) (*Manager, error) {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
 	m := &Manager{
-		board:  board,
-		cfg:    cfg,
-		router: gin.New(),
+		board:      board,
+		cfg:        cfg,
+		wampClient: wampClient,
+		router:     gin.New(),
+	}
+
+	// Client IPs are taken from forwarding headers of trusted proxies only
+	if err := m.router.SetTrustedProxies(cfg.REST.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid rest.trusted_proxies: %w", err)
 	}
 
 	// Setup middleware
+	m.router.Use(requestIDMiddleware())
 	m.router.Use(gin.Recovery())
 	m.router.Use(m.loggerMiddleware())
+	if cfg.REST.ReadOnly {
+		m.router.Use(readOnlyMiddleware())
+	}
 
 	// Setup routes
 	m.setupRoutes()
@@ -69,6 +116,21 @@ func NewManager(cfg *config.Config, board *board.Board) (*Manager, error) {
 	return m, nil
 }
 
+// SetModuleLister sets the source used by the modules endpoint
+func (m *Manager) SetModuleLister(l ModuleLister) {
+	m.modules = l
+}
+
+// SetServiceLogLocator sets the source used by the service logs endpoint
+func (m *Manager) SetServiceLogLocator(l ServiceLogLocator) {
+	m.serviceLogs = l
+}
+
+// SetClockChecker sets the clock checker reported by the status endpoint
+func (m *Manager) SetClockChecker(c *clock.Checker) {
+	m.clock = c
+}
+
 // Start starts the REST API server
 func (m *Manager) Start(ctx context.Context) error {
 	log.Info("Starting REST API server...")
@@ -76,15 +138,18 @@ func (m *Manager) Start(ctx context.Context) error {
 	port := defaultPort
 	addr := fmt.Sprintf(":%s", port)
 
-	m.server = &http.Server{
-		Addr:    addr,
-		Handler: m.router,
+	m.server = m.newServer(addr)
+
+	// Bind before backgrounding, so a port already in use fails Start
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
 	}
 
-	// Start server in goroutine
+	// Serve in goroutine
 	go func() {
 		log.Infof("REST API server listening on %s", addr)
-		if err := m.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := m.server.Serve(ln); err != nil && err != http.ErrServerClosed {
 			log.Errorf("REST API server error: %v", err)
 		}
 	}()
@@ -92,6 +157,21 @@ func (m *Manager) Start(ctx context.Context) error {
 	return nil
 }
 
+// newServer builds the HTTP server with the configured timeouts, so slow
+// clients cannot hold connections open indefinitely. There are no WebSocket
+// endpoints; the service log follow stream lifts its own write deadline
+func (m *Manager) newServer(addr string) *http.Server {
+	rest := m.cfg.REST
+	return &http.Server{
+		Addr:              addr,
+		Handler:           m.router,
+		ReadHeaderTimeout: time.Duration(rest.ReadTimeout) * time.Second,
+		ReadTimeout:       time.Duration(rest.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(rest.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(rest.IdleTimeout) * time.Second,
+	}
+}
+
 // Stop stops the REST API server
 func (m *Manager) Stop() error {
 	log.Info("Stopping REST API server...")
@@ -110,20 +190,51 @@ func (m *Manager) Stop() error {
 
 // setupRoutes configures all HTTP routes
 func (m *Manager) setupRoutes() {
-	// Static files
-	m.router.StaticFS("/static", http.FS(static))
-
 	// API routes
 	api := m.router.Group("/api")
 	{
+		api.GET("/ping", m.handlePing)
+		api.GET("/version", m.handleVersion)
 		api.GET("/info", m.handleInfo)
 		api.GET("/status", m.handleStatus)
 		api.GET("/board", m.handleBoard)
+		api.GET("/config", m.handleConfig)
+		api.GET("/settings", m.requireAuth(), m.handleSettings)
+		api.GET("/host", m.handleHost)
+		api.GET("/logs", m.requireAuth(), m.handleLogs)
+		api.GET("/services/:name/logs", m.requireAuth(), m.handleServiceLogs)
+		api.GET("/loglevel", m.handleGetLogLevel)
+		api.PUT("/loglevel", m.requireAuth(), m.handleSetLogLevel)
+		api.GET("/modules", m.handleModules)
+		api.GET("/health", m.handleHealth)
+		api.GET("/rpcs", m.handleRPCs)
+		api.GET("/wamp", m.handleWamp)
+		api.POST("/wamp/reconnect", m.requireAuth(), m.handleWampReconnect)
 	}
 
-	// Web UI routes
-	m.router.GET("/", m.handleHome)
-	m.router.GET("/dashboard", m.handleDashboard)
+	// Prometheus metrics
+	m.router.GET("/metrics", m.handleMetrics)
+
+	// Web UI routes and static files
+	if m.cfg.REST.EnableUI {
+		m.router.StaticFS("/static", http.FS(static))
+		m.router.GET("/", m.handleHome)
+		m.router.GET("/dashboard", m.handleDashboard)
+	} else {
+		log.Info("Web UI disabled, serving the API only")
+	}
+}
+
+// readOnlyMiddleware rejects requests that could change state
+func readOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+		default:
+			abortWithError(c, http.StatusForbidden, "REST API is read-only")
+		}
+	}
 }
 
 // loggerMiddleware provides request logging
@@ -135,7 +246,8 @@ func (m *Manager) loggerMiddleware() gin.HandlerFunc {
 		c.Next()
 
 		duration := time.Since(start)
-		log.Debugf("%s %s - %d (%v)",
+		requestLog(c).Debugf("%s %s %s - %d (%v)",
+			c.ClientIP(),
 			c.Request.Method,
 			path,
 			c.Writer.Status(),
@@ -144,25 +256,41 @@ func (m *Manager) loggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// handlePing answers liveness probes; it collects nothing and needs no
+// authentication, so orchestrators can call it as often as they like
+func (m *Manager) handlePing(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"pong": true,
+		"time": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// handleVersion returns the build metadata only, for monitoring probes
+func (m *Manager) handleVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
+
 // handleInfo returns Lightning Rod information
 func (m *Manager) handleInfo(c *gin.Context) {
 	hostname, _ := os.Hostname()
+	info := m.board.Info()
 
 	c.JSON(http.StatusOK, gin.H{
 		"name":    "Lightning-rod",
-		"version": "1.0.0",
+		"version": version.Version,
 		"board": gin.H{
-			"uuid":     m.board.UUID,
-			"name":     m.board.Name,
-			"type":     m.board.Type,
-			"status":   m.board.Status,
+			"uuid":     info.UUID,
+			"name":     info.Name,
+			"type":     info.Type,
+			"status":   info.Status,
 			"hostname": hostname,
 		},
 		"wamp": gin.H{
-			"connected":  true,
-			"session_id": m.board.SessionID,
-			"url":        m.board.GetWampURL(),
-			"realm":      m.board.GetWampRealm(),
+			"connected":   m.wampClient.IsConnected(),
+			"session_id":  fmt.Sprintf("%d", m.wampClient.GetSessionID()),
+			"url":         m.board.GetWampURL(),
+			"realm":       m.board.GetWampRealm(),
+			"diagnostics": m.wampClient.Diagnostics(),
 		},
 	})
 }
@@ -170,40 +298,203 @@ func (m *Manager) handleInfo(c *gin.Context) {
 // handleStatus returns system status
 func (m *Manager) handleStatus(c *gin.Context) {
 	// Get CPU usage
-	cpuPercent, _ := cpu.Percent(time.Second, false)
+	cpuPercent := []float64{}
+	cpuPerCore := []float64{}
+	if usage, err := sysinfo.CPU(c.Request.Context(), sysinfo.SampleInterval); err == nil {
+		cpuPercent = []float64{usage.Percent}
+		cpuPerCore = usage.PerCore
+	}
 
 	// Get memory info
 	vmem, _ := mem.VirtualMemory()
 
-	c.JSON(http.StatusOK, gin.H{
+	status := gin.H{
 		"status": "online",
 		"system": gin.H{
 			"cpu_percent":    cpuPercent,
+			"cpu_per_core":   cpuPerCore,
+			"load_average":   sysinfo.Load(),
 			"memory_percent": vmem.UsedPercent,
 			"memory_total":   vmem.Total,
 			"memory_used":    vmem.Used,
 		},
+		"agent":  sysinfo.Self(),
 		"uptime": time.Now().Unix(),
-	})
+
+		"maintenance": m.wampClient.Maintenance(),
+		"last_reboot": m.board.LastReboot(),
+	}
+	if m.clock != nil {
+		status["clock"] = m.clock.Status()
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// handleHost returns host operating system and platform info
+func (m *Manager) handleHost(c *gin.Context) {
+	info, err := sysinfo.Host()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
 }
 
 // handleBoard returns board configuration
 func (m *Manager) handleBoard(c *gin.Context) {
+	info := m.board.Info()
+
+	// Plugin settings have their own RPCs and may hold data the plugins
+	// would rather not see on an unauthenticated endpoint
+	delete(info.Extra, board.PluginConfigKey)
+
+	c.JSON(http.StatusOK, gin.H{
+		"uuid":       info.UUID,
+		"code":       info.Code,
+		"name":       info.Name,
+		"type":       info.Type,
+		"status":     info.Status,
+		"mobile":     info.Mobile,
+		"agent":      info.Agent,
+		"created_at": info.CreatedAt,
+		"updated_at": info.UpdatedAt,
+		"location":   info.Location,
+		"extra":      info.Extra,
+		"tags":       m.board.Tags(),
+
+		"allowed_next_status": m.board.AllowedNextStatuses(),
+	})
+}
+
+// handleConfig returns the effective configuration, with secrets redacted,
+// and the paths it resolves to
+func (m *Manager) handleConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"paths": gin.H{
+			"config_file":   m.cfg.File(),
+			"home":          m.cfg.LightningRod.Home,
+			"runtime_dir":   m.cfg.RuntimeDir(),
+			"settings_dir":  m.cfg.SettingsDir(),
+			"state_dir":     m.cfg.StateDir(),
+			"settings_file": m.cfg.SettingsFile(),
+			"log_file":      m.cfg.LightningRod.LogFile,
+		},
+		"config": m.cfg.Redacted(),
+	})
+}
+
+// handleLogs returns the last lines of the agent log file
+func (m *Manager) handleLogs(c *gin.Context) {
+	lines, _ := strconv.Atoi(c.DefaultQuery("lines", strconv.Itoa(logfile.DefaultLines)))
+
+	tail, err := logfile.Tail(m.cfg.LightningRod.LogFile, lines, c.Query("level"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, logfile.ErrFileLoggingDisabled):
+			status = http.StatusNotFound
+		case errors.Is(err, logfile.ErrInvalidLevel):
+			status = http.StatusBadRequest
+		}
+		abortWithError(c, status, err.Error())
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"uuid":       m.board.UUID,
-		"code":       m.board.Code,
-		"name":       m.board.Name,
-		"type":       m.board.Type,
-		"status":     m.board.Status,
-		"mobile":     m.board.Mobile,
-		"agent":      m.board.Agent,
-		"created_at": m.board.CreatedAt,
-		"updated_at": m.board.UpdatedAt,
-		"location":   m.board.Location,
-		"extra":      m.board.Extra,
+		"file":  m.cfg.LightningRod.LogFile,
+		"lines": tail,
 	})
 }
 
+// handleModules returns the loaded modules and their state
+func (m *Manager) handleModules(c *gin.Context) {
+	modules := []ModuleInfo{}
+	if m.modules != nil {
+		modules = m.modules.Modules()
+	}
+
+	c.JSON(http.StatusOK, modules)
+}
+
+// handleWamp returns the WAMP connection state
+func (m *Manager) handleWamp(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"connected":    m.wampClient.IsConnected(),
+		"reconnecting": m.wampClient.IsReconnecting(),
+		"session_id":   m.wampClient.GetSessionID(),
+		"agent":        m.board.Info().Agent,
+		"url":          m.board.GetWampURL(),
+		"realm":        m.board.GetWampRealm(),
+		"diagnostics":  m.wampClient.Diagnostics(),
+		"stats":        m.wampClient.Stats(),
+	})
+}
+
+// handleWampReconnect triggers a WAMP reconnect in the background
+func (m *Manager) handleWampReconnect(c *gin.Context) {
+	if !m.wampClient.ReconnectAsync() {
+		abortWithError(c, http.StatusConflict, "reconnect already in progress")
+		return
+	}
+
+	requestLog(c).Info("WAMP reconnect requested via REST API")
+	c.JSON(http.StatusAccepted, gin.H{"message": "reconnect started"})
+}
+
+// handleRPCs returns the registered procedures grouped by module or, with
+// stats=true, the invocation counters of the procedures called so far
+func (m *Manager) handleRPCs(c *gin.Context) {
+	if stats, _ := strconv.ParseBool(c.Query("stats")); stats {
+		c.JSON(http.StatusOK, m.wampClient.RPCStats())
+		return
+	}
+	c.JSON(http.StatusOK, m.wampClient.ListRegisteredByModule())
+}
+
+// handleHealth reports whether the agent is connected and every enabled
+// module is healthy and ready, answering 503 otherwise
+func (m *Manager) handleHealth(c *gin.Context) {
+	modules := []ModuleInfo{}
+	if m.modules != nil {
+		modules = m.modules.Modules()
+	}
+
+	healthy := m.wampClient.IsConnected()
+	for _, mod := range modules {
+		if mod.Enabled && (!mod.Healthy || !mod.Ready) {
+			healthy = false
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	if !healthy {
+		status, code = "degraded", http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status":         status,
+		"wamp_connected": m.wampClient.IsConnected(),
+		"modules":        modules,
+	})
+}
+
+// handleMetrics renders the agent gauges in the Prometheus text format
+func (m *Manager) handleMetrics(c *gin.Context) {
+	self := sysinfo.Self()
+	metrics.SetGauge(metrics.AgentRSSBytes, "Resident set size of the agent", float64(self.RSS))
+	metrics.SetGauge(metrics.AgentHeapAllocBytes, "Heap bytes allocated by the agent", float64(self.HeapAlloc))
+	metrics.SetGauge(metrics.AgentGoroutines, "Goroutines of the agent", float64(self.Goroutines))
+	metrics.SetGauge(metrics.AgentGCPauseSeconds, "Cumulative GC pause time of the agent", self.GCPauseTotal)
+
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := metrics.Write(c.Writer); err != nil {
+		log.Warnf("Failed to write metrics: %v", err)
+	}
+}
+
 // handleHome renders the home page
 func (m *Manager) handleHome(c *gin.Context) {
 	tmpl, err := template.ParseFS(templates, "templates/home.html")
@@ -213,13 +504,14 @@ func (m *Manager) handleHome(c *gin.Context) {
 	}
 
 	hostname, _ := os.Hostname()
+	info := m.board.Info()
 
 	data := gin.H{
 		"Title":    "Lightning-rod Dashboard",
-		"Board":    m.board.Name,
-		"UUID":     m.board.UUID,
-		"Type":     m.board.Type,
-		"Status":   m.board.Status,
+		"Board":    info.Name,
+		"UUID":     info.UUID,
+		"Type":     info.Type,
+		"Status":   info.Status,
 		"Hostname": hostname,
 	}
 
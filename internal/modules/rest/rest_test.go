@@ -0,0 +1,618 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/clock"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/metrics"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/version"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/wamp"
+	"github.com/gin-gonic/gin"
+)
+
+const testAPIKey = "s3cret"
+
+const testSettings = `{
+  "iotronic": {
+    "board": {
+      "uuid": "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11",
+      "code": "TESTCODE",
+      "name": "board-1",
+      "status": "registered",
+      "type": "server"
+    },
+    "wamp": {
+      "main-agent": {"url": "ws://router.test:8181/", "realm": "s4t"}
+    }
+  }
+}`
+
+// newTestManager returns a REST manager for a test board; configure, if
+// not nil, adjusts the configuration first
+func newTestManager(t *testing.T, configure func(*config.Config)) *Manager {
+	t.Helper()
+
+	dir := t.TempDir()
+	settings := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(settings, []byte(testSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = dir
+	cfg.Board.SettingsFile = settings
+	cfg.Board.SettingsReadAttempts = 1
+	if configure != nil {
+		configure(cfg)
+	}
+
+	b, err := board.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to load board: %v", err)
+	}
+	wc := wamp.NewClient(cfg, b)
+	t.Cleanup(wc.Stop)
+
+	m, err := NewManager(cfg, b, wc)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+// serve performs a request against the manager's router and decodes the
+// JSON response body into out, if not nil
+func serve(t *testing.T, m *Manager, req *http.Request, out any) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.router.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q: %v", req.Method, req.URL, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+// newRequest builds a request with an optional JSON body and API key
+func newRequest(method, target, body, apiKey string) *http.Request {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	return req
+}
+
+func TestPing(t *testing.T) {
+	// Neither an API key nor read-only mode gets in the way of the probe
+	m := newTestManager(t, func(cfg *config.Config) {
+		cfg.REST.APIKey = testAPIKey
+		cfg.REST.ReadOnly = true
+	})
+
+	var body struct {
+		Pong bool   `json:"pong"`
+		Time string `json:"time"`
+	}
+	start := time.Now()
+	code := serve(t, m, newRequest(http.MethodGet, "/api/ping", "", ""), &body)
+	elapsed := time.Since(start)
+
+	if code != http.StatusOK || !body.Pong {
+		t.Fatalf("GET /api/ping = %d %+v, want 200 with pong", code, body)
+	}
+	if _, err := time.Parse(time.RFC3339, body.Time); err != nil {
+		t.Errorf("time %q is not RFC 3339: %v", body.Time, err)
+	}
+	if elapsed > 100*time.Millisecond {
+		t.Errorf("GET /api/ping took %v, want a cheap probe", elapsed)
+	}
+}
+
+func TestStatusLastReboot(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var before struct {
+		LastReboot *board.RebootRecord `json:"last_reboot"`
+	}
+	serve(t, m, newRequest(http.MethodGet, "/api/status", "", ""), &before)
+	if before.LastReboot != nil {
+		t.Errorf("last_reboot = %+v before any reboot", before.LastReboot)
+	}
+
+	if err := m.board.RecordReboot(board.RebootConfigChange); err != nil {
+		t.Fatal(err)
+	}
+	var after struct {
+		LastReboot *board.RebootRecord `json:"last_reboot"`
+	}
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/status", "", ""), &after); code != http.StatusOK {
+		t.Fatalf("GET /api/status = %d", code)
+	}
+	if after.LastReboot == nil || after.LastReboot.Reason != board.RebootConfigChange {
+		t.Errorf("last_reboot = %+v, want %s", after.LastReboot, board.RebootConfigChange)
+	}
+}
+
+func TestStatusClock(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var without map[string]any
+	serve(t, m, newRequest(http.MethodGet, "/api/status", "", ""), &without)
+	if _, ok := without["clock"]; ok {
+		t.Errorf("clock = %v without an NTP server", without["clock"])
+	}
+
+	// A failed check is reported too, rather than a stale offset
+	checker := clock.NewChecker("127.0.0.1:1", time.Minute, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	checker.Check(ctx)
+	m.SetClockChecker(checker)
+
+	var with struct {
+		Clock *clock.Status `json:"clock"`
+	}
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/status", "", ""), &with); code != http.StatusOK {
+		t.Fatalf("GET /api/status = %d", code)
+	}
+	if with.Clock == nil || *with.Clock != checker.Status() {
+		t.Errorf("clock = %+v, want %+v", with.Clock, checker.Status())
+	}
+}
+
+func TestRPCsStats(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var stats []wamp.ProcedureStats
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/rpcs?stats=true", "", ""), &stats); code != http.StatusOK {
+		t.Fatalf("GET /api/rpcs?stats=true = %d", code)
+	}
+	if stats == nil || len(stats) != 0 {
+		t.Errorf("stats = %#v, want an empty list before any call", stats)
+	}
+
+	var byModule map[string][]string
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/rpcs", "", ""), &byModule); code != http.StatusOK {
+		t.Fatalf("GET /api/rpcs = %d", code)
+	}
+}
+
+// fakeModules is a ModuleLister returning fixed module states
+type fakeModules []ModuleInfo
+
+func (f fakeModules) Modules() []ModuleInfo { return f }
+
+func TestHealthLastError(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lastErr := &lasterror.Error{Operation: "enable web", Message: "failed to reload nginx: exit status 1", At: at}
+	m.SetModuleLister(fakeModules{
+		{Name: "device", Enabled: true, Healthy: true, Ready: true},
+		{Name: "webservice", Enabled: true, Healthy: true, Ready: true, LastError: lastErr},
+	})
+
+	var body struct {
+		Modules []struct {
+			Name      string           `json:"name"`
+			LastError *lasterror.Error `json:"last_error"`
+		} `json:"modules"`
+	}
+	serve(t, m, newRequest(http.MethodGet, "/api/health", "", ""), &body)
+
+	if len(body.Modules) != 2 {
+		t.Fatalf("modules = %+v, want 2", body.Modules)
+	}
+	if body.Modules[0].LastError != nil {
+		t.Errorf("device last_error = %+v, want none", body.Modules[0].LastError)
+	}
+	if got := body.Modules[1].LastError; got == nil || *got != *lastErr {
+		t.Errorf("webservice last_error = %+v, want %+v", got, lastErr)
+	}
+}
+
+func TestBoardAndInfo(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var board map[string]any
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/board", "", ""), &board); code != http.StatusOK {
+		t.Fatalf("GET /api/board status = %d", code)
+	}
+	if board["uuid"] != "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11" || board["name"] != "board-1" || board["status"] != "registered" {
+		t.Errorf("GET /api/board = %v", board)
+	}
+
+	var info struct {
+		Board map[string]any `json:"board"`
+		WAMP  map[string]any `json:"wamp"`
+	}
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/info", "", ""), &info); code != http.StatusOK {
+		t.Fatalf("GET /api/info status = %d", code)
+	}
+	if info.Board["type"] != "server" || info.Board["name"] != "board-1" {
+		t.Errorf("GET /api/info board = %v", info.Board)
+	}
+	if info.WAMP["session_id"] != "0" {
+		t.Errorf("session_id = %v, want 0 while disconnected", info.WAMP["session_id"])
+	}
+}
+
+func TestBoardHidesPluginConfig(t *testing.T) {
+	m := newTestManager(t, nil)
+	if err := m.board.SetPluginConfig("weather", "token", "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Extra map[string]any `json:"extra"`
+	}
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/board", "", ""), &body); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if _, ok := body.Extra[board.PluginConfigKey]; ok {
+		t.Errorf("extra = %v, want no plugin settings", body.Extra)
+	}
+	if got, _ := m.board.PluginConfig("weather"); got["token"] != "abc" {
+		t.Error("plugin settings removed from the board")
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	m := newTestManager(t, func(cfg *config.Config) {
+		cfg.REST.ReadTimeout = 1
+		cfg.REST.WriteTimeout = 2
+		cfg.REST.IdleTimeout = 3
+	})
+
+	server := m.newServer("127.0.0.1:0")
+	if server.ReadHeaderTimeout != time.Second || server.ReadTimeout != time.Second ||
+		server.WriteTimeout != 2*time.Second || server.IdleTimeout != 3*time.Second {
+		t.Errorf("server timeouts: header %v, read %v, write %v, idle %v",
+			server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+
+	srv := httptest.NewUnstartedServer(m.router)
+	srv.Config = server
+	srv.Start()
+	defer srv.Close()
+
+	// A client that never finishes its request headers is disconnected
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /api/ping HTTP/1.1\r\nHost: board\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	conn.SetReadDeadline(start.Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("connection not closed by the server: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("slow client disconnected after %v, want about the 1s read timeout", elapsed)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "lightning-rod.log")
+	lines := "level=info msg=started\nlevel=error msg=failed\n"
+	if err := os.WriteFile(logFile, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		logFile    string
+		configured string
+		apiKey     string
+		query      string
+		code       int
+		lines      []string
+	}{
+		{"all", logFile, testAPIKey, testAPIKey, "", http.StatusOK, []string{"level=info msg=started", "level=error msg=failed"}},
+		{"level", logFile, testAPIKey, testAPIKey, "?level=error", http.StatusOK, []string{"level=error msg=failed"}},
+		{"invalid level", logFile, testAPIKey, testAPIKey, "?level=loud", http.StatusBadRequest, nil},
+		{"disabled", "", testAPIKey, testAPIKey, "", http.StatusNotFound, nil},
+		{"missing key", logFile, testAPIKey, "", "", http.StatusUnauthorized, nil},
+		{"no api_key", logFile, "", "", "", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, func(cfg *config.Config) {
+				cfg.LightningRod.LogFile = tt.logFile
+				cfg.REST.APIKey = tt.configured
+			})
+
+			var body struct {
+				Lines []string `json:"lines"`
+			}
+			code := serve(t, m, newRequest(http.MethodGet, "/api/logs"+tt.query, "", tt.apiKey), &body)
+			if code != tt.code {
+				t.Fatalf("GET /api/logs%s = %d, want %d", tt.query, code, tt.code)
+			}
+			if !reflect.DeepEqual(body.Lines, tt.lines) {
+				t.Errorf("lines = %q, want %q", body.Lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestModules(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var empty []map[string]any
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/modules", "", ""), &empty); code != http.StatusOK || empty == nil || len(empty) != 0 {
+		t.Errorf("GET /api/modules without a lister = %d %v, want 200 and an empty list", code, empty)
+	}
+
+	m.SetModuleLister(fakeModules{
+		{Name: "device", Enabled: true, Healthy: true, Ready: true, RPCCount: 20},
+		{Name: "service"},
+		{Name: "webservice", Enabled: true, Reason: "nginx not installed", RPCCount: 6},
+	})
+	var got []map[string]any
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/modules", "", ""), &got); code != http.StatusOK {
+		t.Fatalf("GET /api/modules = %d", code)
+	}
+	want := []map[string]any{
+		{"name": "device", "enabled": true, "healthy": true, "ready": true, "rpc_count": 20.0},
+		{"name": "service", "enabled": false, "healthy": false, "ready": false, "rpc_count": 0.0},
+		{"name": "webservice", "enabled": true, "healthy": false, "ready": false, "reason": "nginx not installed", "rpc_count": 6.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GET /api/modules = %v, want %v", got, want)
+	}
+}
+
+func TestWampState(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var state map[string]any
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/wamp", "", ""), &state); code != http.StatusOK {
+		t.Fatalf("GET /api/wamp = %d", code)
+	}
+	want := map[string]any{
+		"connected":    false,
+		"reconnecting": false,
+		"url":          "ws://router.test:8181/",
+		"realm":        "s4t",
+	}
+	for key, value := range want {
+		if state[key] != value {
+			t.Errorf("%s = %v, want %v", key, state[key], value)
+		}
+	}
+	for _, key := range []string{"session_id", "agent", "diagnostics", "stats"} {
+		if _, ok := state[key]; !ok {
+			t.Errorf("state has no %q: %v", key, state)
+		}
+	}
+}
+
+func TestWampReconnect(t *testing.T) {
+	// Reconnecting needs an API key
+	m := newTestManager(t, nil)
+	if code := serve(t, m, newRequest(http.MethodPost, "/api/wamp/reconnect", "", ""), nil); code != http.StatusForbidden {
+		t.Errorf("POST /api/wamp/reconnect without rest.api_key = %d, want %d", code, http.StatusForbidden)
+	}
+
+	// Keep the reconnect waiting so that it is still running on the next request
+	m = newTestManager(t, func(cfg *config.Config) {
+		cfg.Autobahn.ConnectionTimer = 60
+		cfg.REST.APIKey = testAPIKey
+	})
+	if code := serve(t, m, newRequest(http.MethodPost, "/api/wamp/reconnect", "", "wrong"), nil); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/wamp/reconnect with a wrong key = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if m.wampClient.IsReconnecting() {
+		t.Fatal("unauthorized request started a reconnect")
+	}
+
+	if code := serve(t, m, newRequest(http.MethodPost, "/api/wamp/reconnect", "", testAPIKey), nil); code != http.StatusAccepted {
+		t.Fatalf("first POST /api/wamp/reconnect = %d, want %d", code, http.StatusAccepted)
+	}
+	var body map[string]any
+	if code := serve(t, m, newRequest(http.MethodPost, "/api/wamp/reconnect", "", testAPIKey), &body); code != http.StatusConflict {
+		t.Errorf("second POST /api/wamp/reconnect = %d, want %d", code, http.StatusConflict)
+	}
+	if body["error"] == nil {
+		t.Errorf("conflict body = %v, want an error", body)
+	}
+
+	var state map[string]any
+	serve(t, m, newRequest(http.MethodGet, "/api/wamp", "", ""), &state)
+	if state["reconnecting"] != true {
+		t.Errorf("reconnecting = %v during a reconnect", state["reconnecting"])
+	}
+}
+
+func TestVersion(t *testing.T) {
+	origVersion, origCommit, origBuiltAt := version.Version, version.Commit, version.BuiltAt
+	t.Cleanup(func() { version.Version, version.Commit, version.BuiltAt = origVersion, origCommit, origBuiltAt })
+	version.Version, version.Commit, version.BuiltAt = "2.1.0", "abc1234", "2024-05-01T10:00:00Z"
+
+	// Probes need no API key even when one is configured
+	m := newTestManager(t, func(cfg *config.Config) { cfg.REST.APIKey = testAPIKey })
+
+	var info map[string]string
+	if code := serve(t, m, newRequest(http.MethodGet, "/api/version", "", ""), &info); code != http.StatusOK {
+		t.Fatalf("GET /api/version = %d", code)
+	}
+	want := map[string]string{
+		"version":    "2.1.0",
+		"commit":     "abc1234",
+		"built_at":   "2024-05-01T10:00:00Z",
+		"go_version": runtime.Version(),
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("version = %v, want %v", info, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	rec := httptest.NewRecorder()
+	m.router.ServeHTTP(rec, newRequest(http.MethodGet, "/metrics", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, metrics.ContentType)
+	}
+	for _, name := range []string{metrics.SettingsLoadedTimestamp, metrics.AgentGoroutines} {
+		if !strings.Contains(rec.Body.String(), "\n"+name+" ") {
+			t.Errorf("metrics lack %s:\n%s", name, rec.Body.String())
+		}
+	}
+}
+
+func TestEnableUI(t *testing.T) {
+	tests := []struct {
+		enableUI bool
+		target   string
+		want     int
+	}{
+		{true, "/", http.StatusOK},
+		{true, "/dashboard", http.StatusFound},
+		{true, "/static/static/favicon.ico", http.StatusOK},
+		{true, "/api/ping", http.StatusOK},
+		{false, "/", http.StatusNotFound},
+		{false, "/dashboard", http.StatusNotFound},
+		{false, "/static/static/favicon.ico", http.StatusNotFound},
+		{false, "/api/ping", http.StatusOK},
+		{false, "/api/info", http.StatusOK},
+		{false, "/metrics", http.StatusOK},
+	}
+	for _, tt := range tests {
+		m := newTestManager(t, func(cfg *config.Config) { cfg.REST.EnableUI = tt.enableUI })
+		if code := serve(t, m, newRequest(http.MethodGet, tt.target, "", ""), nil); code != tt.want {
+			t.Errorf("GET %s with enable_ui %v = %d, want %d", tt.target, tt.enableUI, code, tt.want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	tests := []struct {
+		readOnly bool
+		method   string
+		target   string
+		body     string
+		want     int
+	}{
+		{true, http.MethodGet, "/api/info", "", http.StatusOK},
+		{true, http.MethodGet, "/api/wamp", "", http.StatusOK},
+		{true, http.MethodPost, "/api/wamp/reconnect", "", http.StatusForbidden},
+		{true, http.MethodPut, "/api/loglevel", `{"level":"debug"}`, http.StatusForbidden},
+		{true, http.MethodDelete, "/api/services/ssh", "", http.StatusForbidden},
+		{false, http.MethodGet, "/api/info", "", http.StatusOK},
+		{false, http.MethodPost, "/api/wamp/reconnect", "", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		m := newTestManager(t, func(cfg *config.Config) {
+			cfg.REST.ReadOnly = tt.readOnly
+			cfg.REST.APIKey = testAPIKey
+			cfg.Autobahn.ConnectionTimer = 60
+		})
+		if code := serve(t, m, newRequest(tt.method, tt.target, tt.body, testAPIKey), nil); code != tt.want {
+			t.Errorf("%s %s with read_only %v = %d, want %d", tt.method, tt.target, tt.readOnly, code, tt.want)
+		}
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		want    string
+	}{
+		{"none trusted", nil, "10.0.0.1:4321", "10.0.0.1"},
+		{"trusted proxy", []string{"10.0.0.1"}, "10.0.0.1:4321", "203.0.113.7"},
+		{"trusted network", []string{"10.0.0.0/8"}, "10.1.2.3:4321", "203.0.113.7"},
+		{"untrusted peer", []string{"10.0.0.1"}, "10.0.0.2:4321", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, func(cfg *config.Config) { cfg.REST.TrustedProxies = tt.trusted })
+			m.router.GET("/test/client-ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			req := newRequest(http.MethodGet, "/test/client-ip", "", "")
+			req.RemoteAddr = tt.remote
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rec := httptest.NewRecorder()
+			m.router.ServeHTTP(rec, req)
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrustedProxiesInvalid(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	cfg.REST.TrustedProxies = []string{"not-an-address"}
+	if _, err := NewManager(cfg, nil, nil); err == nil || !strings.Contains(err.Error(), "rest.trusted_proxies") {
+		t.Errorf("NewManager with an invalid proxy = %v, want an invalid rest.trusted_proxies", err)
+	}
+}
+
+func TestStartPortInUse(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	// Whoever holds the port, Start must not report the API as up
+	ln, err := net.Listen("tcp", ":"+defaultPort)
+	if err == nil {
+		defer ln.Close()
+	}
+	err = m.Start(context.Background())
+	if err == nil {
+		m.Stop()
+		t.Fatalf("Start() succeeded with port %s in use", defaultPort)
+	}
+	if !strings.Contains(err.Error(), "failed to listen on :"+defaultPort) {
+		t.Errorf("Start() = %v, want a listen error", err)
+	}
+}
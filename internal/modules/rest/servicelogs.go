@@ -0,0 +1,170 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/logfile"
+)
+
+// followInterval is how often a followed service log is checked for new
+// lines, replaceable for testing
+var followInterval = 500 * time.Millisecond
+
+// maxFollowRead caps how much of a followed log is read at a time
+const maxFollowRead = 1 << 20
+
+// handleServiceLogs returns the last lines of the tunnel client log of a
+// service or, with follow=true, streams them as server-sent events and
+// keeps sending the lines appended until the client goes away
+func (m *Manager) handleServiceLogs(c *gin.Context) {
+	name := c.Param("name")
+	var path string
+	exists := false
+	if m.serviceLogs != nil {
+		path, exists = m.serviceLogs.ServiceLogPath(name)
+	}
+	if !exists {
+		abortWithError(c, http.StatusNotFound, "service "+name+" not found")
+		return
+	}
+
+	lines, _ := strconv.Atoi(c.DefaultQuery("lines", strconv.Itoa(logfile.DefaultLines)))
+	follow, _ := strconv.ParseBool(c.Query("follow"))
+
+	// Lines appended after this offset are left to the follow loop
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	tail, err := logfile.Tail(path, lines, "")
+	if err != nil {
+		// A service whose client has not written anything yet has no log
+		if !errors.Is(err, fs.ErrNotExist) {
+			abortWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		tail = []string{}
+	}
+
+	if !follow {
+		c.JSON(http.StatusOK, gin.H{
+			"service": name,
+			"file":    path,
+			"lines":   tail,
+		})
+		return
+	}
+
+	m.followServiceLog(c, path, offset, tail)
+}
+
+// followServiceLog sends tail, then every line appended to path past
+// offset, as "line" events until the request is cancelled
+func (m *Manager) followServiceLog(c *gin.Context, path string, offset int64, tail []string) {
+	// The stream outlives rest.write_timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestLog(c).Debugf("Cannot lift the write deadline of a log stream: %v", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, line := range tail {
+		c.SSEvent("line", line)
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	var partial []byte
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, start, err := readFrom(path, offset)
+		if err != nil {
+			continue
+		}
+		if start < offset {
+			// Emptied by the log cleanup; start over
+			partial = nil
+		}
+		offset = start + int64(len(data))
+
+		partial = append(partial, data...)
+		sent := false
+		for {
+			i := bytes.IndexByte(partial, '\n')
+			if i < 0 {
+				break
+			}
+			if line := bytes.TrimRight(partial[:i], "\r"); len(line) > 0 {
+				c.SSEvent("line", string(line))
+				sent = true
+			}
+			partial = partial[i+1:]
+		}
+		if len(partial) > logfile.MaxLineBytes {
+			partial = partial[len(partial)-logfile.MaxLineBytes:]
+		}
+		if sent {
+			c.Writer.Flush()
+		}
+	}
+}
+
+// readFrom returns what follows offset in the file at path, up to
+// maxFollowRead bytes, and the offset it was read from: a file shorter
+// than offset is read from the start
+func readFrom(path string, offset int64) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+
+	data, err := io.ReadAll(io.LimitReader(io.NewSectionReader(f, offset, info.Size()-offset), maxFollowRead))
+	if err != nil {
+		return nil, offset, err
+	}
+	return data, offset, nil
+}
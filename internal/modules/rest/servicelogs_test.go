@@ -0,0 +1,196 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rest
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// fakeServiceLogs maps service names to their log files
+type fakeServiceLogs map[string]string
+
+func (f fakeServiceLogs) ServiceLogPath(name string) (string, bool) {
+	path, ok := f[name]
+	return path, ok
+}
+
+// newServiceLogsManager returns a manager knowing the services "ssh",
+// whose log holds three lines, and "web", which has no log yet
+func newServiceLogsManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	sshLog := filepath.Join(dir, "ssh.log")
+	if err := os.WriteFile(sshLog, []byte("connecting\nconnected\ntunnel up\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestManager(t, func(cfg *config.Config) { cfg.REST.APIKey = testAPIKey })
+	m.SetServiceLogLocator(fakeServiceLogs{
+		"ssh": sshLog,
+		"web": filepath.Join(dir, "web.log"),
+	})
+	return m, sshLog
+}
+
+func TestServiceLogs(t *testing.T) {
+	m, _ := newServiceLogsManager(t)
+
+	tests := []struct {
+		name   string
+		target string
+		apiKey string
+		status int
+		lines  []string
+	}{
+		{"tail", "/api/services/ssh/logs?lines=2", testAPIKey, http.StatusOK, []string{"connected", "tunnel up"}},
+		{"default lines", "/api/services/ssh/logs", testAPIKey, http.StatusOK, []string{"connecting", "connected", "tunnel up"}},
+		{"no log yet", "/api/services/web/logs", testAPIKey, http.StatusOK, []string{}},
+		{"unknown service", "/api/services/ftp/logs", testAPIKey, http.StatusNotFound, nil},
+		{"no API key", "/api/services/ssh/logs", "", http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Service string   `json:"service"`
+				Lines   []string `json:"lines"`
+			}
+			code := serve(t, m, newRequest(http.MethodGet, tt.target, "", tt.apiKey), &body)
+			if code != tt.status {
+				t.Fatalf("status = %d, want %d", code, tt.status)
+			}
+			if tt.lines != nil && !reflect.DeepEqual(body.Lines, tt.lines) {
+				t.Errorf("lines = %q, want %q", body.Lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestServiceLogsFollow(t *testing.T) {
+	orig := followInterval
+	followInterval = 10 * time.Millisecond
+	t.Cleanup(func() { followInterval = orig })
+
+	m, sshLog := newServiceLogsManager(t)
+	srv := httptest.NewServer(m.router)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/services/ssh/logs?lines=1&follow=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", testAPIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Content-Type = %q, want an event stream", ct)
+	}
+
+	events := bufio.NewScanner(resp.Body)
+	next := func() string {
+		t.Helper()
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data:"); ok {
+				return data
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return ""
+	}
+
+	if got := next(); got != "tunnel up" {
+		t.Errorf("first event = %q, want the tail", got)
+	}
+
+	f, err := os.OpenFile(sshLog, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("reconnecting\n")
+	f.Close()
+
+	if got := next(); got != "reconnecting" {
+		t.Errorf("followed event = %q, want the appended line", got)
+	}
+}
+
+func TestServiceLogsFollowOutlivesWriteTimeout(t *testing.T) {
+	orig := followInterval
+	followInterval = 10 * time.Millisecond
+	t.Cleanup(func() { followInterval = orig })
+
+	m, sshLog := newServiceLogsManager(t)
+	m.cfg.REST.WriteTimeout = 1
+	srv := httptest.NewUnstartedServer(m.router)
+	srv.Config = m.newServer("127.0.0.1:0")
+	srv.Start()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/services/ssh/logs?lines=1&follow=true", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", testAPIKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	events := bufio.NewScanner(resp.Body)
+	next := func() string {
+		t.Helper()
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data:"); ok {
+				return data
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return ""
+	}
+	next()
+
+	// Past the write timeout the stream still delivers new lines
+	time.Sleep(1500 * time.Millisecond)
+	f, err := os.OpenFile(sshLog, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("still here\n")
+	f.Close()
+
+	if got := next(); got != "still here" {
+		t.Errorf("event after the write timeout = %q", got)
+	}
+}
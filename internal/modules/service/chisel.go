@@ -0,0 +1,88 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// chiselBackend tunnels services through a chisel server started with
+// --reverse. Chisel routes by port, not by name: each service is published
+// on the server port equal to its local port, so boards sharing a server
+// must expose distinct ports.
+type chiselBackend struct {
+	bin    string
+	server string
+	host   string
+}
+
+// newChiselBackend derives the chisel server from the tunnel endpoint,
+// which chisel reaches over HTTP(S) rather than WS(S)
+func newChiselBackend(bin, endpoint string) (*chiselBackend, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tunnel endpoint %q: %w", endpoint, err)
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	return &chiselBackend{bin: bin, server: u.String(), host: u.Hostname()}, nil
+}
+
+func (b *chiselBackend) Name() string      { return BackendChisel }
+func (b *chiselBackend) Bin() string       { return b.bin }
+func (b *chiselBackend) ServerURL() string { return b.server }
+
+// remote returns the reverse remote of svc: R:<server port>:<target>
+func (b *chiselBackend) remote(svc *ServiceInfo) string {
+	return "R:" + strconv.Itoa(svc.LocalPort) + ":" + svc.target()
+}
+
+func (b *chiselBackend) Args(svc *ServiceInfo) []string {
+	return []string{"client", b.server, b.remote(svc)}
+}
+
+func (b *chiselBackend) PublicURL(svc *ServiceInfo) string {
+	return "tcp://" + net.JoinHostPort(b.host, strconv.Itoa(svc.LocalPort))
+}
+
+func (b *chiselBackend) Match(args []string) (string, bool) {
+	rest, ok := clientArgs(args, b.bin)
+	if !ok || len(rest) != 2 || rest[0] != b.server {
+		return "", false
+	}
+
+	// R:<port>:<host>:<port>, with the host possibly a bracketed IPv6
+	port, target, ok := strings.Cut(strings.TrimPrefix(rest[1], "R:"), ":")
+	if !ok || !strings.HasPrefix(rest[1], "R:") {
+		return "", false
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", false
+	}
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		return "", false
+	}
+
+	return target, true
+}
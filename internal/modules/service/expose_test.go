@@ -0,0 +1,482 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/state"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// newExposeTestManager returns a manager whose wstun is a script that
+// sleeps until killed
+func newExposeTestManager(t *testing.T) *Manager {
+	t.Helper()
+	wstun := filepath.Join(t.TempDir(), "wstun")
+	if err := os.WriteFile(wstun, []byte("#!/bin/sh\nexec sleep 60\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	cfg.Services.WstunBin = wstun
+	cfg.Services.StopGracePeriod = 1
+	m := &Manager{
+		cfg:      cfg,
+		services: make(map[string]*ServiceInfo),
+		pending:  make(map[string]string),
+		store:    state.NewMemoryStore(),
+		boardID:  "b1",
+		backend:  &wstunBackend{bin: wstun, server: "ws://router.test:8080", publicBase: "ws://router.test:8080"},
+	}
+	t.Cleanup(func() {
+		m.mu.RLock()
+		names := make([]string, 0, len(m.services))
+		for name := range m.services {
+			names = append(names, name)
+		}
+		m.mu.RUnlock()
+		for _, name := range names {
+			m.unexposeService(name)
+		}
+	})
+	return m
+}
+
+// exposeConcurrently runs expose n times at once and returns how many
+// calls succeeded
+func exposeConcurrently(n int, expose func(i int) error) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	start := make(chan struct{})
+	ok := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			if expose(i) == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return ok
+}
+
+func TestConcurrentExposeSameName(t *testing.T) {
+	m := newExposeTestManager(t)
+
+	ok := exposeConcurrently(16, func(int) error {
+		return m.exposeService("ssh", 22, defaultTargetHost, "", nil)
+	})
+	if ok != 1 {
+		t.Errorf("%d exposes succeeded, want 1", ok)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.services) != 1 || m.services["ssh"] == nil || m.services["ssh"].PID <= 0 {
+		t.Errorf("services = %v", m.services)
+	}
+	if len(m.pending) != 0 {
+		t.Errorf("reservations left behind: %v", m.pending)
+	}
+}
+
+func TestConcurrentExposeSameTunnelID(t *testing.T) {
+	m := newExposeTestManager(t)
+
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	ok := exposeConcurrently(len(names), func(i int) error {
+		return m.exposeService(names[i], 8000+i, defaultTargetHost, "shared", nil)
+	})
+	if ok != 1 {
+		t.Errorf("%d exposes succeeded, want 1", ok)
+	}
+}
+
+func TestExposeFailureReleasesName(t *testing.T) {
+	m := newExposeTestManager(t)
+	backend := m.backend.(*wstunBackend)
+	wstun := backend.bin
+	backend.bin = filepath.Join(t.TempDir(), "missing")
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err == nil {
+		t.Fatal("expose with a missing wstun succeeded")
+	}
+	m.mu.RLock()
+	if len(m.pending) != 0 || len(m.services) != 0 {
+		t.Errorf("pending = %v, services = %v", m.pending, m.services)
+	}
+	m.mu.RUnlock()
+
+	// The name can be exposed once the failure is fixed
+	backend.bin = wstun
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Errorf("expose after failure: %v", err)
+	}
+}
+
+// gatedTunnel holds Start until gate is closed, signalling entered first
+type gatedTunnel struct {
+	Tunnel
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (g *gatedTunnel) Start(env map[string]string) error {
+	close(g.entered)
+	<-g.gate
+	return g.Tunnel.Start(env)
+}
+
+func TestStopWaitsForExpose(t *testing.T) {
+	m := newExposeTestManager(t)
+	gated := &gatedTunnel{entered: make(chan struct{}), gate: make(chan struct{})}
+	m.tunnelFactory = func(svc *ServiceInfo) Tunnel {
+		m.tunnelFactory = nil
+		gated.Tunnel = m.newTunnel(svc)
+		return gated
+	}
+
+	exposed := make(chan error, 1)
+	go func() { exposed <- m.exposeService("ssh", 22, defaultTargetHost, "", nil) }()
+	<-gated.entered
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- m.Stop() }()
+	select {
+	case err := <-stopped:
+		t.Fatalf("Stop() = %v returned with an expose in progress", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// New operations are refused while Stop waits
+	if err := m.exposeService("web", 80, defaultTargetHost, "", nil); !errors.Is(err, ErrStopping) {
+		t.Errorf("expose during Stop = %v, want ErrStopping", err)
+	}
+
+	close(gated.gate)
+	if err := <-exposed; err != nil {
+		t.Fatalf("expose: %v", err)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+
+	pid := gated.PID()
+	if pid <= 0 || processAlive(pid) {
+		t.Errorf("wstun %d of the late expose still running after Stop", pid)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.services) != 0 || len(m.pending) != 0 {
+		t.Errorf("services = %v, pending = %v after Stop", m.services, m.pending)
+	}
+}
+
+// waitForWstun waits for the shim to exec the wstun script, which execs
+// sleep
+func waitForWstun(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		args, _ := readCmdline(pid)
+		if len(args) > 0 && filepath.Base(args[0]) == "sleep" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("wstun %d cmdline = %v", pid, args)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExposeAppliesLimits(t *testing.T) {
+	m := newExposeTestManager(t)
+	m.cfg.Services.Nice = 5
+	m.cfg.Services.RlimitNofile = 128
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose: %v", err)
+	}
+	pid := m.services["ssh"].PID
+	waitForWstun(t, pid)
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+2:]))
+	if fields[16] != "5" {
+		t.Errorf("wstun nice = %s, want 5", fields[16])
+	}
+	limits, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`Max open files\s+128\s+128`).Match(limits) {
+		t.Errorf("wstun limits:\n%s", limits)
+	}
+}
+
+func TestServicesListKeepsKey(t *testing.T) {
+	m := newExposeTestManager(t)
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose: %v", err)
+	}
+
+	reply := m.handleServicesList(context.Background(), &nexuswamp.Invocation{}).Args[0].(map[string]any)
+	data, _ := reply["data"].(map[string]any)
+	for _, list := range []any{reply["services"], data["services"]} {
+		if services, _ := list.([]map[string]any); len(services) != 1 || services[0]["name"] != "ssh" {
+			t.Errorf("services = %v in %v", list, reply)
+		}
+	}
+}
+
+func TestValidateTargetHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"127.0.0.1", false},
+		{"192.168.1.10", false},
+		{"::1", false},
+		{"camera.local", false},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"255.255.255.255", true},
+		{"bad host!", true},
+		{"-leading.dash", true},
+	}
+	for _, tt := range tests {
+		if err := validateTargetHost(tt.host); (err != nil) != tt.wantErr {
+			t.Errorf("validateTargetHost(%q) = %v, want error %v", tt.host, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExposeTargetHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       nexuswamp.List
+		kwargs     nexuswamp.Dict
+		wantTarget string
+	}{
+		{"default", nexuswamp.List{"ssh", 22.0}, nil, "127.0.0.1:22"},
+		{"argument", nexuswamp.List{"ssh", 22.0, "192.168.1.10"}, nil, "192.168.1.10:22"},
+		{"kwarg", nexuswamp.List{"ssh", 22.0}, nexuswamp.Dict{"target_host": "fd00::5"}, "[fd00::5]:22"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newExposeTestManager(t)
+			res := m.handleExposeService(context.Background(), &nexuswamp.Invocation{Arguments: tt.args, ArgumentsKw: tt.kwargs})
+			if reply := res.Args[0].(map[string]any); reply["result"] != "SUCCESS" {
+				t.Fatalf("expose = %v", reply)
+			}
+			args := tunnelArgs(t, m, "ssh")
+			i := slices.Index(args, "-t")
+			if i < 0 || i+1 >= len(args) || args[i+1] != tt.wantTarget {
+				t.Errorf("wstun args = %v, want -t %s", args, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestExposeRejectsInvalidTargetHost(t *testing.T) {
+	m := newExposeTestManager(t)
+	res := m.handleExposeService(context.Background(), &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"ssh", 22.0},
+		ArgumentsKw: nexuswamp.Dict{"target_host": "0.0.0.0"},
+	})
+	if reply := res.Args[0].(map[string]any); reply["result"] != "ERROR" {
+		t.Errorf("expose = %v, want an error", reply)
+	}
+	if _, ok := m.services["ssh"]; ok {
+		t.Error("service registered despite an invalid target host")
+	}
+}
+
+func TestExposeRejectsInvalidLocalPort(t *testing.T) {
+	for _, port := range []float64{0, -22, 65536, 22.5} {
+		m := newExposeTestManager(t)
+		res := m.handleExposeService(context.Background(), &nexuswamp.Invocation{
+			Arguments: nexuswamp.List{"ssh", port},
+		})
+		reply := res.Args[0].(map[string]any)
+		if msg, _ := reply["message"].(string); reply["result"] != "ERROR" || !strings.Contains(msg, "invalid local_port") {
+			t.Errorf("expose on port %v = %v, want an invalid local_port error", port, reply)
+		}
+		if len(m.services) != 0 || len(m.pending) != 0 {
+			t.Errorf("expose on port %v reserved the service", port)
+		}
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    map[string]string
+		wantErr bool
+	}{
+		{"absent", nil, nil, false},
+		{"variables", map[string]any{"HTTPS_PROXY": "http://proxy:3128", "_TOKEN2": "x"}, map[string]string{"HTTPS_PROXY": "http://proxy:3128", "_TOKEN2": "x"}, false},
+		{"not a map", []any{"TOKEN=x"}, nil, true},
+		{"invalid name", map[string]any{"BAD-NAME": "x"}, nil, true},
+		{"leading digit", map[string]any{"1TOKEN": "x"}, nil, true},
+		{"non-string value", map[string]any{"PORT": 8080.0}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnv(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("env = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	got := mergeEnv([]string{"PATH=/bin", "TOKEN=parent", "HOME=/root"}, map[string]string{"TOKEN": "child", "PROXY": "p"})
+	want := []string{"PATH=/bin", "HOME=/root", "PROXY=p", "TOKEN=child"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnv = %v, want %v", got, want)
+	}
+}
+
+func TestExposeEnv(t *testing.T) {
+	m := newExposeTestManager(t)
+	t.Setenv("LR_PARENT_VAR", "inherited")
+
+	var logs bytes.Buffer
+	out := log.StandardLogger().Out
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(out) })
+
+	res := m.handleExposeService(context.Background(), &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"ssh", 22.0},
+		ArgumentsKw: nexuswamp.Dict{"env": map[string]any{"WSTUN_TOKEN": "s3cret-token"}},
+	})
+	if reply := res.Args[0].(map[string]any); reply["result"] != "SUCCESS" {
+		t.Fatalf("expose = %v", reply)
+	}
+	pid := m.services["ssh"].PID
+	waitForWstun(t, pid)
+
+	environ, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := strings.Split(string(environ), "\x00")
+	for _, kv := range []string{"WSTUN_TOKEN=s3cret-token", "LR_PARENT_VAR=inherited"} {
+		if !slices.Contains(vars, kv) {
+			t.Errorf("wstun environment lacks %s", kv)
+		}
+	}
+	if strings.Contains(logs.String(), "s3cret-token") {
+		t.Errorf("env value leaked to the logs:\n%s", logs.String())
+	}
+
+	res = m.handleExposeService(context.Background(), &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"web", 80.0},
+		ArgumentsKw: nexuswamp.Dict{"env": map[string]any{"BAD=NAME": "x"}},
+	})
+	if reply := res.Args[0].(map[string]any); reply["result"] != "ERROR" {
+		t.Errorf("expose with an invalid env name = %v, want an error", reply)
+	}
+}
+
+func TestExposeExtraArgs(t *testing.T) {
+	m := newExposeTestManager(t)
+	m.backend.(*wstunBackend).extraArgs = []string{"--reconnect", "--ping-interval=30"}
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose: %v", err)
+	}
+	args := tunnelArgs(t, m, "ssh")
+	if len(args) < 2 || !slices.Equal(args[len(args)-2:], []string{"--reconnect", "--ping-interval=30"}) {
+		t.Errorf("wstun args = %v, want the extra args last", args)
+	}
+}
+
+func TestExposeTunnelLimit(t *testing.T) {
+	m := newExposeTestManager(t)
+	m.cfg.Services.MaxTunnels = 2
+
+	for _, name := range []string{"ssh", "web"} {
+		if err := m.exposeService(name, 22, defaultTargetHost, "", nil); err != nil {
+			t.Fatalf("expose %s: %v", name, err)
+		}
+	}
+
+	if err := m.exposeService("mqtt", 1883, defaultTargetHost, "", nil); !errors.Is(err, ErrTunnelLimit) {
+		t.Errorf("expose over the limit = %v, want ErrTunnelLimit", err)
+	}
+	res := m.handleExposeService(context.Background(), &nexuswamp.Invocation{Arguments: nexuswamp.List{"mqtt", 1883.0}})
+	if reply := res.Args[0].(map[string]any); reply["code"] != "LIMIT_REACHED" {
+		t.Errorf("ExposeService over the limit = %v, want LIMIT_REACHED", reply)
+	}
+
+	// Unexposing frees a slot
+	if err := m.unexposeService("ssh"); err != nil {
+		t.Fatalf("unexpose ssh: %v", err)
+	}
+	if err := m.exposeService("mqtt", 1883, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose after unexpose: %v", err)
+	}
+
+	// Only running tunnels count
+	m.mu.Lock()
+	m.services["web"].Status = "dead"
+	m.mu.Unlock()
+	if err := m.exposeService("http", 80, defaultTargetHost, "", nil); err != nil {
+		t.Errorf("expose beside a dead tunnel: %v", err)
+	}
+}
+
+func TestExposeUnlimitedTunnels(t *testing.T) {
+	m := newExposeTestManager(t)
+	for i := 0; i < 5; i++ {
+		if err := m.exposeService(fmt.Sprintf("svc%d", i), 8000+i, defaultTargetHost, "", nil); err != nil {
+			t.Fatalf("expose svc%d with max_tunnels 0: %v", i, err)
+		}
+	}
+}
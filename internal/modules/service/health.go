@@ -0,0 +1,155 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// Health probe types
+const (
+	ProbeTCP  = "tcp"
+	ProbeHTTP = "http"
+)
+
+// HealthResult is the outcome of probing a tunneled service
+type HealthResult struct {
+	Healthy   bool    `json:"healthy"`
+	Probe     string  `json:"probe"`
+	Target    string  `json:"target"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// probeClient issues HTTP probes; redirects are reported as the response
+// instead of followed, so a probe never leaves the probed service
+var probeClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// validateProbePath checks that path is an absolute path on the probed
+// service, so it cannot name another host
+func validateProbePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid probe path %q: must start with /", path)
+	}
+	return nil
+}
+
+// probeService checks that the service listening on addr responds, either
+// by opening a TCP connection or by issuing an HTTP GET for path
+func probeService(ctx context.Context, probe, addr, path string, timeout time.Duration) HealthResult {
+	res := HealthResult{Probe: probe, Target: addr}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch probe {
+	case ProbeTCP:
+		var conn net.Conn
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+	case ProbeHTTP:
+		err = validateProbePath(path)
+		var req *http.Request
+		if err == nil {
+			u := url.URL{Scheme: "http", Host: addr, Path: path}
+			req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		}
+		if err == nil {
+			var resp *http.Response
+			resp, err = probeClient.Do(req)
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode >= http.StatusInternalServerError {
+					err = fmt.Errorf("HTTP status %d", resp.StatusCode)
+				}
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown probe type %q", probe)
+	}
+
+	res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Healthy = true
+	return res
+}
+
+// handleServiceHealth handles the ServiceHealth RPC
+func (m *Manager) handleServiceHealth(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ServiceHealth called")
+
+	if len(inv.Arguments) < 1 {
+		return rpc.Error("Missing argument: service_name required")
+	}
+
+	serviceName, ok := inv.Arguments[0].(string)
+	if !ok {
+		return rpc.Error("Invalid service_name type")
+	}
+
+	m.mu.RLock()
+	svc, exists := m.services[serviceName]
+	var target string
+	if exists {
+		target = svc.target()
+	}
+	m.mu.RUnlock()
+
+	if !exists {
+		return rpc.Error(fmt.Sprintf("service %s not found", serviceName))
+	}
+
+	probe := m.cfg.Services.HealthProbe
+	if p, ok := inv.ArgumentsKw["probe"].(string); ok && p != "" {
+		probe = p
+	}
+	path := "/"
+	if p, ok := inv.ArgumentsKw["path"].(string); ok && p != "" {
+		path = p
+	}
+	if err := validateProbePath(path); err != nil {
+		return rpc.Error(err.Error())
+	}
+
+	timeout := time.Duration(m.cfg.Services.HealthTimeout) * time.Second
+	health := probeService(ctx, probe, target, path, timeout)
+
+	return rpc.SuccessObject(fmt.Sprintf("Service %s probed", serviceName), health)
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// silentListener accepts connections and never answers them
+func silentListener(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make(chan net.Conn, 16)
+	go func() {
+		defer close(conns)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		for conn := range conns {
+			conn.Close()
+		}
+	})
+	return ln.Addr().String()
+}
+
+// closedPort returns an address nothing listens on
+func closedPort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestProbeService(t *testing.T) {
+	// The redirect target would fail the probe if it were followed
+	var redirected atomic.Bool
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/moved":
+			http.Redirect(w, r, "/broken", http.StatusFound)
+		case "/":
+		default:
+			redirected.Store(true)
+		}
+	}))
+	defer ok.Close()
+	okAddr := strings.TrimPrefix(ok.URL, "http://")
+	silent := silentListener(t)
+	closed := closedPort(t)
+
+	tests := []struct {
+		name    string
+		probe   string
+		addr    string
+		path    string
+		healthy bool
+	}{
+		{"tcp listening", ProbeTCP, silent, "", true},
+		{"tcp closed", ProbeTCP, closed, "", false},
+		{"http responding", ProbeHTTP, okAddr, "/", true},
+		{"http server error", ProbeHTTP, okAddr, "/broken", false},
+		{"http hanging", ProbeHTTP, silent, "/", false},
+		{"http closed", ProbeHTTP, closed, "/", false},
+		{"http redirect not followed", ProbeHTTP, okAddr, "/moved", true},
+		{"http other host", ProbeHTTP, okAddr, "@" + closed + "/", false},
+		{"http relative path", ProbeHTTP, okAddr, "health", false},
+		{"unknown probe", "icmp", okAddr, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			res := probeService(context.Background(), tt.probe, tt.addr, tt.path, 300*time.Millisecond)
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("probe took %v despite the 300ms timeout", elapsed)
+			}
+			if res.Healthy != tt.healthy || res.Probe != tt.probe || res.Target != tt.addr {
+				t.Errorf("probe = %+v, want healthy %v", res, tt.healthy)
+			}
+			if !tt.healthy && res.Error == "" {
+				t.Error("unhealthy result without an error")
+			}
+			if res.LatencyMs < 0 {
+				t.Errorf("latency = %v", res.LatencyMs)
+			}
+		})
+	}
+	if redirected.Load() {
+		t.Error("probe requested a path other than the one given")
+	}
+}
+
+func TestServiceHealthRPC(t *testing.T) {
+	_, port, _ := net.SplitHostPort(silentListener(t))
+	localPort, _ := strconv.Atoi(port)
+	cfg := &config.Config{}
+	cfg.Services.HealthProbe = ProbeTCP
+	cfg.Services.HealthTimeout = 1
+	m := &Manager{cfg: cfg, services: map[string]*ServiceInfo{
+		"web": {Name: "web", LocalPort: localPort},
+	}}
+
+	res := m.handleServiceHealth(context.Background(), &nexuswamp.Invocation{Arguments: nexuswamp.List{"web"}})
+	reply, _ := res.Args[0].(map[string]any)
+	data, _ := reply["data"].(map[string]any)
+	if data["healthy"] != true || data["probe"] != ProbeTCP || data["target"] != "127.0.0.1:"+port {
+		t.Errorf("ServiceHealth web = %v, want a healthy tcp probe", reply)
+	}
+
+	// A probe type given by the caller wins over the configured one
+	res = m.handleServiceHealth(context.Background(), &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"web"},
+		ArgumentsKw: nexuswamp.Dict{"probe": ProbeHTTP},
+	})
+	reply, _ = res.Args[0].(map[string]any)
+	data, _ = reply["data"].(map[string]any)
+	if data["healthy"] != false || data["probe"] != ProbeHTTP {
+		t.Errorf("ServiceHealth web over http = %v, want an unhealthy http probe", reply)
+	}
+
+	// A path naming another host is refused before probing
+	res = m.handleServiceHealth(context.Background(), &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"web"},
+		ArgumentsKw: nexuswamp.Dict{"probe": ProbeHTTP, "path": "@evil.host/"},
+	})
+	reply, _ = res.Args[0].(map[string]any)
+	if reply["result"] != "ERROR" || !strings.Contains(reply["message"].(string), "must start with /") {
+		t.Errorf("ServiceHealth with path @evil.host/ = %v, want an invalid path error", reply)
+	}
+
+	res = m.handleServiceHealth(context.Background(), &nexuswamp.Invocation{Arguments: nexuswamp.List{"ssh"}})
+	if reply, _ := res.Args[0].(map[string]any); reply["result"] != "ERROR" {
+		t.Errorf("ServiceHealth of an unknown service = %v, want an error", reply)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// Aggregate tunnel health reported by ServiceManagerInfo
+const (
+	HealthIdle     = "idle"
+	HealthOK       = "healthy"
+	HealthDegraded = "degraded"
+)
+
+// managerInfo summarizes the tunnel manager: the tunnel client in use and
+// the health of the running tunnels, each probed with the configured probe
+func (m *Manager) managerInfo(ctx context.Context) map[string]any {
+	type tunnel struct {
+		target string
+		alive  bool
+	}
+
+	m.mu.RLock()
+	version := m.clientVersion
+	var tunnels []tunnel
+	for _, svc := range m.services {
+		if svc.Status == "running" {
+			tunnels = append(tunnels, tunnel{target: svc.target(), alive: liveStatus(svc) == "running"})
+		}
+	}
+	m.mu.RUnlock()
+
+	timeout := time.Duration(m.cfg.Services.HealthTimeout) * time.Second
+	results := make([]HealthResult, len(tunnels))
+	var wg sync.WaitGroup
+	for i, t := range tunnels {
+		if !t.alive {
+			results[i] = HealthResult{Probe: m.cfg.Services.HealthProbe, Target: t.target, Error: "tunnel process not running"}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			results[i] = probeService(ctx, m.cfg.Services.HealthProbe, target, "/", timeout)
+		}(i, t.target)
+	}
+	wg.Wait()
+
+	healthy := 0
+	var latency float64
+	for _, r := range results {
+		if r.Healthy {
+			healthy++
+			latency += r.LatencyMs
+		}
+	}
+
+	health := HealthIdle
+	switch {
+	case len(results) == 0:
+	case healthy == len(results):
+		health = HealthOK
+	default:
+		health = HealthDegraded
+	}
+
+	info := map[string]any{
+		"type":            m.tunnelBackend().Name(),
+		"bin":             m.tunnelBackend().Bin(),
+		"version":         version,
+		"server_url":      m.tunnelBackend().ServerURL(),
+		"active_tunnels":  len(results),
+		"healthy_tunnels": healthy,
+		"status":          health,
+	}
+	if healthy > 0 {
+		info["avg_latency_ms"] = latency / float64(healthy)
+	}
+
+	return info
+}
+
+// handleServiceManagerInfo handles the ServiceManagerInfo RPC
+func (m *Manager) handleServiceManagerInfo(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC ServiceManagerInfo called")
+
+	return rpc.Success("Service manager info retrieved", m.managerInfo(ctx))
+}
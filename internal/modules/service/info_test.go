@@ -0,0 +1,77 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestManagerInfo(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	cfg := &config.Config{}
+	cfg.Services.WstunBin = "/usr/bin/wstun"
+	cfg.Services.HealthProbe = ProbeTCP
+	cfg.Services.HealthTimeout = 1
+	m := &Manager{
+		cfg:           cfg,
+		backend:       &wstunBackend{bin: "/usr/bin/wstun", server: "ws://router.test:8080"},
+		clientVersion: "1.2.3",
+		services:      map[string]*ServiceInfo{},
+	}
+
+	info := m.managerInfo(context.Background())
+	if info["status"] != HealthIdle || info["active_tunnels"] != 0 {
+		t.Errorf("idle info = %v", info)
+	}
+	for key, want := range map[string]any{
+		"type":       "wstun",
+		"bin":        "/usr/bin/wstun",
+		"version":    "1.2.3",
+		"server_url": "ws://router.test:8080",
+	} {
+		if info[key] != want {
+			t.Errorf("%s = %v, want %v", key, info[key], want)
+		}
+	}
+
+	m.services["web"] = &ServiceInfo{Name: "web", LocalPort: port, Status: "running", PID: os.Getpid()}
+	info = m.managerInfo(context.Background())
+	if info["status"] != HealthOK || info["active_tunnels"] != 1 || info["healthy_tunnels"] != 1 {
+		t.Errorf("healthy info = %v", info)
+	}
+	if _, ok := info["avg_latency_ms"].(float64); !ok {
+		t.Errorf("avg_latency_ms missing: %v", info)
+	}
+
+	// A tunnel whose process is gone degrades the aggregate
+	m.services["dead"] = &ServiceInfo{Name: "dead", LocalPort: port, Status: "running"}
+	m.services["stopped"] = &ServiceInfo{Name: "stopped", LocalPort: port, Status: "stopped"}
+	info = m.managerInfo(context.Background())
+	if info["status"] != HealthDegraded || info["active_tunnels"] != 2 || info["healthy_tunnels"] != 1 {
+		t.Errorf("degraded info = %v", info)
+	}
+}
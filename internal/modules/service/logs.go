@@ -0,0 +1,151 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const serviceLogSuffix = ".log"
+
+// serviceLogPath returns the file the tunnel client of service name
+// writes its output to; the name is escaped so it stays in the directory
+func (m *Manager) serviceLogPath(name string) string {
+	return filepath.Join(m.cfg.ServiceLogDir(), url.PathEscape(name)+serviceLogSuffix)
+}
+
+// ServiceLogPath returns the tunnel client log of service name, or false
+// if no such service is exposed or being exposed
+func (m *Manager) ServiceLogPath(name string) (string, bool) {
+	m.mu.RLock()
+	_, exists := m.services[name]
+	if !exists {
+		_, exists = m.pending[name]
+	}
+	m.mu.RUnlock()
+
+	if !exists {
+		return "", false
+	}
+	return m.serviceLogPath(name), true
+}
+
+// openServiceLog opens the log of service name for appending, creating
+// the log directory if needed
+func (m *Manager) openServiceLog(name string) (*os.File, error) {
+	if err := os.MkdirAll(m.cfg.ServiceLogDir(), 0750); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(m.serviceLogPath(name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+}
+
+// cleanupServiceLogs removes the logs of services no longer present, then
+// empties the oldest logs until the rest fits in services.log_budget. The
+// logs of present services are emptied rather than removed, as their
+// clients keep them open.
+func (m *Manager) cleanupServiceLogs() {
+	dir := m.cfg.ServiceLogDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to list service logs in %s: %v", dir, err)
+		}
+		return
+	}
+
+	type serviceLog struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var kept []serviceLog
+	var total int64
+
+	m.mu.RLock()
+	for _, e := range entries {
+		stem, ok := strings.CutSuffix(e.Name(), serviceLogSuffix)
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+
+		name, err := url.PathUnescape(stem)
+		_, exists := m.services[name]
+		_, pending := m.pending[name]
+		if err != nil || (!exists && !pending) {
+			if err := os.Remove(path); err != nil {
+				log.Warnf("Failed to remove log %s: %v", path, err)
+			} else {
+				log.Infof("Removed log %s of a service no longer exposed", path)
+			}
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		kept = append(kept, serviceLog{path, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	m.mu.RUnlock()
+
+	budget := m.cfg.Services.LogBudget
+	if budget <= 0 || total <= budget {
+		return
+	}
+
+	sort.Slice(kept, func(i, j int) bool { return kept[i].modTime.Before(kept[j].modTime) })
+	for _, l := range kept {
+		if total <= budget {
+			break
+		}
+		if err := os.Truncate(l.path, 0); err != nil {
+			log.Warnf("Failed to empty log %s: %v", l.path, err)
+			continue
+		}
+		log.Infof("Emptied log %s (%d bytes) to stay within services.log_budget", l.path, l.size)
+		total -= l.size
+	}
+}
+
+// runLogCleanup cleans up the service logs every
+// services.log_cleanup_interval until ctx is done
+func (m *Manager) runLogCleanup(ctx context.Context) {
+	interval := time.Duration(m.cfg.Services.LogCleanupInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.cleanupServiceLogs()
+		}
+	}
+}
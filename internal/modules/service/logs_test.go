@@ -0,0 +1,157 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestCleanupServiceLogs(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	cfg.Services.LogBudget = 500
+	m := &Manager{
+		cfg:      cfg,
+		services: map[string]*ServiceInfo{"old": {Name: "old"}, "new": {Name: "new"}, "a/b": {Name: "a/b"}},
+		pending:  map[string]string{"exposing": ""},
+	}
+
+	dir := cfg.ServiceLogDir()
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"old.log", 300, 3 * time.Hour},
+		{"new.log", 300, time.Minute},
+		{"a%2Fb.log", 50, 2 * time.Hour},
+		{"exposing.log", 100, time.Hour},
+		{"gone.log", 1000, 4 * time.Hour},
+		{"notes.txt", 1000, 4 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", f.size)), 0640); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.cleanupServiceLogs()
+
+	sizes := map[string]int64{}
+	var total int64
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		info, _ := e.Info()
+		sizes[e.Name()] = info.Size()
+		if strings.HasSuffix(e.Name(), serviceLogSuffix) {
+			total += info.Size()
+		}
+	}
+
+	if _, ok := sizes["gone.log"]; ok {
+		t.Error("log of a service no longer present was kept")
+	}
+	if sizes["notes.txt"] != 1000 {
+		t.Error("a file that is not a service log was touched")
+	}
+	if total > cfg.Services.LogBudget {
+		t.Errorf("service logs use %d bytes, budget %d", total, cfg.Services.LogBudget)
+	}
+	// Only the oldest log had to go to fit the budget
+	want := map[string]int64{"old.log": 0, "new.log": 300, "a%2Fb.log": 50, "exposing.log": 100}
+	for name, size := range want {
+		got, ok := sizes[name]
+		if !ok || got != size {
+			t.Errorf("%s: %d bytes (present %v), want %d", name, got, ok, size)
+		}
+	}
+}
+
+func TestCleanupServiceLogsNoBudget(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	m := &Manager{cfg: cfg, services: map[string]*ServiceInfo{"web": {Name: "web"}}, pending: map[string]string{}}
+
+	f, err := m.openServiceLog("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(strings.Repeat("x", 1<<20))
+	f.Close()
+
+	m.cleanupServiceLogs()
+	if info, err := os.Stat(m.serviceLogPath("web")); err != nil || info.Size() != 1<<20 {
+		t.Errorf("log of a present service changed without a budget: %v %v", info, err)
+	}
+}
+
+func TestTunnelOutputLogged(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	m := &Manager{cfg: cfg}
+
+	tun := &processTunnel{
+		cmd:     exec.Command("sh", "-c", "echo connected; echo failed >&2"),
+		openLog: func() (*os.File, error) { return m.openServiceLog("../web") },
+	}
+	if err := tun.Start(nil); err != nil {
+		t.Fatal(err)
+	}
+	<-tun.done
+
+	data, err := os.ReadFile(filepath.Join(cfg.ServiceLogDir(), "..%2Fweb.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "connected\nfailed\n" {
+		t.Errorf("log = %q, want both output streams", data)
+	}
+}
+
+func TestServiceLogPath(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	m := &Manager{
+		cfg:      cfg,
+		services: map[string]*ServiceInfo{"ssh": {Name: "ssh"}},
+		pending:  map[string]string{"web": ""},
+	}
+
+	for name, known := range map[string]bool{"ssh": true, "web": true, "ftp": false} {
+		path, ok := m.ServiceLogPath(name)
+		if ok != known {
+			t.Errorf("ServiceLogPath(%q) found = %v, want %v", name, ok, known)
+		}
+		if ok && path != filepath.Join(cfg.ServiceLogDir(), name+".log") {
+			t.Errorf("ServiceLogPath(%q) = %q", name, path)
+		}
+	}
+}
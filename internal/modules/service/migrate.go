@@ -0,0 +1,172 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatusMigrated is the service event published when a tunnel restarts
+// against a new server
+const StatusMigrated = "migrated"
+
+// tunnelBackend returns the backend in use
+func (m *Manager) tunnelBackend() Backend {
+	m.endpointMu.RLock()
+	defer m.endpointMu.RUnlock()
+	return m.backend
+}
+
+// tunnelEndpoint returns the tunnel server endpoint in use
+func (m *Manager) tunnelEndpoint() string {
+	m.endpointMu.RLock()
+	defer m.endpointMu.RUnlock()
+	return m.wstunURL
+}
+
+// watchEndpoint checks the tunnel endpoint whenever the board settings are
+// reloaded or a WAMP session opens, possibly on a failover agent. Checks
+// run off the publisher's goroutine.
+func (m *Manager) watchEndpoint() {
+	unsubSettings := eventbus.Subscribe(eventbus.Default, eventbus.BoardChanged, func(e eventbus.BoardChange) {
+		if e.Field == "settings" {
+			go m.migrateLogged()
+		}
+	})
+	unsubWAMP := eventbus.Subscribe(eventbus.Default, eventbus.WAMPStateChanged, func(e eventbus.WAMPState) {
+		if e.Connected {
+			go m.migrateLogged()
+		}
+	})
+	m.unsubscribe = func() {
+		unsubSettings()
+		unsubWAMP()
+	}
+}
+
+// migrateLogged migrates the tunnels if needed, logging any failure
+func (m *Manager) migrateLogged() {
+	if _, err := m.migrateTunnels(); err != nil {
+		m.errs.Record("migrate tunnels", err)
+		log.Errorf("Failed to migrate tunnels: %v", err)
+	}
+}
+
+// migrateTunnels re-derives the tunnel endpoint from the WAMP URL and, if
+// it changed, restarts every running tunnel against the new server under
+// the same name, port and tunnel id. It returns the services migrated.
+func (m *Manager) migrateTunnels() ([]string, error) {
+	m.migrateMu.Lock()
+	defer m.migrateMu.Unlock()
+
+	if !m.started.Load() {
+		return nil, nil
+	}
+
+	host, endpoint, err := wstunEndpoint(m.cfg.Services, m.board.GetWampURL())
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == m.tunnelEndpoint() {
+		return nil, nil
+	}
+
+	m.endpointMu.RLock()
+	publicBase := m.publicBase
+	if m.cfg.Services.PublicBaseURL == "" {
+		publicBase = endpoint
+	}
+	m.endpointMu.RUnlock()
+
+	backend, err := newBackend(m.cfg.Services, endpoint, publicBase)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Tunnel server changed from %s to %s, migrating tunnels", m.tunnelEndpoint(), endpoint)
+
+	// Claim the running services so exposes and unexposes wait for the
+	// migration; services busy with one are left to it
+	m.mu.Lock()
+	var claimed []*ServiceInfo
+	for name, svc := range m.services {
+		if svc.Status != "running" {
+			continue
+		}
+		if err := m.reserve(name, svc.TunnelID); err != nil {
+			log.Warnf("Not migrating service %s: %v", name, err)
+			continue
+		}
+		claimed = append(claimed, svc)
+	}
+	m.mu.Unlock()
+
+	// The old clients are recognised by the old backend
+	grace := time.Duration(m.cfg.Services.StopGracePeriod) * time.Second
+	for _, svc := range claimed {
+		m.stopTunnel(svc, grace)
+	}
+
+	m.endpointMu.Lock()
+	m.wstunIP = host
+	m.wstunURL = endpoint
+	m.publicBase = publicBase
+	m.backend = backend
+	m.endpointMu.Unlock()
+
+	var migrated []string
+	var failed int
+	for _, svc := range claimed {
+		tunnel := m.newTunnel(svc)
+		startErr := tunnel.Start(svc.env)
+
+		m.mu.Lock()
+		if startErr != nil {
+			svc.Status = "dead"
+			svc.PID = 0
+			svc.tunnel = nil
+		} else {
+			svc.PublicURL = backend.PublicURL(svc)
+			svc.PID = tunnel.PID()
+			svc.tunnel = tunnel
+		}
+		m.release(svc.Name)
+		m.mu.Unlock()
+
+		if startErr != nil {
+			failed++
+			log.Errorf("Failed to restart the tunnel of service %s on %s: %v", svc.Name, endpoint, startErr)
+			publishStatus(eventbus.ServiceStatus{Name: svc.Name, Status: "dead"})
+			continue
+		}
+
+		log.Infof("Service %s migrated to %s (PID: %d)", svc.Name, endpoint, svc.PID)
+		publishStatus(eventbus.ServiceStatus{Name: svc.Name, Status: StatusMigrated, PID: svc.PID})
+		migrated = append(migrated, svc.Name)
+	}
+
+	if len(claimed) > 0 {
+		m.scheduleSave()
+	}
+	if failed > 0 {
+		return migrated, fmt.Errorf("%d of %d tunnels could not be restarted on %s", failed, len(claimed), endpoint)
+	}
+	return migrated, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+)
+
+// migrateSettings returns board settings whose main agent is wampURL
+func migrateSettings(t *testing.T, wampURL string) *config.BoardSettings {
+	t.Helper()
+	s := &config.BoardSettings{}
+	s.Iotronic.Board.UUID = "b1"
+	s.Iotronic.Board.Code = "TESTCODE"
+	s.Iotronic.Board.Status = "registered"
+	s.Iotronic.WAMP.MainAgent = &config.WampAgent{URL: wampURL, Realm: "s4t"}
+	return s
+}
+
+// newMigrateTestManager returns a started manager whose board points at
+// the router.test WAMP agent
+func newMigrateTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	m := newExposeTestManager(t)
+	m.cfg.Board.SettingsFile = filepath.Join(m.cfg.LightningRod.Home, "settings.json")
+	m.cfg.Board.SettingsReadAttempts = 1
+	if err := config.SaveBoardSettings(m.cfg.Board.SettingsFile, migrateSettings(t, "ws://router.test:8181/")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := board.New(m.cfg)
+	if err != nil {
+		t.Fatalf("board.New: %v", err)
+	}
+	m.board = b
+	m.wstunURL = "ws://router.test:8080"
+	m.started.Store(true)
+	return m
+}
+
+// tunnelArgs returns the command line of the client running svc
+func tunnelArgs(t *testing.T, m *Manager, name string) []string {
+	t.Helper()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	pt, ok := m.services[name].tunnel.(*processTunnel)
+	if !ok {
+		t.Fatalf("service %s has no tunnel", name)
+	}
+	return pt.cmd.Args
+}
+
+func TestMigrateTunnelsOnEndpointChange(t *testing.T) {
+	m := newMigrateTestManager(t)
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose ssh: %v", err)
+	}
+	if err := m.exposeService("web", 80, defaultTargetHost, "web-tunnel", map[string]string{"TOKEN": "x"}); err != nil {
+		t.Fatalf("expose web: %v", err)
+	}
+	oldPIDs := map[string]int{"ssh": m.services["ssh"].PID, "web": m.services["web"].PID}
+
+	events := make(chan eventbus.ServiceStatus, 16)
+	unsub := eventbus.Subscribe(eventbus.Default, eventbus.ServiceStatusChanged, func(e eventbus.ServiceStatus) {
+		if e.Status == StatusMigrated {
+			events <- e
+		}
+	})
+	t.Cleanup(unsub)
+	m.watchEndpoint()
+	t.Cleanup(m.unsubscribe)
+
+	// A settings push moves the board to another server
+	if err := m.board.SetConfig(migrateSettings(t, "wss://new-router.test:8181/")); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+
+	var migrated []string
+	for len(migrated) < 2 {
+		select {
+		case e := <-events:
+			migrated = append(migrated, e.Name)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("migrated events = %v, want ssh and web", migrated)
+		}
+	}
+	slices.Sort(migrated)
+	if !slices.Equal(migrated, []string{"ssh", "web"}) {
+		t.Errorf("migrated = %v, want [ssh web]", migrated)
+	}
+
+	const newURL = "wss://new-router.test:8080"
+	if got := m.tunnelEndpoint(); got != newURL {
+		t.Errorf("tunnel endpoint = %q, want %q", got, newURL)
+	}
+
+	for _, name := range []string{"ssh", "web"} {
+		m.mu.RLock()
+		svc := m.services[name]
+		status, pid, publicURL := svc.Status, svc.PID, svc.PublicURL
+		m.mu.RUnlock()
+
+		if status != "running" || pid <= 0 || pid == oldPIDs[name] {
+			t.Errorf("%s: status %s, PID %d (was %d), want a new running client", name, status, pid, oldPIDs[name])
+		}
+		if !strings.HasPrefix(publicURL, newURL) {
+			t.Errorf("%s: public URL %q, want it under %s", name, publicURL, newURL)
+		}
+		if args := tunnelArgs(t, m, name); !slices.Contains(args, newURL) {
+			t.Errorf("%s: client args %v, want server %s", name, args, newURL)
+		}
+
+		// The old client is gone
+		if err := syscall.Kill(oldPIDs[name], 0); err == nil {
+			t.Errorf("%s: old client %d still running", name, oldPIDs[name])
+		}
+	}
+
+	// Names, ports, tunnel ids and environment are kept
+	m.mu.RLock()
+	web := m.services["web"]
+	if web.LocalPort != 80 || web.TunnelID != "web-tunnel" || web.env["TOKEN"] != "x" {
+		t.Errorf("web = %+v, want port 80, tunnel id web-tunnel and its env", web)
+	}
+	if len(m.pending) != 0 {
+		t.Errorf("reservations left behind: %v", m.pending)
+	}
+	m.mu.RUnlock()
+
+	// Without a further change nothing restarts
+	migratedAgain, err := m.migrateTunnels()
+	if err != nil || len(migratedAgain) != 0 {
+		t.Errorf("migrateTunnels() = %v, %v, want nothing to do", migratedAgain, err)
+	}
+}
+
+func TestMigrateTunnelsStartFailure(t *testing.T) {
+	m := newMigrateTestManager(t)
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose ssh: %v", err)
+	}
+
+	// The client binary disappears before the endpoint changes
+	m.cfg.Services.WstunBin = filepath.Join(t.TempDir(), "missing")
+	if err := m.board.SetConfig(migrateSettings(t, "ws://new-router.test:8181/")); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+
+	if _, err := m.migrateTunnels(); err == nil {
+		t.Fatal("migrateTunnels succeeded without a client binary")
+	}
+	m.mu.RLock()
+	svc := m.services["ssh"]
+	if svc.Status != "dead" || svc.PID != 0 {
+		t.Errorf("ssh = %+v, want it recorded dead", svc)
+	}
+	m.mu.RUnlock()
+}
+
+func TestMigrateTunnelsStopped(t *testing.T) {
+	m := newMigrateTestManager(t)
+	m.started.Store(false)
+
+	if err := m.board.SetConfig(migrateSettings(t, "ws://new-router.test:8181/")); err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+	if migrated, err := m.migrateTunnels(); err != nil || migrated != nil {
+		t.Errorf("migrateTunnels() = %v, %v on a stopped manager", migrated, err)
+	}
+	if got := m.tunnelEndpoint(); got != "ws://router.test:8080" {
+		t.Errorf("tunnel endpoint = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/state"
+)
+
+func TestStateLocations(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = filepath.Join(root, "home")
+	cfg.LightningRod.RuntimeDir = filepath.Join(root, "run")
+	cfg.LightningRod.SettingsDir = filepath.Join(root, "settings")
+	if err := config.EnsureDirs(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := state.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{
+		cfg:      cfg,
+		store:    store,
+		services: map[string]*ServiceInfo{"web": {Name: "web"}},
+	}
+	if err := m.saveServicesConfig(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := m.openServiceLog("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for _, path := range []string{
+		filepath.Join(root, "run", "services.json"),
+		filepath.Join(root, "run", "logs", "services", "web.log"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s: %v", path, err)
+		}
+	}
+	if got := cfg.SettingsFile(); got != filepath.Join(root, "settings", "settings.json") {
+		t.Errorf("SettingsFile() = %q, want it in settings_dir", got)
+	}
+
+	// Nothing lands in home besides the state directory
+	entries, err := os.ReadDir(cfg.LightningRod.Home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "state" {
+			t.Errorf("unexpected %s in home", e.Name())
+		}
+	}
+}
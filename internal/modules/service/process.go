@@ -0,0 +1,151 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// procRoot is the procfs mount point used to inspect running processes
+var procRoot = "/proc"
+
+// processPollInterval is how often a terminating process is checked for exit
+const processPollInterval = 100 * time.Millisecond
+
+// readCmdline returns the command line arguments of a running process
+func readCmdline(pid int) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil, fmt.Errorf("process %d has no command line", pid)
+	}
+
+	parts := bytes.Split(data, []byte{0})
+	args := make([]string, 0, len(parts))
+	for _, p := range parts {
+		args = append(args, string(p))
+	}
+
+	return args, nil
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// liveStatus returns the status of svc, reporting a running service whose
+// tunnel process is gone as dead
+func liveStatus(svc *ServiceInfo) string {
+	if svc.Status != "running" {
+		return svc.Status
+	}
+	if svc.tunnel != nil {
+		return svc.tunnel.Status()
+	}
+	if svc.PID <= 0 || !processAlive(svc.PID) {
+		return "dead"
+	}
+	return "running"
+}
+
+// isTunnelProcess reports whether pid is a tunnel client for our server
+func (m *Manager) isTunnelProcess(pid int) bool {
+	args, err := readCmdline(pid)
+	if err != nil {
+		return false
+	}
+
+	_, ok := m.tunnelBackend().Match(args)
+	return ok
+}
+
+// ownsProcess reports whether the PID recorded for svc still belongs to the
+// tunnel client we launched, so a reused PID is never signalled
+func (m *Manager) ownsProcess(svc *ServiceInfo) bool {
+	if svc.PID <= 0 {
+		return false
+	}
+
+	// Children started by this agent are reaped as soon as they exit, so a
+	// dead tunnel means the PID may already belong to someone else
+	if svc.tunnel != nil {
+		return svc.tunnel.Status() == "running"
+	}
+
+	return processAlive(svc.PID) && m.isTunnelProcess(svc.PID)
+}
+
+// terminateProcess sends SIGTERM to pid and waits up to grace for it to exit,
+// falling back to SIGKILL if it is still alive afterwards
+func terminateProcess(pid int, done <-chan struct{}, grace time.Duration) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	exited := func() bool {
+		if done != nil {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}
+		return !processAlive(pid)
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil
+		}
+		return fmt.Errorf("failed to send SIGTERM to %d: %w", pid, err)
+	}
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if exited() {
+			return nil
+		}
+		time.Sleep(processPollInterval)
+	}
+
+	if exited() {
+		return nil
+	}
+
+	log.Warnf("Process %d did not exit within %v, sending SIGKILL", pid, grace)
+	if err := process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill process %d: %w", pid, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// deadPID returns the PID of a process that has already exited and been
+// reaped
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+// fakeTunnel is a tunnel in a fixed state
+type fakeTunnel struct {
+	status string
+	pid    int
+}
+
+func (f *fakeTunnel) Start(map[string]string) error { return nil }
+func (f *fakeTunnel) Stop(time.Duration) error      { f.status = "dead"; return nil }
+func (f *fakeTunnel) Status() string                { return f.status }
+func (f *fakeTunnel) PID() int                      { return f.pid }
+
+func TestListServicesLiveStatus(t *testing.T) {
+	m := &Manager{services: map[string]*ServiceInfo{
+		"live":     {Name: "live", Status: "running", PID: os.Getpid()},
+		"dead":     {Name: "dead", Status: "running", PID: deadPID(t)},
+		"reaped":   {Name: "reaped", Status: "running", PID: os.Getpid(), tunnel: &fakeTunnel{status: "dead"}},
+		"child":    {Name: "child", Status: "running", PID: 1 << 30, tunnel: &fakeTunnel{status: "running"}},
+		"nopid":    {Name: "nopid", Status: "running"},
+		"stopping": {Name: "stopping", Status: "stopping", PID: os.Getpid()},
+	}}
+	want := map[string]string{
+		"live":     "running",
+		"dead":     "dead",
+		"reaped":   "dead",
+		"child":    "running",
+		"nopid":    "dead",
+		"stopping": "stopping",
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	list := m.listServices(now)
+	if len(list) != len(want) {
+		t.Fatalf("listed %d services, want %d", len(list), len(want))
+	}
+	for _, entry := range list {
+		name := entry["name"].(string)
+		if entry["status"] != want[name] {
+			t.Errorf("%s: status = %v, want %s", name, entry["status"], want[name])
+		}
+		if entry["checked_at"] != "2024-05-01T12:00:00Z" {
+			t.Errorf("%s: checked_at = %v", name, entry["checked_at"])
+		}
+	}
+
+	// Listing reports, it does not reconcile
+	if m.services["dead"].Status != "running" {
+		t.Errorf("recorded status changed to %q", m.services["dead"].Status)
+	}
+}
+
+// startChild runs a shell child that installs trap on SIGTERM and loops
+// until killed. done is closed once the child has been reaped.
+func startChild(t *testing.T, trap string) (*exec.Cmd, <-chan struct{}) {
+	t.Helper()
+
+	cmd := exec.Command("sh", "-c", trap+"; echo ready; while :; do sleep 0.05; done")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot run sh: %v", err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	// The trap is in place once the child says so
+	if !bufio.NewScanner(stdout).Scan() {
+		t.Fatal("child exited before installing its trap")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	return cmd, done
+}
+
+func TestTerminateProcess(t *testing.T) {
+	const grace = 500 * time.Millisecond
+
+	tests := []struct {
+		name string
+		trap string
+		// reaped passes the done channel of our own child; without it the
+		// process is polled like one adopted from a previous run
+		reaped bool
+		killed bool
+	}{
+		{"exits on SIGTERM", "trap 'exit 0' TERM", true, false},
+		{"ignores SIGTERM", "trap '' TERM", true, true},
+		{"adopted exits on SIGTERM", "trap 'exit 0' TERM", false, false},
+		{"adopted ignores SIGTERM", "trap '' TERM", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, done := startChild(t, tt.trap)
+			var wait <-chan struct{}
+			if tt.reaped {
+				wait = done
+			}
+
+			start := time.Now()
+			if err := terminateProcess(cmd.Process.Pid, wait, grace); err != nil {
+				t.Fatalf("terminateProcess: %v", err)
+			}
+			elapsed := time.Since(start)
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("child still running after terminateProcess")
+			}
+			status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+			if killed := status.Signaled() && status.Signal() == syscall.SIGKILL; killed != tt.killed {
+				t.Errorf("killed = %v (status %v), want %v", killed, cmd.ProcessState, tt.killed)
+			}
+			if tt.killed && elapsed < grace {
+				t.Errorf("SIGKILL after %v, before the %v grace period", elapsed, grace)
+			}
+			if !tt.killed && elapsed >= grace {
+				t.Errorf("graceful exit took %v, the whole grace period", elapsed)
+			}
+		})
+	}
+}
+
+func TestOwnsProcess(t *testing.T) {
+	wstun := filepath.Join(t.TempDir(), "wstun")
+	if err := os.WriteFile(wstun, []byte("#!/bin/sh\nsleep 60\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	const server = "ws://router.test:8080"
+	m := &Manager{backend: &wstunBackend{bin: wstun, server: server}}
+
+	client := exec.Command("sh", wstun, "client", "-s", server, "-t", "127.0.0.1:22")
+	if err := client.Start(); err != nil {
+		t.Skipf("cannot run sh: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Process.Kill()
+		client.Wait()
+	})
+	// Wait for the exec, so the command line is the client's
+	deadline := time.Now().Add(5 * time.Second)
+	for !m.isTunnelProcess(client.Process.Pid) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name string
+		svc  *ServiceInfo
+		want bool
+	}{
+		{"adopted client", &ServiceInfo{PID: client.Process.Pid}, true},
+		{"reused by another program", &ServiceInfo{PID: os.Getpid()}, false},
+		{"exited", &ServiceInfo{PID: deadPID(t)}, false},
+		{"no pid", &ServiceInfo{}, false},
+		{"running child", &ServiceInfo{PID: os.Getpid(), tunnel: &fakeTunnel{status: "running"}}, true},
+		{"reaped child", &ServiceInfo{PID: client.Process.Pid, tunnel: &fakeTunnel{status: "dead"}}, false},
+	}
+	for _, tt := range tests {
+		if got := m.ownsProcess(tt.svc); got != tt.want {
+			t.Errorf("%s: ownsProcess = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
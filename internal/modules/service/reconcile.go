@@ -0,0 +1,147 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+	log "github.com/sirupsen/logrus"
+)
+
+// wstunProcess describes a running wstun client found on the system
+type wstunProcess struct {
+	PID    int
+	Target string
+}
+
+// reconcilePlan lists the decisions taken for leftover wstun processes
+type reconcilePlan struct {
+	// Adopt maps service names to the process that is still serving them
+	Adopt map[string]wstunProcess
+	// Kill lists processes that belong to no known service
+	Kill []wstunProcess
+	// Stale lists services whose recorded process is gone
+	Stale []string
+}
+
+// listWstunProcesses scans procfs for wstun clients connected to our server
+func (m *Manager) listWstunProcesses() ([]wstunProcess, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	self := os.Getpid()
+	var procs []wstunProcess
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+
+		args, err := readCmdline(pid)
+		if err != nil {
+			continue
+		}
+
+		if target, ok := m.tunnelBackend().Match(args); ok {
+			procs = append(procs, wstunProcess{PID: pid, Target: target})
+		}
+	}
+
+	return procs, nil
+}
+
+// planReconcile decides which running wstun processes to adopt into the
+// known services and which ones to kill as orphans
+func planReconcile(services map[string]*ServiceInfo, procs []wstunProcess) reconcilePlan {
+	plan := reconcilePlan{Adopt: make(map[string]wstunProcess)}
+
+	byPID := make(map[int]string, len(services))
+	for name, svc := range services {
+		if svc.PID > 0 {
+			byPID[svc.PID] = name
+		}
+	}
+
+	for _, p := range procs {
+		name, ok := byPID[p.PID]
+		if !ok || services[name].target() != p.Target {
+			plan.Kill = append(plan.Kill, p)
+			continue
+		}
+		if _, dup := plan.Adopt[name]; dup {
+			plan.Kill = append(plan.Kill, p)
+			continue
+		}
+		plan.Adopt[name] = p
+	}
+
+	for name := range services {
+		if _, ok := plan.Adopt[name]; !ok {
+			plan.Stale = append(plan.Stale, name)
+		}
+	}
+
+	return plan
+}
+
+// reconcileOrphans adopts wstun clients left over by a previous run that
+// match services.json and kills the ones that don't
+func (m *Manager) reconcileOrphans() error {
+	procs, err := m.listWstunProcesses()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	plan := planReconcile(m.services, procs)
+
+	var changed []eventbus.ServiceStatus
+	for name, p := range plan.Adopt {
+		m.services[name].Status = "running"
+		changed = append(changed, eventbus.ServiceStatus{Name: name, Status: "running", PID: p.PID})
+		log.Infof("Adopted running wstun process %d for service %s", p.PID, name)
+	}
+
+	for _, name := range plan.Stale {
+		m.services[name].Status = "dead"
+		changed = append(changed, eventbus.ServiceStatus{Name: name, Status: "dead", PID: m.services[name].PID})
+		log.Warnf("wstun process for service %s is not running", name)
+	}
+	m.mu.Unlock()
+
+	for _, e := range changed {
+		publishStatus(e)
+	}
+
+	grace := time.Duration(m.cfg.Services.StopGracePeriod) * time.Second
+	for _, p := range plan.Kill {
+		log.Warnf("Killing orphaned wstun process %d (target: %s)", p.PID, p.Target)
+		if err := terminateProcess(p.PID, nil, grace); err != nil {
+			log.Warnf("Failed to terminate orphaned process %d: %v", p.PID, err)
+		}
+	}
+
+	if len(plan.Adopt) > 0 || len(plan.Stale) > 0 {
+		m.scheduleSave()
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPlanReconcile(t *testing.T) {
+	services := map[string]*ServiceInfo{
+		"ssh":  {Name: "ssh", LocalPort: 22, PID: 100},
+		"web":  {Name: "web", LocalPort: 80, TargetHost: "192.168.1.10", PID: 200},
+		"gone": {Name: "gone", LocalPort: 8080, PID: 300},
+		"api":  {Name: "api", LocalPort: 9000, PID: 400},
+	}
+	procs := []wstunProcess{
+		{PID: 100, Target: "127.0.0.1:22"},
+		{PID: 200, Target: "192.168.1.10:80"},
+		// Unknown PID, even though the target is a known service
+		{PID: 101, Target: "127.0.0.1:22"},
+		// Known PID, now forwarding elsewhere
+		{PID: 400, Target: "127.0.0.1:9001"},
+		{PID: 500, Target: "127.0.0.1:5000"},
+	}
+
+	plan := planReconcile(services, procs)
+
+	wantAdopt := map[string]wstunProcess{
+		"ssh": {PID: 100, Target: "127.0.0.1:22"},
+		"web": {PID: 200, Target: "192.168.1.10:80"},
+	}
+	if !reflect.DeepEqual(plan.Adopt, wantAdopt) {
+		t.Errorf("adopt = %v, want %v", plan.Adopt, wantAdopt)
+	}
+
+	var killed []int
+	for _, p := range plan.Kill {
+		killed = append(killed, p.PID)
+	}
+	sort.Ints(killed)
+	if want := []int{101, 400, 500}; !reflect.DeepEqual(killed, want) {
+		t.Errorf("kill = %v, want %v", killed, want)
+	}
+
+	sort.Strings(plan.Stale)
+	if want := []string{"api", "gone"}; !reflect.DeepEqual(plan.Stale, want) {
+		t.Errorf("stale = %v, want %v", plan.Stale, want)
+	}
+}
+
+func TestPlanReconcileNothingRunning(t *testing.T) {
+	plan := planReconcile(map[string]*ServiceInfo{}, []wstunProcess{{PID: 100, Target: "127.0.0.1:22"}})
+	if len(plan.Adopt) != 0 || len(plan.Stale) != 0 || len(plan.Kill) != 1 {
+		t.Errorf("plan = %+v, want the lone process killed", plan)
+	}
+}
+
+// writeProc adds a fake process with the given command line to a procfs
+// tree rooted at root
+func writeProc(t *testing.T, root string, pid int, args ...string) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(strings.Join(args, "\x00")+"\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListWstunProcesses(t *testing.T) {
+	root := t.TempDir()
+	orig := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = orig })
+
+	const bin, server = "/usr/bin/wstun", "ws://router.test:8080"
+	writeProc(t, root, 10, bin, "client", "-s", server, "-t", "127.0.0.1:22")
+	writeProc(t, root, 11, "node", bin, "client", "-t", "127.0.0.1:80", "-s", server)
+	// Another router, a wstun server, another program and a kernel thread
+	writeProc(t, root, 12, bin, "client", "-s", "ws://other.test:8080", "-t", "127.0.0.1:22")
+	writeProc(t, root, 13, bin, "server", "-s", server, "-t", "127.0.0.1:22")
+	writeProc(t, root, 14, "/usr/bin/ssh", "-s", server, "-t", "127.0.0.1:22")
+	writeProc(t, root, 15)
+	writeProc(t, root, os.Getpid(), bin, "client", "-s", server, "-t", "127.0.0.1:23")
+	os.MkdirAll(filepath.Join(root, "self"), 0755)
+
+	m := &Manager{backend: &wstunBackend{bin: bin, server: server}}
+	procs, err := m.listWstunProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	want := []wstunProcess{{PID: 10, Target: "127.0.0.1:22"}, {PID: 11, Target: "127.0.0.1:80"}}
+	if !reflect.DeepEqual(procs, want) {
+		t.Errorf("processes = %+v, want %+v", procs, want)
+	}
+}
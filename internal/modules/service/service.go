@@ -18,23 +18,52 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/url"
-	"os"
 	"os/exec"
-	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/state"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/wamp"
 	gammazero "github.com/gammazero/nexus/v3/client"
 	nexuswamp "github.com/gammazero/nexus/v3/wamp"
 	log "github.com/sirupsen/logrus"
 )
 
-// Manager handles service tunnel management via wstun
+// ErrTunnelLimit is returned when services.max_tunnels tunnels are running
+var ErrTunnelLimit = errors.New("tunnel limit reached")
+
+// lookPath locates the tunnel client binary, replaceable for testing
+var lookPath = exec.LookPath
+
+// ErrStopping is returned for operations started once Stop has begun
+var ErrStopping = errors.New("service manager is stopping")
+
+// defaultTargetHost is where tunnels forward to unless told otherwise
+const defaultTargetHost = "127.0.0.1"
+
+// envKeyPattern matches valid environment variable names
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// hostnamePattern matches RFC 1123 host names
+var hostnamePattern = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// Manager handles service tunnel management through a tunnel backend,
+// wstun by default
 type Manager struct {
 	mu sync.RWMutex
 
@@ -42,23 +71,245 @@ type Manager struct {
 	cfg        *config.Config
 	wampClient *wamp.Client
 
-	wstunIP   string
-	wstunPort string
-	wstunURL  string
-	boardID   string
+	// endpointMu guards the tunnel endpoint and backend, which change
+	// when tunnels migrate to a new server
+	endpointMu sync.RWMutex
+	wstunIP    string
+	wstunPort  string
+	wstunURL   string
+	boardID    string
+
+	// publicBase is the base of the advertised public service URLs
+	publicBase string
+
+	// backend launches and recognises the tunnel clients
+	backend Backend
+
+	// clientVersion is the version reported by the tunnel client, if known
+	clientVersion string
+
+	// notReady explains why tunnels cannot be created
+	notReady string
+
+	// errs holds the last failed service operation
+	errs lasterror.Tracker
 
 	services map[string]*ServiceInfo
+
+	// pending holds the services being exposed or stopped, with the tunnel
+	// id an expose claims; the slow part of those operations runs without
+	// mu held
+	pending map[string]string
+
+	// ops counts the reservations held, so Stop can wait for them;
+	// stopping rejects new ones (guarded by mu)
+	ops      sync.WaitGroup
+	stopping bool
+
+	// store persists the services state
+	store state.Store
+
+	// saveMu serializes writes of services.json
+	saveMu sync.Mutex
+
+	// saveTimer is the pending coalesced save, if any
+	timerMu   sync.Mutex
+	saveTimer *time.Timer
+
+	// cancel stops the periodic log cleanup
+	cancel context.CancelFunc
+
+	// migrateMu serializes tunnel migrations; unsubscribe stops watching
+	// for endpoint changes
+	migrateMu   sync.Mutex
+	unsubscribe func()
+
+	// tunnelFactory builds service tunnels instead of newTunnel when set,
+	// for testing
+	tunnelFactory func(svc *ServiceInfo) Tunnel
+
+	// publish and connected reach the cloud, replaceable for testing
+	publish   func(topic string, args []any, kwargs map[string]any) error
+	connected func() bool
+
+	started  atomic.Bool
+	rpcCount atomic.Int32
 }
 
 // ServiceInfo represents a tunneled service
 type ServiceInfo struct {
-	Name      string `json:"name"`
-	LocalPort int    `json:"local_port"`
-	PublicURL string `json:"public_url"`
-	PID       int    `json:"pid"`
-	Status    string `json:"status"`
+	Name       string `json:"name"`
+	LocalPort  int    `json:"local_port"`
+	TargetHost string `json:"target_host,omitempty"`
+	TunnelID   string `json:"tunnel_id,omitempty"`
+	PublicURL  string `json:"public_url"`
+	PID        int    `json:"pid"`
+	Status     string `json:"status"`
+
+	// tunnel is the client launched by this agent, nil for clients
+	// adopted from a previous run
+	tunnel Tunnel
+
+	// env is the environment the client was launched with, kept in memory
+	// only so a migrated tunnel restarts the same way
+	env map[string]string
+}
+
+// target returns the address wstun forwards the tunnel to
+func (s *ServiceInfo) target() string {
+	host := s.TargetHost
+	if host == "" {
+		host = defaultTargetHost
+	}
+	return net.JoinHostPort(host, strconv.Itoa(s.LocalPort))
+}
+
+// agentFlags are the wstun flags the agent sets itself: the server, the
+// tunnel and the tunnel identifier, in their short and long forms
+var agentFlags = []string{"-s", "--server", "-t", "--tunnel", wstunIDFlag}
+
+// agentFlag returns the agent flag arg sets, or "". Values may follow a
+// flag after "=" or, for short flags, attached as in -sws://host.
+func agentFlag(arg string) string {
+	for _, flag := range agentFlags {
+		switch {
+		case arg == flag, strings.HasPrefix(arg, flag+"="):
+			return flag
+		case !strings.HasPrefix(flag, "--") && strings.HasPrefix(arg, flag):
+			return flag
+		}
+	}
+	return ""
+}
+
+// validateExtraArgs checks the configured extra wstun arguments are
+// non-empty, free of control characters and do not override -s, -t or the
+// tunnel identifier
+func validateExtraArgs(args []string) error {
+	for _, arg := range args {
+		if strings.TrimSpace(arg) == "" {
+			return fmt.Errorf("empty argument")
+		}
+		if strings.IndexFunc(arg, unicode.IsControl) >= 0 {
+			return fmt.Errorf("argument %q contains control characters", arg)
+		}
+		if flag := agentFlag(arg); flag != "" {
+			return fmt.Errorf("argument %s is set by the agent", flag)
+		}
+	}
+	return nil
+}
+
+// parseEnv validates the env kwarg of ExposeService, a map of variable
+// names to string values
+func parseEnv(raw any) (map[string]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	vars, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid env: expected a map of strings")
+	}
+
+	env := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if !envKeyPattern.MatchString(k) {
+			return nil, fmt.Errorf("invalid env variable name %q", k)
+		}
+		value, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for env variable %s: expected a string", k)
+		}
+		env[k] = value
+	}
+
+	return env, nil
 }
 
+// envKeys returns the sorted variable names of env
+func envKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeEnv returns base with the variables of env set, replacing any
+// existing definitions
+func mergeEnv(base []string, env map[string]string) []string {
+	merged := make([]string, 0, len(base)+len(env))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, override := env[name]; !override {
+			merged = append(merged, kv)
+		}
+	}
+	for _, k := range envKeys(env) {
+		merged = append(merged, k+"="+env[k])
+	}
+	return merged
+}
+
+// validateLocalPort rejects ports outside the TCP range
+func validateLocalPort(port float64) error {
+	if port < 1 || port > 65535 || port != float64(int(port)) {
+		return fmt.Errorf("invalid local_port %v: must be an integer between 1 and 65535", port)
+	}
+	return nil
+}
+
+// validateTargetHost rejects hosts a tunnel cannot usefully forward to
+func validateTargetHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsUnspecified() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+			return fmt.Errorf("target_host %s is not a unicast address", host)
+		}
+		return nil
+	}
+
+	if !hostnamePattern.MatchString(host) {
+		return fmt.Errorf("invalid target_host %q", host)
+	}
+
+	return nil
+}
+
+// defaultWstunPort is the port of the wstun server on the WAMP host
+const defaultWstunPort = "8080"
+
+// wstunEndpoint derives the wstun server from the WAMP URL: the same host
+// on the wstun port, over ws or wss like the WAMP URL unless
+// services.wstun_scheme says otherwise
+func wstunEndpoint(cfg config.ServicesConfig, wampURL string) (host, endpoint string, err error) {
+	parsedURL, err := url.Parse(wampURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse WAMP URL: %w", err)
+	}
+
+	// Hostname strips the port and the brackets of IPv6 literals
+	host = parsedURL.Hostname()
+
+	protocol := "ws"
+	if parsedURL.Scheme == "wss" {
+		protocol = "wss"
+	}
+	switch cfg.WstunScheme {
+	case "":
+	case "ws", "wss":
+		protocol = cfg.WstunScheme
+	default:
+		return "", "", fmt.Errorf("invalid services.wstun_scheme %q (expected ws or wss)", cfg.WstunScheme)
+	}
+
+	return host, fmt.Sprintf("%s://%s", protocol, net.JoinHostPort(host, defaultWstunPort)), nil
+}
+
+// servicesStateName is the state store key of the services configuration
+const servicesStateName = "services"
+
 // ServicesConfig represents the services.json file
 type ServicesConfig struct {
 	Services map[string]*ServiceInfo `json:"services"`
@@ -71,28 +322,47 @@ func NewManager(cfg *config.Config, board *board.Board, wampClient *wamp.Client)
 		cfg:        cfg,
 		wampClient: wampClient,
 		services:   make(map[string]*ServiceInfo),
+		pending:    make(map[string]string),
 		boardID:    board.UUID,
 	}
+	if wampClient != nil {
+		m.publish = wampClient.Publish
+		m.connected = wampClient.IsConnected
+	}
 
-	// Parse WAMP URL to get wstun connection info
-	parsedURL, err := url.Parse(board.GetWampURL())
+	store, err := state.New(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse WAMP URL: %w", err)
+		return nil, err
 	}
+	m.store = store
 
-	hostParts := strings.Split(parsedURL.Host, ":")
-	m.wstunIP = hostParts[0]
-	m.wstunPort = "8080" // Default wstun port
+	// Derive the wstun endpoint from the WAMP URL
+	m.wstunIP, m.wstunURL, err = wstunEndpoint(cfg.Services, board.GetWampURL())
+	if err != nil {
+		return nil, err
+	}
+	m.wstunPort = defaultWstunPort
+
+	// Advertise services under the externally reachable base, if it
+	// differs from the wstun endpoint
+	m.publicBase = m.wstunURL
+	if base := cfg.Services.PublicBaseURL; base != "" {
+		u, err := url.Parse(base)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid services.public_base_url %q", base)
+		}
+		m.publicBase = strings.TrimRight(base, "/")
+	}
 
-	// Determine protocol (ws or wss)
-	protocol := "ws"
-	if parsedURL.Scheme == "wss" {
-		protocol = "wss"
+	backend, err := newBackend(cfg.Services, m.wstunURL, m.publicBase)
+	if err != nil {
+		return nil, err
 	}
-	m.wstunURL = fmt.Sprintf("%s://%s:%s", protocol, m.wstunIP, m.wstunPort)
+	m.backend = backend
 
-	log.Infof("WSTUN bin path: %s", cfg.Services.WstunBin)
-	log.Infof("WSTUN URL: %s", m.wstunURL)
+	log.Infof("Tunnel backend: %s (%s)", backend.Name(), backend.Bin())
+	log.Infof("Tunnel server URL: %s", backend.ServerURL())
+	log.Infof("Public base URL: %s", m.publicBase)
 
 	return m, nil
 }
@@ -101,16 +371,36 @@ func NewManager(cfg *config.Config, board *board.Board, wampClient *wamp.Client)
 func (m *Manager) Start(ctx context.Context) error {
 	log.Info("Starting Service Manager...")
 
+	// Without the tunnel client ExposeService is registered but answers
+	// NOT_READY
+	m.checkClient()
+
 	// Load existing services configuration
 	if err := m.loadServicesConfig(); err != nil {
 		log.Warnf("Failed to load services config: %v", err)
 	}
 
+	// Adopt or clean up wstun clients left behind by a previous run
+	if err := m.reconcileOrphans(); err != nil {
+		log.Warnf("Failed to reconcile wstun processes: %v", err)
+	}
+
+	// Drop the logs of services gone since the last run
+	m.cleanupServiceLogs()
+
 	// Register RPC procedures
 	if err := m.registerRPCs(); err != nil {
 		return fmt.Errorf("failed to register RPCs: %w", err)
 	}
 
+	cleanupCtx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	go m.runLogCleanup(cleanupCtx)
+
+	// Follow the tunnel server when the WAMP endpoint changes
+	m.watchEndpoint()
+
+	m.started.Store(true)
 	log.Info("Service Manager started successfully")
 	return nil
 }
@@ -118,27 +408,135 @@ func (m *Manager) Start(ctx context.Context) error {
 // Stop shuts down the service manager
 func (m *Manager) Stop() error {
 	log.Info("Stopping Service Manager...")
+	m.started.Store(false)
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.unsubscribe != nil {
+		m.unsubscribe()
+	}
 
-	// Stop all running services
+	// Let a migration in progress finish; later ones see the manager
+	// stopped
+	m.migrateMu.Lock()
+	m.migrateMu.Unlock()
+
+	// Refuse new operations and wait for the ones in progress, so a
+	// tunnel started by a late expose is stopped below rather than leaked
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.stopping = true
+	m.mu.Unlock()
+	m.ops.Wait()
 
+	// Stop all running services
+	m.mu.RLock()
+	names := make([]string, 0, len(m.services))
 	for name := range m.services {
-		if err := m.stopService(name); err != nil {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+
+	stopped := make([]string, 0, len(names))
+	for _, name := range names {
+		if err := m.unexpose(name, true); err != nil {
 			log.Errorf("Failed to stop service %s: %v", name, err)
+			continue
 		}
+		stopped = append(stopped, name)
+	}
+	m.announceTeardown(stopped)
+
+	// Write the final state regardless of any pending save
+	m.cancelSave()
+	if err := m.saveServicesConfig(); err != nil {
+		return fmt.Errorf("failed to save services config: %w", err)
 	}
 
 	return nil
 }
 
+// Name returns the module name
+func (m *Manager) Name() string {
+	return "service"
+}
+
+// Healthy reports whether the manager is started
+func (m *Manager) Healthy() bool {
+	return m.started.Load()
+}
+
+// Ready reports whether the manager's dependencies are available and, if
+// not, why
+func (m *Manager) Ready() (bool, string) {
+	m.mu.RLock()
+	reason := m.notReady
+	m.mu.RUnlock()
+	if reason == "" {
+		return true, ""
+	}
+
+	// The tunnel client may have been installed since, no restart needed
+	m.checkClient()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.notReady == "", m.notReady
+}
+
+// checkClient records whether the tunnel client binary is installed,
+// logging when that changes, and checks the version of one found
+func (m *Manager) checkClient() {
+	backend := m.tunnelBackend()
+	_, err := lookPath(backend.Bin())
+
+	m.mu.Lock()
+	wasReady := m.notReady == ""
+	if err != nil {
+		m.notReady = fmt.Sprintf("%s binary %s not found", backend.Name(), backend.Bin())
+	} else {
+		m.notReady = ""
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		if wasReady {
+			log.Warnf("%s not found, services cannot be exposed: %v", backend.Name(), err)
+		}
+		return
+	}
+	if !wasReady {
+		log.Infof("%s found, services can be exposed", backend.Name())
+	}
+
+	// Check the tunnel client speaks the flags we pass it
+	m.checkClientVersion()
+}
+
+// LastError returns the last failed operation of the manager, cleared
+// once one succeeds
+func (m *Manager) LastError() *lasterror.Error {
+	return m.errs.Last()
+}
+
+// requireReady wraps handler so it answers NOT_READY while wstun is missing
+func (m *Manager) requireReady(handler func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult) func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult {
+	return func(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+		if ready, reason := m.Ready(); !ready {
+			return rpc.ErrorCode("NOT_READY", fmt.Sprintf("Service manager not ready: %s", reason))
+		}
+		return handler(ctx, inv)
+	}
+}
+
+// RPCCount returns the number of RPC procedures registered by the manager
+func (m *Manager) RPCCount() int {
+	return int(m.rpcCount.Load())
+}
+
 // loadServicesConfig loads the services configuration from file
 func (m *Manager) loadServicesConfig() error {
-	configPath := filepath.Join(m.cfg.LightningRod.Home, "services.json")
-
-	data, err := os.ReadFile(configPath)
+	data, err := m.store.Load(servicesStateName)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, state.ErrNotFound) {
 			// Create empty config
 			return m.saveServicesConfig()
 		}
@@ -155,40 +553,161 @@ func (m *Manager) loadServicesConfig() error {
 		m.services = make(map[string]*ServiceInfo)
 	}
 
+	// Services saved before tunnel ids existed use the derived one
+	for name, svc := range m.services {
+		if svc.TunnelID == "" {
+			svc.TunnelID = defaultTunnelID(m.boardID, name)
+		}
+	}
+
 	return nil
 }
 
-// saveServicesConfig saves the services configuration to file
+// saveServicesConfig saves the services configuration to file (must be
+// called without mu held). Saves are serialized and each one snapshots the
+// current state, so the last write always reflects every earlier change.
 func (m *Manager) saveServicesConfig() error {
-	configPath := filepath.Join(m.cfg.LightningRod.Home, "services.json")
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
 
+	m.mu.RLock()
 	cfg := ServicesConfig{
 		Services: m.services,
 	}
-
 	data, err := json.MarshalIndent(cfg, "", "  ")
+	m.mu.RUnlock()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	return m.store.Save(servicesStateName, data)
+}
+
+// scheduleSave saves the services configuration services.save_delay
+// seconds from now, so every change made in the meantime is covered by a
+// single write (must be called without mu held)
+func (m *Manager) scheduleSave() {
+	delay := time.Duration(m.cfg.Services.SaveDelay) * time.Second
+	if delay <= 0 {
+		m.saveLogged()
+		return
+	}
+
+	m.timerMu.Lock()
+	defer m.timerMu.Unlock()
+
+	if m.saveTimer == nil {
+		m.saveTimer = time.AfterFunc(delay, func() {
+			m.timerMu.Lock()
+			m.saveTimer = nil
+			m.timerMu.Unlock()
+
+			m.saveLogged()
+		})
+	}
+}
+
+// cancelSave drops the pending coalesced save, if any
+func (m *Manager) cancelSave() {
+	m.timerMu.Lock()
+	defer m.timerMu.Unlock()
+
+	if m.saveTimer != nil {
+		m.saveTimer.Stop()
+		m.saveTimer = nil
+	}
+}
+
+// saveLogged saves the services configuration, logging any failure
+func (m *Manager) saveLogged() {
+	if err := m.saveServicesConfig(); err != nil {
+		m.errs.Record("save services config", err)
+		log.Warnf("Failed to save services config: %v", err)
+	}
+}
+
+// publishStatus announces a service status change on the event bus (must
+// be called without mu held)
+func publishStatus(e eventbus.ServiceStatus) {
+	eventbus.Publish(eventbus.Default, eventbus.ServiceStatusChanged, e)
+}
+
+// activeTunnels counts the running tunnels and the ones being started
+// (lock held)
+func (m *Manager) activeTunnels() int {
+	n := 0
+	for _, svc := range m.services {
+		if svc.Status == "running" {
+			n++
+		}
+	}
+	for name := range m.pending {
+		if _, exists := m.services[name]; !exists {
+			n++
+		}
+	}
+	return n
+}
+
+// reserve marks name as having an operation in progress, claiming
+// tunnelID if not empty, failing if another one already is or Stop has
+// begun. Concurrent exposes of a name thus start a single tunnel. The
+// reservation is dropped with release (lock held).
+func (m *Manager) reserve(name, tunnelID string) error {
+	if m.stopping {
+		return ErrStopping
+	}
+	return m.claim(name, tunnelID)
+}
+
+// claim is reserve without the Stop check, for Stop itself (lock held)
+func (m *Manager) claim(name, tunnelID string) error {
+	if _, busy := m.pending[name]; busy {
+		return fmt.Errorf("service %s has an operation in progress", name)
+	}
+	m.pending[name] = tunnelID
+	m.ops.Add(1)
+	return nil
+}
+
+// release drops the reservation of name (lock held)
+func (m *Manager) release(name string) {
+	if _, busy := m.pending[name]; !busy {
+		return
+	}
+	delete(m.pending, name)
+	m.ops.Done()
 }
 
 // registerRPCs registers service-related RPC procedures
 func (m *Manager) registerRPCs() error {
 	procedures := map[string]func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult{
-		fmt.Sprintf("iotronic.%s.%s.ExposeService", m.board.SessionID, m.board.UUID):   m.handleExposeService,
-		fmt.Sprintf("iotronic.%s.%s.UnexposeService", m.board.SessionID, m.board.UUID): m.handleUnexposeService,
-		fmt.Sprintf("iotronic.%s.%s.ServicesList", m.board.SessionID, m.board.UUID):    m.handleServicesList,
+		m.wampClient.Procedure("ExposeService"):      m.requireReady(m.handleExposeService),
+		m.wampClient.Procedure("UnexposeService"):    m.handleUnexposeService,
+		m.wampClient.Procedure("ServicesList"):       m.handleServicesList,
+		m.wampClient.Procedure("ServiceHealth"):      m.handleServiceHealth,
+		m.wampClient.Procedure("TunnelInfo"):         m.handleTunnelInfo,
+		m.wampClient.Procedure("ServiceManagerInfo"): m.handleServiceManagerInfo,
+	}
+
+	// Procedures rejected in maintenance mode
+	mutating := map[string]bool{
+		m.wampClient.Procedure("ExposeService"):   true,
+		m.wampClient.Procedure("UnexposeService"): true,
 	}
 
 	for proc, handler := range procedures {
-		if err := m.wampClient.Register(proc, handler); err != nil {
+		opts := []wamp.RegisterOption{wamp.WithModule(m.Name()), wamp.WithConcurrencyLimit(m.cfg.RPC.ServiceConcurrency)}
+		if mutating[proc] {
+			opts = append(opts, wamp.Mutating())
+		}
+		if err := m.wampClient.Register(proc, handler, opts...); err != nil {
 			return fmt.Errorf("failed to register %s: %w", proc, err)
 		}
 		log.Infof("Registered RPC: %s", proc)
 	}
 
+	m.rpcCount.Store(int32(len(procedures)))
 	return nil
 }
 
@@ -197,49 +716,59 @@ func (m *Manager) handleExposeService(ctx context.Context, inv *nexuswamp.Invoca
 	log.Info("RPC ExposeService called")
 
 	if len(inv.Arguments) < 2 {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Missing arguments: service_name and local_port required",
-			}},
-		}
+		return rpc.Error("Missing arguments: service_name and local_port required")
 	}
 
 	serviceName, ok := inv.Arguments[0].(string)
 	if !ok {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Invalid service_name type",
-			}},
-		}
+		return rpc.Error("Invalid service_name type")
 	}
 
 	localPort, ok := inv.Arguments[1].(float64)
 	if !ok {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Invalid local_port type",
-			}},
+		return rpc.Error("Invalid local_port type")
+	}
+	if err := validateLocalPort(localPort); err != nil {
+		return rpc.Error(err.Error())
+	}
+
+	// Optional target host, as third argument or kwarg
+	targetHost := defaultTargetHost
+	if len(inv.Arguments) > 2 {
+		if h, ok := inv.Arguments[2].(string); ok && h != "" {
+			targetHost = h
 		}
 	}
+	if h, ok := inv.ArgumentsKw["target_host"].(string); ok && h != "" {
+		targetHost = h
+	}
+	if err := validateTargetHost(targetHost); err != nil {
+		return rpc.Error(err.Error())
+	}
+
+	// Optional environment for the wstun child
+	env, err := parseEnv(inv.ArgumentsKw["env"])
+	if err != nil {
+		return rpc.Error(err.Error())
+	}
 
-	if err := m.exposeService(serviceName, int(localPort)); err != nil {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": fmt.Sprintf("Failed to expose service: %v", err),
-			}},
+	// Optional tunnel identifier, derived from the board and service
+	// names by default
+	tunnelID, _ := inv.ArgumentsKw["tunnel_id"].(string)
+	if tunnelID != "" {
+		if err := validateTunnelID(tunnelID); err != nil {
+			return rpc.Error(err.Error())
 		}
 	}
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": fmt.Sprintf("Service %s exposed on port %d", serviceName, int(localPort)),
-		}},
+	if err := m.errs.Observe("expose "+serviceName, m.exposeService(serviceName, int(localPort), targetHost, tunnelID, env)); err != nil {
+		if errors.Is(err, ErrTunnelLimit) {
+			return rpc.ErrorCode("LIMIT_REACHED", fmt.Sprintf("Failed to expose service: %v", err))
+		}
+		return rpc.Error(fmt.Sprintf("Failed to expose service: %v", err))
 	}
+
+	return rpc.Success(fmt.Sprintf("Service %s exposed on %s:%d", serviceName, targetHost, int(localPort)), nil)
 }
 
 // handleUnexposeService handles the UnexposeService RPC
@@ -247,141 +776,178 @@ func (m *Manager) handleUnexposeService(ctx context.Context, inv *nexuswamp.Invo
 	log.Info("RPC UnexposeService called")
 
 	if len(inv.Arguments) < 1 {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Missing argument: service_name required",
-			}},
-		}
+		return rpc.Error("Missing argument: service_name required")
 	}
 
 	serviceName, ok := inv.Arguments[0].(string)
 	if !ok {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Invalid service_name type",
-			}},
-		}
+		return rpc.Error("Invalid service_name type")
 	}
 
-	if err := m.unexposeService(serviceName); err != nil {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": fmt.Sprintf("Failed to unexpose service: %v", err),
-			}},
-		}
+	if err := m.errs.Observe("unexpose "+serviceName, m.unexposeService(serviceName)); err != nil {
+		return rpc.Error(fmt.Sprintf("Failed to unexpose service: %v", err))
 	}
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": fmt.Sprintf("Service %s unexposed", serviceName),
-		}},
-	}
+	return rpc.Success(fmt.Sprintf("Service %s unexposed", serviceName), nil)
 }
 
 // handleServicesList handles the ServicesList RPC
 func (m *Manager) handleServicesList(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
 	log.Info("RPC ServicesList called")
 
+	return rpc.List("Services list retrieved", "services", m.listServices(time.Now()))
+}
+
+// listServices describes every service, checking that the tunnel of each
+// running one is still alive; the recorded state is left for the
+// reconciler to update
+func (m *Manager) listServices(now time.Time) []map[string]any {
+	checkedAt := now.UTC().Format(time.RFC3339)
+
 	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	servicesList := make([]map[string]any, 0, len(m.services))
 	for _, svc := range m.services {
 		servicesList = append(servicesList, map[string]any{
 			"name":       svc.Name,
 			"local_port": svc.LocalPort,
 			"public_url": svc.PublicURL,
-			"status":     svc.Status,
+			"status":     liveStatus(svc),
+			"checked_at": checkedAt,
 		})
 	}
-	m.mu.RUnlock()
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":   "SUCCESS",
-			"message":  "Services list retrieved",
-			"services": servicesList,
-		}},
-	}
+	return servicesList
 }
 
 // exposeService exposes a service via wstun
-func (m *Manager) exposeService(name string, localPort int) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Manager) exposeService(name string, localPort int, targetHost, tunnelID string, env map[string]string) error {
+	if tunnelID == "" {
+		tunnelID = defaultTunnelID(m.boardID, name)
+	}
 
+	m.mu.Lock()
 	// Check if service already exists
 	if _, exists := m.services[name]; exists {
+		m.mu.Unlock()
 		return fmt.Errorf("service %s already exposed", name)
 	}
-
-	// Start wstun tunnel
-	cmd := exec.Command(
-		m.cfg.Services.WstunBin,
-		"client",
-		"-s", m.wstunURL,
-		"-t", fmt.Sprintf("127.0.0.1:%d", localPort),
-	)
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start wstun: %w", err)
+	if owner, used := m.tunnelIDOwner(tunnelID); used {
+		m.mu.Unlock()
+		return fmt.Errorf("tunnel id %s already used by service %s", tunnelID, owner)
+	}
+	if limit := m.cfg.Services.MaxTunnels; limit > 0 {
+		if active := m.activeTunnels(); active >= limit {
+			m.mu.Unlock()
+			return fmt.Errorf("%w: %d of %d running", ErrTunnelLimit, active, limit)
+		}
 	}
+	if err := m.reserve(name, tunnelID); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.mu.Unlock()
 
-	publicURL := fmt.Sprintf("%s/%s", m.wstunURL, name)
+	// The reservation is dropped whether the tunnel started or not
+	defer func() {
+		m.mu.Lock()
+		m.release(name)
+		m.mu.Unlock()
+	}()
 
-	// Store service info
-	m.services[name] = &ServiceInfo{
+	svc := &ServiceInfo{
 		Name:      name,
 		LocalPort: localPort,
-		PublicURL: publicURL,
-		PID:       cmd.Process.Pid,
-		Status:    "running",
+		TunnelID:  tunnelID,
+		env:       env,
+	}
+	if targetHost != defaultTargetHost {
+		svc.TargetHost = targetHost
 	}
 
-	// Save configuration
-	if err := m.saveServicesConfig(); err != nil {
-		log.Warnf("Failed to save services config: %v", err)
+	// Start the tunnel client
+	tunnel := m.newTunnel(svc)
+	if err := tunnel.Start(env); err != nil {
+		return fmt.Errorf("failed to start %s: %w", m.tunnelBackend().Name(), err)
 	}
 
-	log.Infof("Service %s exposed on port %d (PID: %d)", name, localPort, cmd.Process.Pid)
+	svc.PublicURL = m.tunnelBackend().PublicURL(svc)
+	svc.PID = tunnel.PID()
+	svc.Status = "running"
+	svc.tunnel = tunnel
+
+	// Store service info
+	m.mu.Lock()
+	m.services[name] = svc
+	m.mu.Unlock()
+
+	publishStatus(eventbus.ServiceStatus{Name: name, Status: "running", PID: svc.PID})
+
+	// Save configuration
+	m.scheduleSave()
+
+	log.Infof("Service %s exposed on %s (PID: %d)", name, svc.target(), svc.PID)
 
 	return nil
 }
 
+// stopTunnel terminates the tunnel client of svc, killing it after grace,
+// unless its PID now belongs to another process
+func (m *Manager) stopTunnel(svc *ServiceInfo, grace time.Duration) {
+	switch {
+	case svc.tunnel != nil:
+		if err := svc.tunnel.Stop(grace); err != nil {
+			log.Warnf("Failed to stop tunnel of service %s: %v", svc.Name, err)
+		}
+	case m.ownsProcess(svc):
+		if err := terminateProcess(svc.PID, nil, grace); err != nil {
+			log.Warnf("Failed to terminate process %d: %v", svc.PID, err)
+		}
+	case svc.PID > 0:
+		log.Warnf("Process %d is no longer the tunnel client of service %s, not signalling it", svc.PID, svc.Name)
+	}
+}
+
 // unexposeService stops and removes a service tunnel
 func (m *Manager) unexposeService(name string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	return m.stopService(name)
+	return m.unexpose(name, false)
 }
 
-// stopService stops a running service (must be called with lock held)
-func (m *Manager) stopService(name string) error {
+// unexpose stops and removes a service tunnel; shutdown is set by Stop,
+// which may still reserve services
+func (m *Manager) unexpose(name string, shutdown bool) error {
+	m.mu.Lock()
 	svc, exists := m.services[name]
 	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("service %s not found", name)
 	}
-
-	// Kill the wstun process
-	if svc.PID > 0 {
-		process, err := os.FindProcess(svc.PID)
-		if err == nil {
-			if err := process.Kill(); err != nil {
-				log.Warnf("Failed to kill process %d: %v", svc.PID, err)
-			}
-		}
+	reserve := m.reserve
+	if shutdown {
+		reserve = m.claim
+	}
+	if err := reserve(name, ""); err != nil {
+		m.mu.Unlock()
+		return err
 	}
+	svc.Status = "stopping"
+	m.mu.Unlock()
+
+	publishStatus(eventbus.ServiceStatus{Name: name, Status: "stopping", PID: svc.PID})
+
+	m.stopTunnel(svc, time.Duration(m.cfg.Services.StopGracePeriod)*time.Second)
 
 	// Remove from services map
+	m.mu.Lock()
 	delete(m.services, name)
+	m.release(name)
+	m.mu.Unlock()
+
+	publishStatus(eventbus.ServiceStatus{Name: name, Status: "stopped"})
 
 	// Save configuration
-	if err := m.saveServicesConfig(); err != nil {
-		log.Warnf("Failed to save services config: %v", err)
-	}
+	m.scheduleSave()
 
 	log.Infof("Service %s unexposed", name)
 
@@ -0,0 +1,336 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/state"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestReadyRechecksClient(t *testing.T) {
+	m := newExposeTestManager(t)
+	wstun := filepath.Join(t.TempDir(), "wstun")
+	if err := os.WriteFile(wstun, []byte("#!/bin/sh\necho wstun 1.2.3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m.backend.(*wstunBackend).bin = wstun
+
+	installed := false
+	orig := lookPath
+	lookPath = func(file string) (string, error) {
+		if !installed {
+			return "", errors.New("executable file not found in $PATH")
+		}
+		return file, nil
+	}
+	t.Cleanup(func() { lookPath = orig })
+
+	m.checkClient()
+	handler := m.requireReady(func(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+		return rpc.Success("ok", nil)
+	})
+	reply := handler(context.Background(), &nexuswamp.Invocation{}).Args[0].(map[string]any)
+	if reply["code"] != "NOT_READY" {
+		t.Fatalf("handler = %v without wstun, want NOT_READY", reply)
+	}
+	if ready, _ := m.Ready(); ready {
+		t.Fatal("Ready() = true without wstun")
+	}
+
+	// Installing wstun makes the manager ready on the next check, without
+	// a restart, and its version is detected then
+	installed = true
+	if ready, reason := m.Ready(); !ready {
+		t.Fatalf("Ready() = false, %q after wstun was installed", reason)
+	}
+	reply = handler(context.Background(), &nexuswamp.Invocation{}).Args[0].(map[string]any)
+	if reply["result"] != rpc.ResultSuccess {
+		t.Errorf("handler = %v after wstun was installed, want pass-through", reply)
+	}
+	if m.clientVersion != "1.2.3" {
+		t.Errorf("client version = %q, want 1.2.3", m.clientVersion)
+	}
+}
+
+func TestWstunEndpointScheme(t *testing.T) {
+	tests := []struct {
+		wampURL  string
+		scheme   string
+		endpoint string
+		wantErr  bool
+	}{
+		{"wss://router.test:8181/", "", "wss://router.test:8080", false},
+		{"ws://router.test:8181/", "", "ws://router.test:8080", false},
+		{"wss://router.test:8181/", "ws", "ws://router.test:8080", false},
+		{"ws://router.test:8181/", "wss", "wss://router.test:8080", false},
+		{"wss://router.test:8181/", "wss", "wss://router.test:8080", false},
+		{"wss://router.test:8181/", "https", "", true},
+		{"wss://router.test:8181/", "WSS", "", true},
+	}
+	for _, tt := range tests {
+		cfg := config.ServicesConfig{WstunScheme: tt.scheme}
+		host, endpoint, err := wstunEndpoint(cfg, tt.wampURL)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s with wstun_scheme %q: error = %v, want error %v", tt.wampURL, tt.scheme, err, tt.wantErr)
+			continue
+		}
+		if endpoint != tt.endpoint || (!tt.wantErr && host != "router.test") {
+			t.Errorf("%s with wstun_scheme %q = %q %q, want router.test %q", tt.wampURL, tt.scheme, host, endpoint, tt.endpoint)
+		}
+	}
+}
+
+func TestWstunEndpointHosts(t *testing.T) {
+	tests := []struct {
+		wampURL  string
+		host     string
+		endpoint string
+	}{
+		{"wss://192.168.1.5:8181/", "192.168.1.5", "wss://192.168.1.5:8080"},
+		{"wss://[2001:db8::1]:8181/", "2001:db8::1", "wss://[2001:db8::1]:8080"},
+		{"ws://[::1]/", "::1", "ws://[::1]:8080"},
+		{"wss://router.example.com:8181/", "router.example.com", "wss://router.example.com:8080"},
+		{"wss://router.example.com/", "router.example.com", "wss://router.example.com:8080"},
+	}
+	for _, tt := range tests {
+		host, endpoint, err := wstunEndpoint(config.ServicesConfig{}, tt.wampURL)
+		if err != nil {
+			t.Errorf("%s: %v", tt.wampURL, err)
+			continue
+		}
+		if host != tt.host || endpoint != tt.endpoint {
+			t.Errorf("%s = %q %q, want %q %q", tt.wampURL, host, endpoint, tt.host, tt.endpoint)
+		}
+	}
+}
+
+func TestNewManagerWstunScheme(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	cfg.Board.SettingsFile = filepath.Join(cfg.LightningRod.Home, "settings.json")
+	cfg.Board.SettingsReadAttempts = 1
+	cfg.Services.WstunScheme = "ws"
+	if err := config.SaveBoardSettings(cfg.Board.SettingsFile, migrateSettings(t, "wss://router.test:8181/")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := board.New(cfg)
+	if err != nil {
+		t.Fatalf("board.New: %v", err)
+	}
+
+	m, err := NewManager(cfg, b, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if m.wstunURL != "ws://router.test:8080" {
+		t.Errorf("wstun URL = %q, want ws://router.test:8080 from wstun_scheme", m.wstunURL)
+	}
+
+	cfg.Services.WstunScheme = "http"
+	if _, err := NewManager(cfg, b, nil); err == nil || !strings.Contains(err.Error(), "services.wstun_scheme") {
+		t.Errorf("NewManager with wstun_scheme http = %v, want an invalid wstun_scheme", err)
+	}
+}
+
+func TestNewManagerPublicBaseURL(t *testing.T) {
+	tests := []struct {
+		base    string
+		want    string
+		wantErr bool
+	}{
+		{"", "wss://router.test:8080/web-tunnel", false},
+		{"https://tunnels.example.com/", "https://tunnels.example.com/web-tunnel", false},
+		{"https://lb.example.com:8443/s4t", "https://lb.example.com:8443/s4t/web-tunnel", false},
+		{"tunnels.example.com", "", true},
+		{"https://", "", true},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.LightningRod.Home = t.TempDir()
+		cfg.Board.SettingsFile = filepath.Join(cfg.LightningRod.Home, "settings.json")
+		cfg.Board.SettingsReadAttempts = 1
+		cfg.Services.PublicBaseURL = tt.base
+		if err := config.SaveBoardSettings(cfg.Board.SettingsFile, migrateSettings(t, "wss://router.test:8181/")); err != nil {
+			t.Fatal(err)
+		}
+		b, err := board.New(cfg)
+		if err != nil {
+			t.Fatalf("board.New: %v", err)
+		}
+
+		m, err := NewManager(cfg, b, nil)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "services.public_base_url") {
+				t.Errorf("NewManager with public_base_url %q = %v, want an invalid public_base_url", tt.base, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("NewManager with public_base_url %q: %v", tt.base, err)
+		}
+		if got := m.backend.PublicURL(&ServiceInfo{Name: "web", TunnelID: "web-tunnel"}); got != tt.want {
+			t.Errorf("public URL with public_base_url %q = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestValidateExtraArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"--reconnect", "--ping-interval=30"}, false},
+		{[]string{"--timeout", "10"}, false},
+		{[]string{""}, true},
+		{[]string{"  "}, true},
+		{[]string{"--header=a\nb"}, true},
+		{[]string{"-s", "ws://elsewhere"}, true},
+		{[]string{"-t", "10.0.0.1:22"}, true},
+		{[]string{wstunIDFlag, "other"}, true},
+		{[]string{"--id=other"}, true},
+		{[]string{"-sws://elsewhere"}, true},
+		{[]string{"-s=ws://elsewhere"}, true},
+		{[]string{"-t=10.0.0.1:22"}, true},
+		{[]string{"-t10.0.0.1:22"}, true},
+		{[]string{"--server", "ws://elsewhere"}, true},
+		{[]string{"--tunnel=10.0.0.1:22"}, true},
+		{[]string{"--identity=x"}, false},
+		{[]string{"--idle-timeout=30"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateExtraArgs(tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("validateExtraArgs(%q) = %v, want error %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+// countingStore is a memory store counting its saves
+type countingStore struct {
+	*state.MemoryStore
+	saves atomic.Int32
+}
+
+func (s *countingStore) Save(name string, data []byte) error {
+	s.saves.Add(1)
+	return s.MemoryStore.Save(name, data)
+}
+
+// savedServices returns the names of the services saved in s
+func savedServices(t *testing.T, s state.Store) []string {
+	t.Helper()
+	data, err := s.Load(servicesStateName)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var saved ServicesConfig
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(saved.Services))
+	for name := range saved.Services {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestSaveCoalesced(t *testing.T) {
+	m := newExposeTestManager(t)
+	store := &countingStore{MemoryStore: state.NewMemoryStore()}
+	m.store = store
+	m.cfg.Services.SaveDelay = 1
+
+	for _, name := range []string{"ssh", "web", "mqtt"} {
+		if err := m.exposeService(name, 22, defaultTargetHost, "", nil); err != nil {
+			t.Fatalf("expose %s: %v", name, err)
+		}
+	}
+	if err := m.unexposeService("mqtt"); err != nil {
+		t.Fatalf("unexpose: %v", err)
+	}
+	if n := store.saves.Load(); n != 0 {
+		t.Errorf("%d saves within the save delay, want none yet", n)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.saves.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := store.saves.Load(); n != 1 {
+		t.Errorf("%d saves after the save delay, want 1", n)
+	}
+	if got := savedServices(t, store); !slices.Equal(got, []string{"ssh", "web"}) {
+		t.Errorf("saved services = %v, want [ssh web]", got)
+	}
+}
+
+func TestSaveImmediateWithoutDelay(t *testing.T) {
+	m := newExposeTestManager(t)
+	store := &countingStore{MemoryStore: state.NewMemoryStore()}
+	m.store = store
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose: %v", err)
+	}
+	if n := store.saves.Load(); n == 0 {
+		t.Error("no save with services.save_delay 0")
+	}
+}
+
+func TestStopFlushesPendingSave(t *testing.T) {
+	m := newExposeTestManager(t)
+	store := &countingStore{MemoryStore: state.NewMemoryStore()}
+	m.store = store
+	m.cfg.Services.SaveDelay = 60
+
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatalf("expose: %v", err)
+	}
+	if err := m.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := store.saves.Load(); n != 1 {
+		t.Errorf("%d saves on Stop, want 1", n)
+	}
+	// The final write reflects the services Stop tore down
+	if got := savedServices(t, store); len(got) != 0 {
+		t.Errorf("saved services = %v after Stop, want none", got)
+	}
+
+	m.timerMu.Lock()
+	pending := m.saveTimer != nil
+	m.timerMu.Unlock()
+	if pending {
+		t.Error("save still scheduled after Stop")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// teardownTimeout bounds how long Stop spends announcing the services it
+// tore down, replaceable for testing
+var teardownTimeout = 2 * time.Second
+
+// servicesTopic returns the topic service events are published to
+func (m *Manager) servicesTopic() string {
+	return fmt.Sprintf("iotronic.board.%s.services", m.boardID)
+}
+
+// announceTeardown tells the cloud, best-effort, that the named services
+// were unexposed by a shutdown, so it does not keep showing them active.
+// Nothing is sent while WAMP is down, and publishing is abandoned after
+// teardownTimeout.
+func (m *Manager) announceTeardown(names []string) {
+	if len(names) == 0 || m.connected == nil || !m.connected() {
+		return
+	}
+
+	topic := m.servicesTopic()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, name := range names {
+			event := map[string]any{
+				"board":     m.boardID,
+				"service":   name,
+				"event":     "unexposed",
+				"reason":    "shutdown",
+				"timestamp": time.Now().Format("2006-01-02T15:04:05.000000"),
+			}
+			if err := m.publish(topic, []any{event}, nil); err != nil {
+				log.Warnf("Failed to announce the teardown of service %s: %v", name, err)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(teardownTimeout):
+		log.Warnf("Gave up announcing the teardown of %d services after %s", len(names), teardownTimeout)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCloud records what the manager publishes
+type fakeCloud struct {
+	mu     sync.Mutex
+	up     bool
+	block  chan struct{}
+	topics []string
+	events []map[string]any
+}
+
+func (c *fakeCloud) connected() bool { return c.up }
+
+func (c *fakeCloud) publish(topic string, args []any, kwargs map[string]any) error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.topics = append(c.topics, topic)
+	c.events = append(c.events, args[0].(map[string]any))
+	return nil
+}
+
+func TestStopAnnouncesTeardown(t *testing.T) {
+	tests := []struct {
+		name string
+		up   bool
+		want []string
+	}{
+		{"connected", true, []string{"ssh", "web"}},
+		{"disconnected", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newExposeTestManager(t)
+			cloud := &fakeCloud{up: tt.up}
+			m.publish, m.connected = cloud.publish, cloud.connected
+			for i, name := range []string{"ssh", "web"} {
+				if err := m.exposeService(name, 8000+i, defaultTargetHost, "", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := m.Stop(); err != nil {
+				t.Fatalf("Stop() = %v", err)
+			}
+
+			var got []string
+			for i, e := range cloud.events {
+				if cloud.topics[i] != "iotronic.board.b1.services" || e["event"] != "unexposed" || e["board"] != "b1" {
+					t.Errorf("event %v on %s", e, cloud.topics[i])
+				}
+				got = append(got, e["service"].(string))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("announced %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopTeardownBounded(t *testing.T) {
+	orig := teardownTimeout
+	teardownTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { teardownTimeout = orig })
+
+	m := newExposeTestManager(t)
+	cloud := &fakeCloud{up: true, block: make(chan struct{})}
+	defer close(cloud.block)
+	m.publish, m.connected = cloud.publish, cloud.connected
+	if err := m.exposeService("ssh", 22, defaultTargetHost, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.Stop() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Stop() = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() blocked on a stuck publish")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	log "github.com/sirupsen/logrus"
+)
+
+// Tunnel backends selectable with services.tunnel_backend
+const (
+	BackendWstun  = "wstun"
+	BackendChisel = "chisel"
+)
+
+// Backend is a tunnel client implementation: how its processes are
+// launched and how they are recognised among the running processes
+type Backend interface {
+	// Name returns the services.tunnel_backend value of the backend
+	Name() string
+	// Bin returns the path of the client binary
+	Bin() string
+	// ServerURL returns the tunnel server the clients connect to
+	ServerURL() string
+	// Args returns the client arguments tunneling svc
+	Args(svc *ServiceInfo) []string
+	// PublicURL returns where svc is reachable once tunneled
+	PublicURL(svc *ServiceInfo) string
+	// Match reports whether args is a client launched by this backend,
+	// returning the address it forwards to
+	Match(args []string) (target string, ok bool)
+}
+
+// newBackend returns the backend selected by the configuration; server is
+// the tunnel server endpoint derived from the WAMP URL
+func newBackend(cfg config.ServicesConfig, server, publicBase string) (Backend, error) {
+	switch cfg.TunnelBackend {
+	case "", BackendWstun:
+		if err := validateExtraArgs(cfg.WstunExtraArgs); err != nil {
+			return nil, fmt.Errorf("invalid services.wstun_extra_args: %w", err)
+		}
+		return &wstunBackend{bin: cfg.WstunBin, server: server, publicBase: publicBase, extraArgs: cfg.WstunExtraArgs}, nil
+	case BackendChisel:
+		return newChiselBackend(cfg.ChiselBin, server)
+	default:
+		return nil, fmt.Errorf("invalid services.tunnel_backend %q (expected %s or %s)", cfg.TunnelBackend, BackendWstun, BackendChisel)
+	}
+}
+
+// clientArgs returns the arguments following "client" when args runs bin;
+// bin is often a script, so it may be argv[0] or argv[1]
+func clientArgs(args []string, bin string) ([]string, bool) {
+	for i := 0; i < len(args) && i < 2; i++ {
+		if args[i] != bin {
+			continue
+		}
+		if i+1 >= len(args) || args[i+1] != "client" {
+			return nil, false
+		}
+		return args[i+2:], true
+	}
+	return nil, false
+}
+
+// Tunnel is the client process of one service tunnel
+type Tunnel interface {
+	// Start launches the client with env added to the agent environment
+	Start(env map[string]string) error
+	// Stop terminates the client, killing it after grace
+	Stop(grace time.Duration) error
+	// Status returns running or dead
+	Status() string
+	// PID returns the client process ID, 0 before Start
+	PID() int
+}
+
+// processTunnel runs a backend client as a child of the agent
+type processTunnel struct {
+	cmd    *exec.Cmd
+	limits proclimit.Limits
+
+	// openLog opens the file the client output is appended to
+	openLog func() (*os.File, error)
+
+	// done is closed once the child has been reaped
+	done chan struct{}
+}
+
+// newTunnel returns the not yet started tunnel of svc
+func (m *Manager) newTunnel(svc *ServiceInfo) Tunnel {
+	if m.tunnelFactory != nil {
+		return m.tunnelFactory(svc)
+	}
+	backend := m.tunnelBackend()
+	return &processTunnel{
+		cmd:     exec.Command(backend.Bin(), backend.Args(svc)...),
+		limits:  m.cfg.ChildLimits(),
+		openLog: func() (*os.File, error) { return m.openServiceLog(svc.Name) },
+	}
+}
+
+func (t *processTunnel) Start(env map[string]string) error {
+	log.Infof("Running %s", strings.Join(t.cmd.Args, " "))
+	if len(env) > 0 {
+		t.cmd.Env = mergeEnv(os.Environ(), env)
+		// Values may hold credentials, only the names are logged
+		log.Infof("Passing environment %v to %s", envKeys(env), t.cmd.Path)
+	}
+
+	if t.openLog != nil {
+		f, err := t.openLog()
+		if err != nil {
+			log.Warnf("Failed to open the log of %s, its output is discarded: %v", t.cmd.Path, err)
+		} else {
+			// The child keeps its own descriptor
+			defer f.Close()
+			t.cmd.Stdout = f
+			t.cmd.Stderr = f
+		}
+	}
+
+	if err := proclimit.Apply(t.cmd, t.limits); err != nil {
+		return err
+	}
+	if err := t.cmd.Start(); err != nil {
+		return err
+	}
+
+	// Reap the child when it exits so its PID is never mistaken for ours
+	t.done = make(chan struct{})
+	go func() {
+		t.cmd.Wait()
+		close(t.done)
+	}()
+
+	return nil
+}
+
+func (t *processTunnel) Stop(grace time.Duration) error {
+	if t.done == nil {
+		return nil
+	}
+	return terminateProcess(t.PID(), t.done, grace)
+}
+
+func (t *processTunnel) Status() string {
+	if t.done == nil {
+		return "dead"
+	}
+	select {
+	case <-t.done:
+		return "dead"
+	default:
+		return "running"
+	}
+}
+
+func (t *processTunnel) PID() int {
+	if t.cmd.Process == nil {
+		return 0
+	}
+	return t.cmd.Process.Pid
+}
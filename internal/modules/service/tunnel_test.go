@@ -0,0 +1,146 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestNewBackend(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    string
+		wantErr bool
+	}{
+		{"", BackendWstun, false},
+		{"wstun", BackendWstun, false},
+		{"chisel", BackendChisel, false},
+		{"ssh", "", true},
+	}
+	for _, tt := range tests {
+		cfg := config.ServicesConfig{
+			TunnelBackend: tt.backend,
+			WstunBin:      "/usr/bin/wstun",
+			ChiselBin:     "/usr/bin/chisel",
+		}
+		b, err := newBackend(cfg, "wss://router.test:8080", "https://tunnels.test")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newBackend(%q) error = %v, wantErr %v", tt.backend, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if b.Name() != tt.want {
+			t.Errorf("newBackend(%q).Name() = %q, want %q", tt.backend, b.Name(), tt.want)
+		}
+		if wantBin := "/usr/bin/" + tt.want; b.Bin() != wantBin {
+			t.Errorf("newBackend(%q).Bin() = %q, want %q", tt.backend, b.Bin(), wantBin)
+		}
+	}
+
+	// Extra arguments are validated for wstun only
+	cfg := config.ServicesConfig{WstunExtraArgs: []string{"-s", "ws://elsewhere"}}
+	if _, err := newBackend(cfg, "ws://router.test:8080", ""); err == nil {
+		t.Error("wstun accepted an overridden -s")
+	}
+}
+
+func TestWstunBackend(t *testing.T) {
+	b := &wstunBackend{
+		bin:        "wstun",
+		server:     "ws://router.test:8080",
+		publicBase: "https://router.test",
+		extraArgs:  []string{"--verbose"},
+	}
+	svc := &ServiceInfo{Name: "ssh", LocalPort: 22, TunnelID: "b1-ssh"}
+
+	got := strings.Join(b.Args(svc), " ")
+	want := "client -s ws://router.test:8080 -t 127.0.0.1:22 --id b1-ssh --verbose"
+	if got != want {
+		t.Errorf("Args = %q, want %q", got, want)
+	}
+	if got := b.PublicURL(svc); got != "https://router.test/b1-ssh" {
+		t.Errorf("PublicURL = %q", got)
+	}
+
+	// Reconcile recognises the children started this way, and only them
+	for _, argv0 := range [][]string{{"wstun"}, {"node", "wstun"}} {
+		args := append(argv0, b.Args(svc)...)
+		if target, ok := b.Match(args); !ok || target != svc.target() {
+			t.Errorf("Match(%v) = %q, %v", args, target, ok)
+		}
+	}
+	for _, args := range [][]string{
+		{"wstun", "client", "-s", "ws://other:8080", "-t", "127.0.0.1:22"},
+		{"wstun", "server", "-s", "ws://router.test:8080", "-t", "127.0.0.1:22"},
+		{"chisel", "client", "-s", "ws://router.test:8080", "-t", "127.0.0.1:22"},
+	} {
+		if _, ok := b.Match(args); ok {
+			t.Errorf("Match(%v) matched", args)
+		}
+	}
+}
+
+func TestChiselBackend(t *testing.T) {
+	tests := []struct {
+		endpoint   string
+		wantServer string
+	}{
+		{"ws://router.test:8080", "http://router.test:8080"},
+		{"wss://router.test:8080", "https://router.test:8080"},
+		{"wss://[2001:db8::1]:8080", "https://[2001:db8::1]:8080"},
+	}
+	for _, tt := range tests {
+		b, err := newChiselBackend("chisel", tt.endpoint)
+		if err != nil {
+			t.Fatalf("newChiselBackend(%q): %v", tt.endpoint, err)
+		}
+		if b.ServerURL() != tt.wantServer {
+			t.Errorf("ServerURL = %q, want %q", b.ServerURL(), tt.wantServer)
+		}
+	}
+
+	b, _ := newChiselBackend("chisel", "wss://router.test:8080")
+	svc := &ServiceInfo{Name: "web", LocalPort: 8081, TargetHost: "192.168.1.10", TunnelID: "b1-web"}
+
+	got := strings.Join(b.Args(svc), " ")
+	want := "client https://router.test:8080 R:8081:192.168.1.10:8081"
+	if got != want {
+		t.Errorf("Args = %q, want %q", got, want)
+	}
+	if got := b.PublicURL(svc); got != "tcp://router.test:8081" {
+		t.Errorf("PublicURL = %q", got)
+	}
+
+	args := append([]string{"chisel"}, b.Args(svc)...)
+	if target, ok := b.Match(args); !ok || target != svc.target() {
+		t.Errorf("Match(%v) = %q, %v", args, target, ok)
+	}
+	for _, args := range [][]string{
+		{"chisel", "client", "https://other:8080", "R:8081:192.168.1.10:8081"},
+		{"chisel", "client", "https://router.test:8080", "8081:192.168.1.10:8081"},
+		{"chisel", "client", "https://router.test:8080", "R:x:192.168.1.10:8081"},
+		{"chisel", "server", "https://router.test:8080", "R:8081:192.168.1.10:8081"},
+	} {
+		if _, ok := b.Match(args); ok {
+			t.Errorf("Match(%v) matched", args)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// wstunIDFlag passes the tunnel identifier the wstun server routes on
+const wstunIDFlag = "--id"
+
+// maxTunnelIDLen bounds tunnel identifiers, which end up in URLs
+const maxTunnelIDLen = 128
+
+var (
+	tunnelIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	tunnelIDUnsafe  = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// defaultTunnelID derives the tunnel identifier of a service from the
+// board UUID and the service name, so tunnels of different boards never
+// collide on the wstun server
+func defaultTunnelID(boardUUID, name string) string {
+	safe := strings.Trim(tunnelIDUnsafe.ReplaceAllString(name, "-"), "-")
+	id := boardUUID + "-" + safe
+	if len(id) > maxTunnelIDLen {
+		id = id[:maxTunnelIDLen]
+	}
+	return id
+}
+
+// validateTunnelID checks a caller-provided tunnel identifier
+func validateTunnelID(id string) error {
+	if len(id) > maxTunnelIDLen || !tunnelIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid tunnel_id %q: 1-%d letters, digits, '_' or '-'", id, maxTunnelIDLen)
+	}
+	return nil
+}
+
+// tunnelIDOwner returns the service using or being exposed with tunnel
+// id, if any (lock held)
+func (m *Manager) tunnelIDOwner(id string) (string, bool) {
+	for name, svc := range m.services {
+		if svc.TunnelID == id {
+			return name, true
+		}
+	}
+	for name, pendingID := range m.pending {
+		if pendingID == id {
+			return name, true
+		}
+	}
+	return "", false
+}
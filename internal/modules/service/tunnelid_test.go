@@ -0,0 +1,80 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultTunnelID(t *testing.T) {
+	tests := []struct {
+		uuid, name, want string
+	}{
+		{"b1", "ssh", "b1-ssh"},
+		{"b1", "web ui/v2", "b1-web-ui-v2"},
+		{"b1", "..", "b1-"},
+	}
+	for _, tt := range tests {
+		got := defaultTunnelID(tt.uuid, tt.name)
+		if got != tt.want {
+			t.Errorf("defaultTunnelID(%q, %q) = %q, want %q", tt.uuid, tt.name, got, tt.want)
+		}
+		if got != defaultTunnelID(tt.uuid, tt.name) {
+			t.Errorf("defaultTunnelID(%q, %q) is not deterministic", tt.uuid, tt.name)
+		}
+	}
+
+	long := defaultTunnelID(strings.Repeat("a", 100), strings.Repeat("b", 100))
+	if len(long) != maxTunnelIDLen {
+		t.Errorf("len = %d, want %d", len(long), maxTunnelIDLen)
+	}
+	if err := validateTunnelID(defaultTunnelID("b1", "ssh")); err != nil {
+		t.Errorf("derived id rejected: %v", err)
+	}
+}
+
+func TestValidateTunnelID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"ssh-1", true},
+		{"A_b-9", true},
+		{"", false},
+		{"-ssh", false},
+		{"a/b", false},
+		{"a b", false},
+		{strings.Repeat("a", maxTunnelIDLen+1), false},
+	}
+	for _, tt := range tests {
+		if err := validateTunnelID(tt.id); (err == nil) != tt.valid {
+			t.Errorf("validateTunnelID(%q) = %v, want valid=%v", tt.id, err, tt.valid)
+		}
+	}
+}
+
+func TestTunnelIDOwner(t *testing.T) {
+	m := &Manager{services: map[string]*ServiceInfo{
+		"ssh": {Name: "ssh", TunnelID: "b1-ssh"},
+	}}
+	if owner, used := m.tunnelIDOwner("b1-ssh"); !used || owner != "ssh" {
+		t.Errorf("tunnelIDOwner = %q, %v", owner, used)
+	}
+	if _, used := m.tunnelIDOwner("b1-web"); used {
+		t.Error("unused id reported as used")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// minWstunVersion is the oldest wstun known to accept the client flags
+// used by exposeService
+const minWstunVersion = "1.0.0"
+
+// wstunVersionTimeout bounds each wstun version query
+const wstunVersionTimeout = 5 * time.Second
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// detectWstunVersion runs the wstun binary with --version, falling back to
+// -v, under limits and extracts the version number from its output
+func detectWstunVersion(bin string, limits proclimit.Limits) (string, error) {
+	var lastErr error
+	for _, flag := range []string{"--version", "-v"} {
+		ctx, cancel := context.WithTimeout(context.Background(), wstunVersionTimeout)
+		cmd := exec.CommandContext(ctx, bin, flag)
+		if err := proclimit.Apply(cmd, limits); err != nil {
+			cancel()
+			return "", err
+		}
+		output, err := cmd.CombinedOutput()
+		cancel()
+
+		if version := versionPattern.FindString(string(output)); version != "" {
+			return version, nil
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("no version in output %q", strings.TrimSpace(string(output)))
+		}
+	}
+
+	return "", lastErr
+}
+
+// compareVersions compares dotted numeric versions, returning -1, 0 or 1
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkClientVersion detects and records the tunnel client version,
+// warning when wstun is older than minWstunVersion
+func (m *Manager) checkClientVersion() {
+	version, err := detectWstunVersion(m.tunnelBackend().Bin(), m.cfg.ChildLimits())
+	if err != nil {
+		log.Warnf("Could not determine %s version: %v", m.tunnelBackend().Name(), err)
+		return
+	}
+
+	m.mu.Lock()
+	m.clientVersion = version
+	m.mu.Unlock()
+
+	log.Infof("%s version: %s", m.tunnelBackend().Name(), version)
+	if m.tunnelBackend().Name() == BackendWstun && compareVersions(version, minWstunVersion) < 0 {
+		log.Warnf("wstun %s is older than the minimum supported %s, tunnels may not work", version, minWstunVersion)
+	}
+}
+
+// handleTunnelInfo handles the TunnelInfo RPC; the wstun_* keys describe
+// the client of whichever backend is configured
+func (m *Manager) handleTunnelInfo(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC TunnelInfo called")
+
+	m.mu.RLock()
+	version := m.clientVersion
+	m.mu.RUnlock()
+
+	compatible := version != ""
+	if m.tunnelBackend().Name() == BackendWstun {
+		compatible = compatible && compareVersions(version, minWstunVersion) >= 0
+	}
+
+	return rpc.Success("Tunnel information", map[string]any{
+		"backend":           m.tunnelBackend().Name(),
+		"wstun_bin":         m.tunnelBackend().Bin(),
+		"wstun_url":         m.tunnelBackend().ServerURL(),
+		"wstun_version":     version,
+		"min_wstun_version": minWstunVersion,
+		"compatible":        compatible,
+	})
+}
+
+// Versions returns the detected tunnel client version keyed by backend
+// name, or nothing if it is not known yet
+func (m *Manager) Versions() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.clientVersion == "" {
+		return nil
+	}
+	return map[string]string{m.tunnelBackend().Name(): m.clientVersion}
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/proclimit"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// fakeWstun writes an executable script answering like a wstun binary
+func fakeWstun(t *testing.T, script string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "wstun")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func TestDetectWstunVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr bool
+	}{
+		{"--version", `echo "wstun v1.2.3"`, "1.2.3", false},
+		{"-v only", `[ "$1" = "-v" ] && echo 0.9.1 && exit 0; echo "unknown option $1" >&2; exit 1`, "0.9.1", false},
+		{"version on stderr", `echo "wstun 2.0" >&2`, "2.0", false},
+		{"no version", `echo usage: wstun client`, "", true},
+		{"failing", `exit 3`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := detectWstunVersion(fakeWstun(t, tt.script), proclimit.Limits{})
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: version = %q, %v, want %q (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.2.3", "1.0.0", 1},
+		{"0.9.9", "1.0.0", -1},
+		{"1.10.0", "1.9.0", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTunnelInfoVersion(t *testing.T) {
+	tests := []struct {
+		script     string
+		version    string
+		compatible bool
+	}{
+		{`echo "wstun 1.2.3"`, "1.2.3", true},
+		{`echo "wstun 0.9.0"`, "0.9.0", false},
+		{`exit 1`, "", false},
+	}
+	for _, tt := range tests {
+		m := newExposeTestManager(t)
+		m.backend.(*wstunBackend).bin = fakeWstun(t, tt.script)
+		m.checkClientVersion()
+
+		res := m.handleTunnelInfo(context.Background(), &nexuswamp.Invocation{})
+		data := res.Args[0].(map[string]any)["data"].(map[string]any)
+		if data["wstun_version"] != tt.version || data["compatible"] != tt.compatible {
+			t.Errorf("%s: TunnelInfo = %v, want version %q compatible %v", tt.script, data, tt.version, tt.compatible)
+		}
+		if versions := m.Versions(); versions[BackendWstun] != tt.version {
+			t.Errorf("%s: Versions() = %v", tt.script, versions)
+		}
+	}
+}
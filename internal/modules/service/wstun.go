@@ -0,0 +1,69 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package service
+
+import "fmt"
+
+// wstunBackend tunnels services through a wstun server, which routes
+// connections to the board by tunnel id
+type wstunBackend struct {
+	bin        string
+	server     string
+	publicBase string
+	extraArgs  []string
+}
+
+func (b *wstunBackend) Name() string      { return BackendWstun }
+func (b *wstunBackend) Bin() string       { return b.bin }
+func (b *wstunBackend) ServerURL() string { return b.server }
+
+func (b *wstunBackend) Args(svc *ServiceInfo) []string {
+	args := []string{
+		"client",
+		"-s", b.server,
+		"-t", svc.target(),
+		wstunIDFlag, svc.TunnelID,
+	}
+	return append(args, b.extraArgs...)
+}
+
+func (b *wstunBackend) PublicURL(svc *ServiceInfo) string {
+	return fmt.Sprintf("%s/%s", b.publicBase, svc.TunnelID)
+}
+
+// Match is deliberately strict so unrelated processes are never touched
+func (b *wstunBackend) Match(args []string) (string, bool) {
+	rest, ok := clientArgs(args, b.bin)
+	if !ok {
+		return "", false
+	}
+
+	var server, target string
+	for i := 0; i+1 < len(rest); i++ {
+		switch rest[i] {
+		case "-s":
+			server = rest[i+1]
+		case "-t":
+			target = rest[i+1]
+		}
+	}
+
+	if server != b.server || target == "" {
+		return "", false
+	}
+
+	return target, true
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuth holds the credentials protecting a webservice
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// validate checks that the credentials can be written to an htpasswd file
+func (a *BasicAuth) validate() error {
+	if a.Username == "" || a.Password == "" {
+		return fmt.Errorf("basic auth requires both username and password")
+	}
+	if strings.ContainsAny(a.Username, ":\r\n") {
+		return fmt.Errorf("basic auth username contains invalid characters")
+	}
+	return nil
+}
+
+// htpasswdPath returns the htpasswd file used by a webservice
+func (m *Manager) htpasswdPath(name string) string {
+	return filepath.Join(m.cfg.StateDir(), "htpasswd", fmt.Sprintf("lr_%s.htpasswd", name))
+}
+
+// htpasswdEntry renders a single bcrypt htpasswd line
+func htpasswdEntry(auth *BasicAuth) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(auth.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return fmt.Sprintf("%s:%s\n", auth.Username, hash), nil
+}
+
+// writeHtpasswd creates the htpasswd file for a webservice
+func (m *Manager) writeHtpasswd(name string, auth *BasicAuth) (string, error) {
+	if err := auth.validate(); err != nil {
+		return "", err
+	}
+
+	entry, err := htpasswdEntry(auth)
+	if err != nil {
+		return "", err
+	}
+
+	path := m.htpasswdPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return "", fmt.Errorf("failed to create htpasswd directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(entry), 0640); err != nil {
+		return "", fmt.Errorf("failed to write htpasswd file: %w", err)
+	}
+
+	return path, nil
+}
+
+// authDirectives renders the nginx basic-auth directives for a location
+func authDirectives(ws *WebServiceInfo) string {
+	if ws.authFile == "" {
+		return ""
+	}
+	return fmt.Sprintf("        auth_basic \"%s\";\n        auth_basic_user_file %s;\n", authRealm(ws.Name), ws.authFile)
+}
+
+// authRealm turns a webservice name into a realm that is safe inside a
+// quoted nginx string. nginx expands variables in auth_basic and has no
+// escape for '$', so anything outside a conservative set is replaced
+func authRealm(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-' || r == '_' || r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAuthRealm(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"web", "web"},
+		{"my-app_1.0", "my-app_1.0"},
+		{`x"; deny all; #`, "x___deny_all___"},
+		{"$remote_addr", "_remote_addr"},
+		{"a\nb", "a_b"},
+	}
+	for _, tt := range tests {
+		if got := authRealm(tt.name); got != tt.want {
+			t.Errorf("authRealm(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEnableRejectsUnsafeName(t *testing.T) {
+	m, nginx := newBatchTestManager(t)
+	m.cfg.LightningRod.Home = t.TempDir()
+	auth := &BasicAuth{Username: "u", Password: "p"}
+
+	for _, name := range []string{`web"; deny all; #`, "../etc", "a b", "$host", "{x}"} {
+		if _, err := m.enableWebService(context.Background(), name, 8080, 18080, auth, false); err == nil {
+			t.Errorf("enable %q succeeded", name)
+		}
+	}
+	if len(m.webservices) != 0 {
+		t.Errorf("webservices = %v, want none", m.webservices)
+	}
+	if len(nginx.calls) != 0 {
+		t.Errorf("nginx run for rejected names: %v", nginx.calls)
+	}
+	entries, _ := os.ReadDir(nginxConfDir)
+	if len(entries) != 0 {
+		t.Errorf("configs written for rejected names: %v", entries)
+	}
+}
+
+func TestAuthDirectivesQuoteRealm(t *testing.T) {
+	m, _ := newBatchTestManager(t)
+	m.cfg.LightningRod.Home = t.TempDir()
+
+	ws, err := m.enableWebService(context.Background(), "web", 8080, 18080, &BasicAuth{Username: "u", Password: "p"}, false)
+	if err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	conf, err := os.ReadFile(serviceConfPath("web"))
+	if err != nil {
+		t.Fatalf("read conf: %v", err)
+	}
+	if !strings.Contains(string(conf), `auth_basic "web";`) {
+		t.Errorf("conf missing realm:\n%s", conf)
+	}
+	if !strings.Contains(string(conf), "auth_basic_user_file "+ws.authFile+";") {
+		t.Errorf("conf missing user file:\n%s", conf)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// stagedOp is an enable or disable whose nginx config is on disk but not
+// yet loaded
+type stagedOp struct {
+	enable bool
+	ws     *WebServiceInfo
+}
+
+// isStaged reports whether an invocation should be staged, honouring the
+// "staged" kwarg over the configured default
+func (m *Manager) isStaged(inv *nexuswamp.Invocation) bool {
+	if staged, ok := inv.ArgumentsKw["staged"].(bool); ok {
+		return staged
+	}
+	return m.cfg.WebServices.Staged
+}
+
+// stagedVerb qualifies an RPC result message for staged changes
+func stagedVerb(verb string, staged bool) string {
+	if staged {
+		return verb + " (staged, pending CommitWebServices)"
+	}
+	return verb
+}
+
+// commitWebServices tests and reloads nginx once for all staged changes,
+// rolling every one of them back if the new config is rejected. It
+// returns the number of changes applied.
+func (m *Manager) commitWebServices(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ops := m.staged
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	if err := m.reloadNginx(ctx); err != nil {
+		m.rollbackStaged()
+		return 0, fmt.Errorf("staged changes rolled back: %w", err)
+	}
+	m.staged = nil
+
+	for _, op := range ops {
+		if op.enable {
+			op.ws.Status = "enabled"
+			continue
+		}
+
+		// Credentials of disabled webservices are no longer referenced
+		if op.ws.authFile != "" {
+			if err := os.Remove(op.ws.authFile); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to remove htpasswd file: %v", err)
+			}
+		}
+	}
+
+	log.Infof("Committed %d staged webservice changes", len(ops))
+	return len(ops), nil
+}
+
+// rollbackStaged undoes the staged changes in reverse order, restoring the
+// config files nginx last loaded (must be called with lock held)
+func (m *Manager) rollbackStaged() {
+	if len(m.staged) == 0 {
+		return
+	}
+
+	for i := len(m.staged) - 1; i >= 0; i-- {
+		op := m.staged[i]
+		ws := op.ws
+
+		if op.enable {
+			delete(m.webservices, ws.Name)
+			if ws.Path == "" {
+				if err := os.Remove(serviceConfPath(ws.Name)); err != nil && !os.IsNotExist(err) {
+					log.Warnf("Failed to remove nginx config: %v", err)
+				}
+			}
+			if ws.authFile != "" {
+				os.Remove(ws.authFile)
+			}
+			continue
+		}
+
+		m.webservices[ws.Name] = ws
+		if ws.Path == "" {
+			if err := os.WriteFile(serviceConfPath(ws.Name), []byte(portServerConf(ws)), 0644); err != nil {
+				log.Warnf("Failed to restore nginx config: %v", err)
+			}
+		}
+	}
+
+	if m.mode == ModePath {
+		if err := m.writeSharedConf(context.Background(), false); err != nil {
+			log.Warnf("Failed to restore shared nginx config: %v", err)
+		}
+	}
+
+	log.Warnf("Rolled back %d staged webservice changes", len(m.staged))
+	m.staged = nil
+}
+
+// handleCommitWebServices handles the CommitWebServices RPC
+func (m *Manager) handleCommitWebServices(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+	log.Info("RPC CommitWebServices called")
+
+	committed, err := m.commitWebServices(ctx)
+	if m.errs.Observe("commit", err) != nil {
+		return rpc.Error(fmt.Sprintf("Failed to commit webservices: %v", err))
+	}
+
+	return rpc.Success(fmt.Sprintf("%d webservice changes committed", committed), map[string]any{
+		"committed": committed,
+	})
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// fakeNginx records the nginx invocations and fails the config test when
+// reject is set
+type fakeNginx struct {
+	calls  []string
+	reject bool
+}
+
+// newBatchTestManager returns a port-mode manager writing its nginx
+// configs to a temporary directory and driving a fake nginx
+func newBatchTestManager(t *testing.T) (*Manager, *fakeNginx) {
+	t.Helper()
+
+	origDir, origRun := nginxConfDir, runNginx
+	nginxConfDir = t.TempDir()
+	nginx := &fakeNginx{}
+	runNginx = func(ctx context.Context, args ...string) ([]byte, error) {
+		nginx.calls = append(nginx.calls, strings.Join(args, " "))
+		if nginx.reject && args[0] == "-t" {
+			return []byte("unknown directive"), errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { nginxConfDir, runNginx = origDir, origRun })
+
+	cfg := &config.Config{}
+	cfg.WebServices.Mode = ModePort
+	m, err := NewManager(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m, nginx
+}
+
+func confExists(name string) bool {
+	_, err := os.Stat(serviceConfPath(name))
+	return err == nil
+}
+
+func TestCommitStaged(t *testing.T) {
+	m, nginx := newBatchTestManager(t)
+	ctx := context.Background()
+
+	for i, name := range []string{"web", "api"} {
+		if _, err := m.enableWebService(ctx, name, 8080+i, 18080+i, nil, true); err != nil {
+			t.Fatalf("staged enable %s: %v", name, err)
+		}
+	}
+	if len(nginx.calls) != 0 {
+		t.Fatalf("nginx run before commit: %v", nginx.calls)
+	}
+	if m.webservices["web"].Status != "staged" {
+		t.Errorf("status = %q before commit, want staged", m.webservices["web"].Status)
+	}
+
+	committed, err := m.commitWebServices(ctx)
+	if err != nil || committed != 2 {
+		t.Fatalf("commitWebServices() = %d, %v", committed, err)
+	}
+	if len(nginx.calls) != 2 {
+		t.Errorf("nginx calls = %v, want one test and one reload", nginx.calls)
+	}
+	for _, name := range []string{"web", "api"} {
+		if m.webservices[name].Status != "enabled" || !confExists(name) {
+			t.Errorf("%s not enabled after commit", name)
+		}
+	}
+
+	if committed, err := m.commitWebServices(ctx); err != nil || committed != 0 {
+		t.Errorf("empty commit = %d, %v", committed, err)
+	}
+}
+
+func TestCommitRejectedRollsBack(t *testing.T) {
+	m, nginx := newBatchTestManager(t)
+	ctx := context.Background()
+
+	if _, err := m.enableWebService(ctx, "old", 8080, 18080, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.enableWebService(ctx, "new", 8081, 18081, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.disableWebService(ctx, "old", true); err != nil {
+		t.Fatal(err)
+	}
+
+	nginx.reject = true
+	if _, err := m.commitWebServices(ctx); err == nil {
+		t.Fatal("commitWebServices() succeeded with a rejected config")
+	}
+
+	if _, ok := m.webservices["new"]; ok || confExists("new") {
+		t.Error("staged enable kept after a rejected commit")
+	}
+	if _, ok := m.webservices["old"]; !ok || !confExists("old") {
+		t.Error("staged disable not restored after a rejected commit")
+	}
+	if len(m.staged) != 0 {
+		t.Errorf("%d staged changes left", len(m.staged))
+	}
+}
+
+func TestUnstagedRejectedWhileStagedPending(t *testing.T) {
+	m, nginx := newBatchTestManager(t)
+	ctx := context.Background()
+
+	if _, err := m.enableWebService(ctx, "web", 8080, 18080, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	calls := len(nginx.calls)
+
+	if _, err := m.enableWebService(ctx, "api", 8081, 18081, nil, false); !errors.Is(err, ErrStagedPending) {
+		t.Errorf("unstaged enable = %v, want ErrStagedPending", err)
+	}
+	if err := m.disableWebService(ctx, "web", false); !errors.Is(err, ErrStagedPending) {
+		t.Errorf("unstaged disable = %v, want ErrStagedPending", err)
+	}
+	if len(nginx.calls) != calls || confExists("api") {
+		t.Error("rejected change touched nginx")
+	}
+
+	// The RPC answers with a dedicated code
+	res := m.handleEnableWebService(ctx, &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"api", float64(8081), float64(18081)},
+		ArgumentsKw: nexuswamp.Dict{"staged": false},
+	})
+	if reply := resultOf(t, res); reply["code"] != "STAGED_PENDING" {
+		t.Errorf("EnableWebService = %v, want STAGED_PENDING", reply)
+	}
+
+	// Staged changes are still accepted, and unstaged ones once committed
+	if _, err := m.enableWebService(ctx, "api", 8081, 18081, nil, true); err != nil {
+		t.Errorf("staged enable = %v", err)
+	}
+	if _, err := m.commitWebServices(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.disableWebService(ctx, "web", false); err != nil {
+		t.Errorf("unstaged disable after commit = %v", err)
+	}
+}
+
+func TestWebServicesListKeepsKey(t *testing.T) {
+	m, _ := newBatchTestManager(t)
+	if _, err := m.enableWebService(context.Background(), "web", 8080, 18080, nil, false); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+
+	reply := m.handleWebServicesList(context.Background(), &nexuswamp.Invocation{}).Args[0].(map[string]any)
+	data, _ := reply["data"].(map[string]any)
+	for _, list := range []any{reply["webservices"], data["webservices"]} {
+		if webservices, _ := list.([]map[string]any); len(webservices) != 1 || webservices[0]["name"] != "web" {
+			t.Errorf("webservices = %v in %v", list, reply)
+		}
+	}
+}
+
+func TestEnableCancelledDuringReload(t *testing.T) {
+	// An nginx that never finishes its config test
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "nginx"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	execNginx := runNginx
+	m, _ := newBatchTestManager(t)
+	runNginx = execNginx
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := m.enableWebService(ctx, "web", 8080, 18080, nil, false); err == nil {
+		t.Fatal("enableWebService() succeeded after its context was cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("enableWebService() took %v after cancellation", elapsed)
+	}
+	if _, ok := m.webservices["web"]; ok || confExists("web") {
+		t.Error("webservice kept after a cancelled reload")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// ModePort gives every webservice its own public port
+	ModePort = "port"
+	// ModePath serves every webservice under /<name>/ on a shared port
+	ModePath = "path"
+
+	sharedConfName = "lr_shared.conf"
+)
+
+// proxyHeaders are the directives common to every proxied location
+const proxyHeaders = `        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto $scheme;
+`
+
+// serviceConfPath returns the nginx config file for a port-mode webservice
+func serviceConfPath(name string) string {
+	return filepath.Join(nginxConfDir, fmt.Sprintf("lr_%s.conf", name))
+}
+
+// sharedConfPath returns the nginx config file holding path-mode webservices
+func sharedConfPath() string {
+	return filepath.Join(nginxConfDir, sharedConfName)
+}
+
+// locationPath returns the URL prefix a webservice is served under
+func locationPath(name string) string {
+	return "/" + name + "/"
+}
+
+// validateName checks that name can be used as a single URL segment and
+// inside nginx config and htpasswd file names
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("webservice name is empty")
+	}
+	if strings.ContainsAny(name, "/\\?#%;{} \t\r\n\"'$") {
+		return fmt.Errorf("webservice name %q is not a valid webservice name", name)
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("webservice name %q is not a valid webservice name", name)
+	}
+	return nil
+}
+
+// portServerConf renders a dedicated server block for a port-mode webservice
+func portServerConf(ws *WebServiceInfo) string {
+	return fmt.Sprintf(`
+server {
+    listen %d;
+    server_name _;
+
+    location / {
+        proxy_pass http://127.0.0.1:%d;
+%s%s    }
+}
+`, ws.PublicPort, ws.LocalPort, proxyHeaders, authDirectives(ws))
+}
+
+// sharedServerConf renders the single server block that exposes every
+// path-mode webservice as a location on the shared port
+func sharedServerConf(port int, services []*WebServiceInfo) string {
+	sorted := make([]*WebServiceInfo, len(services))
+	copy(sorted, services)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nserver {\n    listen %d;\n    server_name _;\n", port)
+
+	for _, ws := range sorted {
+		prefix := strings.TrimSuffix(ws.Path, "/")
+		// The trailing slash on proxy_pass strips the prefix before forwarding
+		fmt.Fprintf(&b, `
+    location = %s {
+        return 301 %s;
+    }
+
+    location %s {
+        proxy_pass http://127.0.0.1:%d/;
+%s        proxy_set_header X-Forwarded-Prefix %s;
+%s    }
+`, prefix, ws.Path, ws.Path, ws.LocalPort, proxyHeaders, prefix, authDirectives(ws))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSharedServerConf(t *testing.T) {
+	services := []*WebServiceInfo{
+		{Name: "web", LocalPort: 8080, Path: "/web/"},
+		{Name: "api", LocalPort: 9090, Path: "/api/"},
+	}
+	conf := sharedServerConf(80, services)
+
+	if strings.Count(conf, "server {") != 1 || !strings.Contains(conf, "listen 80;") {
+		t.Errorf("want a single server block on port 80:\n%s", conf)
+	}
+	for _, want := range []string{
+		"location = /api {\n        return 301 /api/;",
+		"location /api/ {\n        proxy_pass http://127.0.0.1:9090/;",
+		"proxy_set_header X-Forwarded-Prefix /api;",
+		"location /web/ {\n        proxy_pass http://127.0.0.1:8080/;",
+		"proxy_set_header X-Forwarded-Prefix /web;",
+	} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("shared config lacks %q:\n%s", want, conf)
+		}
+	}
+	// Locations are sorted, so the file does not change between reloads
+	if strings.Index(conf, "location /api/") > strings.Index(conf, "location /web/") {
+		t.Errorf("locations not sorted by path:\n%s", conf)
+	}
+}
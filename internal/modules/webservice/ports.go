@@ -0,0 +1,108 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrPortRangeExhausted is returned when every port of the configured
+// public port range is taken
+var ErrPortRangeExhausted = errors.New("public port range exhausted")
+
+// portAvailable reports whether port can be bound on the host; replaced
+// in tests
+var portAvailable = func(port int) bool {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
+// portRange is an inclusive range of public ports
+type portRange struct {
+	low, high int
+}
+
+// parsePortRange parses a "low-high" range. An empty string yields the
+// zero range, meaning no allocation.
+func parsePortRange(s string) (portRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return portRange{}, nil
+	}
+
+	lo, hi, ok := strings.Cut(s, "-")
+	if !ok {
+		return portRange{}, fmt.Errorf("invalid public port range %q (expected low-high)", s)
+	}
+	low, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil {
+		return portRange{}, fmt.Errorf("invalid public port range %q: %w", s, err)
+	}
+	high, err := strconv.Atoi(strings.TrimSpace(hi))
+	if err != nil {
+		return portRange{}, fmt.Errorf("invalid public port range %q: %w", s, err)
+	}
+	if low < 1 || high > 65535 || low > high {
+		return portRange{}, fmt.Errorf("invalid public port range %q", s)
+	}
+
+	return portRange{low: low, high: high}, nil
+}
+
+// empty reports whether no range is configured
+func (r portRange) empty() bool {
+	return r.low == 0
+}
+
+// String returns the range in its configured form
+func (r portRange) String() string {
+	return fmt.Sprintf("%d-%d", r.low, r.high)
+}
+
+// allocatePublicPort returns the lowest port of the configured range that
+// no webservice uses and that is free on the host. Ports of staged
+// enables and disables count as used, since a rollback restores them.
+// (must be called with lock held)
+func (m *Manager) allocatePublicPort() (int, error) {
+	if m.portRange.empty() {
+		return 0, fmt.Errorf("public_port required (no webservices.public_port_range configured)")
+	}
+
+	used := make(map[int]bool, len(m.webservices))
+	for _, ws := range m.webservices {
+		used[ws.PublicPort] = true
+	}
+	for _, op := range m.staged {
+		used[op.ws.PublicPort] = true
+	}
+
+	for port := m.portRange.low; port <= m.portRange.high; port++ {
+		if used[port] || !portAvailable(port) {
+			continue
+		}
+		return port, nil
+	}
+
+	return 0, fmt.Errorf("%w: no free port in %s", ErrPortRangeExhausted, m.portRange)
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// newPortTestManager builds a port-mode manager allocating from portRange,
+// treating the ports in busy as bound by other processes
+func newPortTestManager(t *testing.T, portRange string, busy ...int) *Manager {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.WebServices.Mode = ModePort
+	cfg.WebServices.PublicPortRange = portRange
+
+	m, err := NewManager(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	taken := make(map[int]bool)
+	for _, port := range busy {
+		taken[port] = true
+	}
+	orig := portAvailable
+	portAvailable = func(port int) bool { return !taken[port] }
+	t.Cleanup(func() { portAvailable = orig })
+
+	return m
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    portRange
+		wantErr bool
+	}{
+		{in: "", want: portRange{}},
+		{in: "8100-8199", want: portRange{low: 8100, high: 8199}},
+		{in: " 9000 - 9000 ", want: portRange{low: 9000, high: 9000}},
+		{in: "8100", wantErr: true},
+		{in: "8199-8100", wantErr: true},
+		{in: "0-10", wantErr: true},
+		{in: "65000-70000", wantErr: true},
+		{in: "a-b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePortRange(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePortRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePortRange(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewManagerInvalidPortRange(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.WebServices.PublicPortRange = "9000-8000"
+
+	if _, err := NewManager(cfg, nil, nil); err == nil {
+		t.Fatal("NewManager accepted an inverted public port range")
+	}
+}
+
+func TestAllocatePublicPort(t *testing.T) {
+	tests := []struct {
+		name      string
+		portRange string
+		busy      []int
+		enabled   []int
+		staged    []int
+		want      int
+		wantErr   error
+	}{
+		{name: "lowest free", portRange: "8100-8102", want: 8100},
+		{name: "skips enabled", portRange: "8100-8102", enabled: []int{8100}, want: 8101},
+		{name: "skips staged", portRange: "8100-8102", enabled: []int{8100}, staged: []int{8101}, want: 8102},
+		{name: "skips busy on host", portRange: "8100-8102", busy: []int{8100, 8101}, want: 8102},
+		{name: "exhausted", portRange: "8100-8101", enabled: []int{8100}, busy: []int{8101}, wantErr: ErrPortRangeExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newPortTestManager(t, tt.portRange, tt.busy...)
+			for i, port := range tt.enabled {
+				name := string(rune('a' + i))
+				m.webservices[name] = &WebServiceInfo{Name: name, PublicPort: port}
+			}
+			// Staged disables are no longer listed but may be restored
+			for _, port := range tt.staged {
+				m.staged = append(m.staged, stagedOp{ws: &WebServiceInfo{Name: "staged", PublicPort: port}})
+			}
+
+			got, err := m.allocatePublicPort()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("allocatePublicPort() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("allocatePublicPort: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("allocatePublicPort() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocatePublicPortNoRange(t *testing.T) {
+	m := newPortTestManager(t, "")
+
+	_, err := m.allocatePublicPort()
+	if err == nil || errors.Is(err, ErrPortRangeExhausted) {
+		t.Fatalf("allocatePublicPort() error = %v, want missing public_port", err)
+	}
+}
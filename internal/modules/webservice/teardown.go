@@ -0,0 +1,66 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// teardownTimeout bounds how long Stop spends announcing the webservices
+// it disabled, replaceable for testing
+var teardownTimeout = 2 * time.Second
+
+// webServicesTopic returns the topic webservice events are published to
+func (m *Manager) webServicesTopic() string {
+	return fmt.Sprintf("iotronic.board.%s.webservices", m.board.UUID)
+}
+
+// announceTeardown tells the cloud, best-effort, that the named
+// webservices were disabled by a shutdown. Nothing is sent while WAMP is
+// down, and publishing is abandoned after teardownTimeout.
+func (m *Manager) announceTeardown(names []string) {
+	if len(names) == 0 || m.connected == nil || !m.connected() {
+		return
+	}
+
+	topic := m.webServicesTopic()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, name := range names {
+			event := map[string]any{
+				"board":      m.board.UUID,
+				"webservice": name,
+				"event":      "disabled",
+				"reason":     "shutdown",
+				"timestamp":  time.Now().Format("2006-01-02T15:04:05.000000"),
+			}
+			if err := m.publish(topic, []any{event}, nil); err != nil {
+				log.Warnf("Failed to announce the teardown of webservice %s: %v", name, err)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(teardownTimeout):
+		log.Warnf("Gave up announcing the teardown of %d webservices after %s", len(names), teardownTimeout)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+)
+
+func TestStopAnnouncesTeardown(t *testing.T) {
+	for _, up := range []bool{true, false} {
+		m := newProxyTestManager(t, true)
+		m.board = &board.Board{UUID: "b1"}
+		m.webservices["web"] = &WebServiceInfo{Name: "web", LocalPort: 8080, PublicPort: 18080, Status: "enabled"}
+
+		var topics []string
+		var events []map[string]any
+		m.connected = func() bool { return up }
+		m.publish = func(topic string, args []any, kwargs map[string]any) error {
+			topics = append(topics, topic)
+			events = append(events, args[0].(map[string]any))
+			return nil
+		}
+
+		if err := m.Stop(); err != nil {
+			t.Fatalf("Stop() = %v", err)
+		}
+
+		if !up {
+			if len(events) != 0 {
+				t.Errorf("published %v while WAMP was down", events)
+			}
+			continue
+		}
+		if len(events) != 1 || topics[0] != "iotronic.board.b1.webservices" ||
+			events[0]["webservice"] != "web" || events[0]["event"] != "disabled" {
+			t.Errorf("published %v on %v, want web disabled", events, topics)
+		}
+	}
+}
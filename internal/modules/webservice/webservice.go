@@ -17,23 +17,48 @@ package webservice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/lasterror"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/wamp"
 	gammazero "github.com/gammazero/nexus/v3/client"
 	nexuswamp "github.com/gammazero/nexus/v3/wamp"
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	nginxConfDir = "/etc/nginx/conf.d"
-)
+// nginxConfDir is where the webservice configs are written; replaced in
+// tests
+var nginxConfDir = "/etc/nginx/conf.d"
+
+// lookPath locates the proxy binary; replaced in tests
+var lookPath = exec.LookPath
+
+// runNginx runs nginx with args and returns its combined output; replaced
+// in tests
+var runNginx = func(ctx context.Context, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, "nginx", args...).CombinedOutput()
+}
+
+// ErrStagedPending is returned by a change that would reload nginx while
+// staged changes wait for CommitWebServices, as the reload would apply
+// them too
+var ErrStagedPending = errors.New("staged webservice changes pending, commit them or stage this change")
+
+// ErrPublicPortInPathMode is returned for a public port other than the
+// shared one in path mode, where every webservice is served on shared_port
+var ErrPublicPortInPathMode = errors.New("public_port cannot be chosen in path mode")
+
+// ErrInvalidPort is returned for a local or public port outside the TCP
+// range
+var ErrInvalidPort = errors.New("port must be between 1 and 65535")
 
 // Manager handles webservice reverse proxy management via nginx
 type Manager struct {
@@ -43,17 +68,42 @@ type Manager struct {
 	cfg        *config.Config
 	wampClient *wamp.Client
 
-	proxyType  string
+	proxyType   string
+	mode        string
 	webservices map[string]*WebServiceInfo
+
+	// portRange is where public ports are allocated when none is given
+	portRange portRange
+
+	// staged holds changes written to disk but not yet reloaded into nginx
+	staged []stagedOp
+
+	// notReady explains why nginx-backed procedures are unavailable
+	notReady string
+
+	// errs holds the last failed webservice operation
+	errs lasterror.Tracker
+
+	// publish and connected reach the cloud, replaceable for testing
+	publish   func(topic string, args []any, kwargs map[string]any) error
+	connected func() bool
+
+	started  atomic.Bool
+	rpcCount atomic.Int32
 }
 
 // WebServiceInfo represents a reverse-proxied webservice
 type WebServiceInfo struct {
-	Name      string `json:"name"`
-	LocalPort int    `json:"local_port"`
-	PublicPort int   `json:"public_port"`
-	Domain    string `json:"domain"`
-	Status    string `json:"status"`
+	Name       string `json:"name"`
+	LocalPort  int    `json:"local_port"`
+	PublicPort int    `json:"public_port"`
+	Path       string `json:"path,omitempty"`
+	Domain     string `json:"domain"`
+	BasicAuth  bool   `json:"basic_auth"`
+	Status     string `json:"status"`
+
+	// authFile is the htpasswd file enforcing basic auth, if any
+	authFile string
 }
 
 // NewManager creates a new webservice manager
@@ -63,10 +113,30 @@ func NewManager(cfg *config.Config, board *board.Board, wampClient *wamp.Client)
 		cfg:         cfg,
 		wampClient:  wampClient,
 		proxyType:   cfg.WebServices.Proxy,
+		mode:        cfg.WebServices.Mode,
 		webservices: make(map[string]*WebServiceInfo),
 	}
+	if wampClient != nil {
+		m.publish = wampClient.Publish
+		m.connected = wampClient.IsConnected
+	}
+
+	switch m.mode {
+	case "":
+		m.mode = ModePort
+	case ModePort, ModePath:
+	default:
+		return nil, fmt.Errorf("invalid webservices mode %q (expected %q or %q)", m.mode, ModePort, ModePath)
+	}
+
+	portRange, err := parsePortRange(cfg.WebServices.PublicPortRange)
+	if err != nil {
+		return nil, err
+	}
+	m.portRange = portRange
 
 	log.Infof("Proxy used: %s", m.proxyType)
+	log.Infof("Routing mode: %s", m.mode)
 
 	return m, nil
 }
@@ -75,16 +145,16 @@ func NewManager(cfg *config.Config, board *board.Board, wampClient *wamp.Client)
 func (m *Manager) Start(ctx context.Context) error {
 	log.Info("Starting WebService Manager...")
 
-	// Verify nginx is available
-	if _, err := exec.LookPath("nginx"); err != nil {
-		log.Warnf("nginx not found, webservice management will be limited: %v", err)
-	}
+	// Verify nginx is available; without it the nginx-backed procedures
+	// are registered but answer PROXY_UNAVAILABLE
+	m.checkProxy()
 
 	// Register RPC procedures
 	if err := m.registerRPCs(); err != nil {
 		return fmt.Errorf("failed to register RPCs: %w", err)
 	}
 
+	m.started.Store(true)
 	log.Info("WebService Manager started successfully")
 	return nil
 }
@@ -92,36 +162,126 @@ func (m *Manager) Start(ctx context.Context) error {
 // Stop shuts down the webservice manager
 func (m *Manager) Stop() error {
 	log.Info("Stopping WebService Manager...")
+	m.started.Store(false)
 
 	// Clean up all webservices
+	ctx := context.Background()
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
+	// Pending changes are dropped so only committed webservices remain
+	m.rollbackStaged()
+
+	disabled := make([]string, 0, len(m.webservices))
 	for name := range m.webservices {
-		if err := m.removeWebService(name); err != nil {
+		if err := m.removeWebService(ctx, name, false); err != nil {
 			log.Errorf("Failed to remove webservice %s: %v", name, err)
+			continue
 		}
+		disabled = append(disabled, name)
 	}
+	m.mu.Unlock()
 
+	m.announceTeardown(disabled)
 	return nil
 }
 
+// Name returns the module name
+func (m *Manager) Name() string {
+	return "webservice"
+}
+
+// Healthy reports whether the manager is started
+func (m *Manager) Healthy() bool {
+	return m.started.Load()
+}
+
+// Ready reports whether the manager's dependencies are available and, if
+// not, why
+func (m *Manager) Ready() (bool, string) {
+	m.mu.RLock()
+	reason := m.notReady
+	m.mu.RUnlock()
+	if reason == "" {
+		return true, ""
+	}
+
+	// nginx may have been installed since, no restart needed
+	m.checkProxy()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.notReady == "", m.notReady
+}
+
+// LastError returns the last failed operation of the manager, cleared
+// once one succeeds
+func (m *Manager) LastError() *lasterror.Error {
+	return m.errs.Last()
+}
+
+// checkProxy records whether the nginx binary is installed, logging when
+// that changes
+func (m *Manager) checkProxy() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := lookPath("nginx"); err != nil {
+		if m.notReady == "" {
+			log.Warnf("nginx not found, webservice management is unavailable: %v", err)
+		}
+		m.notReady = "nginx not installed"
+		return
+	}
+	if m.notReady != "" {
+		log.Info("nginx found, webservice management is available")
+	}
+	m.notReady = ""
+}
+
+// requireReady wraps handler so it answers PROXY_UNAVAILABLE, before
+// touching any nginx config, while nginx is missing
+func (m *Manager) requireReady(handler func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult) func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult {
+	return func(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+		if ready, reason := m.Ready(); !ready {
+			return rpc.ErrorCode("PROXY_UNAVAILABLE", fmt.Sprintf("Webservice proxy unavailable: %s", reason))
+		}
+		return handler(ctx, inv)
+	}
+}
+
+// RPCCount returns the number of RPC procedures registered by the manager
+func (m *Manager) RPCCount() int {
+	return int(m.rpcCount.Load())
+}
+
 // registerRPCs registers webservice-related RPC procedures
 func (m *Manager) registerRPCs() error {
 	procedures := map[string]func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult{
-		fmt.Sprintf("iotronic.%s.%s.EnableWebService", m.board.SessionID, m.board.UUID):  m.handleEnableWebService,
-		fmt.Sprintf("iotronic.%s.%s.DisableWebService", m.board.SessionID, m.board.UUID): m.handleDisableWebService,
-		fmt.Sprintf("iotronic.%s.%s.WebServicesList", m.board.SessionID, m.board.UUID):   m.handleWebServicesList,
-		fmt.Sprintf("iotronic.%s.%s.ProxyInfo", m.board.SessionID, m.board.UUID):         m.handleProxyInfo,
+		m.wampClient.Procedure("EnableWebService"):  m.requireReady(m.handleEnableWebService),
+		m.wampClient.Procedure("DisableWebService"): m.requireReady(m.handleDisableWebService),
+		m.wampClient.Procedure("WebServicesList"):   m.handleWebServicesList,
+		m.wampClient.Procedure("ProxyInfo"):         m.handleProxyInfo,
+		m.wampClient.Procedure("CommitWebServices"): m.requireReady(m.handleCommitWebServices),
+	}
+
+	// Procedures rejected in maintenance mode
+	mutating := map[string]bool{
+		m.wampClient.Procedure("EnableWebService"):  true,
+		m.wampClient.Procedure("DisableWebService"): true,
+		m.wampClient.Procedure("CommitWebServices"): true,
 	}
 
 	for proc, handler := range procedures {
-		if err := m.wampClient.Register(proc, handler); err != nil {
+		opts := []wamp.RegisterOption{wamp.WithModule(m.Name()), wamp.WithConcurrencyLimit(m.cfg.RPC.WebServiceConcurrency)}
+		if mutating[proc] {
+			opts = append(opts, wamp.Mutating())
+		}
+		if err := m.wampClient.Register(proc, handler, opts...); err != nil {
 			return fmt.Errorf("failed to register %s: %w", proc, err)
 		}
 		log.Infof("Registered RPC: %s", proc)
 	}
 
+	m.rpcCount.Store(int32(len(procedures)))
 	return nil
 }
 
@@ -129,34 +289,47 @@ func (m *Manager) registerRPCs() error {
 func (m *Manager) handleEnableWebService(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
 	log.Info("RPC EnableWebService called")
 
-	if len(inv.Arguments) < 3 {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Missing arguments: name, local_port, and public_port required",
-			}},
-		}
+	// The public port is optional: path mode shares one, port mode
+	// allocates it from the configured range when omitted or 0
+	if len(inv.Arguments) < 2 {
+		return rpc.Error("Missing arguments: name and local_port required")
 	}
 
 	name, _ := inv.Arguments[0].(string)
 	localPort, _ := inv.Arguments[1].(float64)
-	publicPort, _ := inv.Arguments[2].(float64)
-
-	if err := m.enableWebService(name, int(localPort), int(publicPort)); err != nil {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": fmt.Sprintf("Failed to enable webservice: %v", err),
-			}},
-		}
+	var publicPort float64
+	if len(inv.Arguments) > 2 {
+		publicPort, _ = inv.Arguments[2].(float64)
 	}
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": fmt.Sprintf("Webservice %s enabled", name),
-		}},
+	// Optional basic auth credentials
+	var auth *BasicAuth
+	username, _ := inv.ArgumentsKw["username"].(string)
+	password, _ := inv.ArgumentsKw["password"].(string)
+	if username != "" || password != "" {
+		auth = &BasicAuth{Username: username, Password: password}
 	}
+
+	staged := m.isStaged(inv)
+	ws, err := m.enableWebService(ctx, name, int(localPort), int(publicPort), auth, staged)
+	if m.errs.Observe("enable "+name, err) != nil {
+		switch {
+		case errors.Is(err, ErrPortRangeExhausted):
+			return rpc.ErrorCode("LIMIT_REACHED", fmt.Sprintf("Failed to enable webservice: %v", err))
+		case errors.Is(err, ErrStagedPending):
+			return rpc.ErrorCode("STAGED_PENDING", fmt.Sprintf("Failed to enable webservice: %v", err))
+		case errors.Is(err, ErrPublicPortInPathMode), errors.Is(err, ErrInvalidPort):
+			return rpc.ErrorCode("INVALID_ARGUMENT", fmt.Sprintf("Failed to enable webservice: %v", err))
+		}
+		return rpc.Error(fmt.Sprintf("Failed to enable webservice: %v", err))
+	}
+
+	return rpc.Success(fmt.Sprintf("Webservice %s %s", name, stagedVerb("enabled", staged)), map[string]any{
+		"name":        ws.Name,
+		"local_port":  ws.LocalPort,
+		"public_port": ws.PublicPort,
+		"path":        ws.Path,
+	})
 }
 
 // handleDisableWebService handles the DisableWebService RPC
@@ -164,31 +337,20 @@ func (m *Manager) handleDisableWebService(ctx context.Context, inv *nexuswamp.In
 	log.Info("RPC DisableWebService called")
 
 	if len(inv.Arguments) < 1 {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": "Missing argument: name required",
-			}},
-		}
+		return rpc.Error("Missing argument: name required")
 	}
 
 	name, _ := inv.Arguments[0].(string)
 
-	if err := m.disableWebService(name); err != nil {
-		return gammazero.InvokeResult{
-			Args: []any{map[string]any{
-				"result":  "ERROR",
-				"message": fmt.Sprintf("Failed to disable webservice: %v", err),
-			}},
+	staged := m.isStaged(inv)
+	if err := m.errs.Observe("disable "+name, m.disableWebService(ctx, name, staged)); err != nil {
+		if errors.Is(err, ErrStagedPending) {
+			return rpc.ErrorCode("STAGED_PENDING", fmt.Sprintf("Failed to disable webservice: %v", err))
 		}
+		return rpc.Error(fmt.Sprintf("Failed to disable webservice: %v", err))
 	}
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": fmt.Sprintf("Webservice %s disabled", name),
-		}},
-	}
+	return rpc.Success(fmt.Sprintf("Webservice %s %s", name, stagedVerb("disabled", staged)), nil)
 }
 
 // handleWebServicesList handles the WebServicesList RPC
@@ -202,119 +364,249 @@ func (m *Manager) handleWebServicesList(ctx context.Context, inv *nexuswamp.Invo
 			"name":        ws.Name,
 			"local_port":  ws.LocalPort,
 			"public_port": ws.PublicPort,
+			"path":        ws.Path,
+			"basic_auth":  ws.BasicAuth,
 			"status":      ws.Status,
 		})
 	}
 	m.mu.RUnlock()
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":      "SUCCESS",
-			"message":     "Webservices list retrieved",
-			"webservices": list,
-		}},
-	}
+	return rpc.List("Webservices list retrieved", "webservices", list)
 }
 
 // handleProxyInfo handles the ProxyInfo RPC
 func (m *Manager) handleProxyInfo(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
 	log.Info("RPC ProxyInfo called")
 
-	// Check nginx status
-	status := "stopped"
-	if m.isNginxRunning() {
-		status = "running"
+	info := map[string]any{
+		"type":      m.proxyType,
+		"mode":      m.mode,
+		"available": true,
 	}
 
-	return gammazero.InvokeResult{
-		Args: []any{map[string]any{
-			"result":  "SUCCESS",
-			"message": "Proxy info retrieved",
-			"data": map[string]any{
-				"type":   m.proxyType,
-				"status": status,
-			},
-		}},
+	// Check nginx status
+	if ready, reason := m.Ready(); !ready {
+		info["available"] = false
+		info["status"] = "unavailable"
+		info["reason"] = reason
+	} else if m.isNginxRunning() {
+		info["status"] = "running"
+	} else {
+		info["status"] = "stopped"
 	}
+
+	return rpc.Success("Proxy info retrieved", info)
 }
 
-// enableWebService enables a webservice via nginx reverse proxy
-func (m *Manager) enableWebService(name string, localPort, publicPort int) error {
+// enableWebService enables a webservice via nginx reverse proxy and
+// returns it. If staged, the nginx config is written but only reloaded by
+// commitWebServices. In port mode a publicPort of 0 is allocated from the
+// configured range; in path mode it must be 0 or the shared port.
+func (m *Manager) enableWebService(ctx context.Context, name string, localPort, publicPort int, auth *BasicAuth, staged bool) (*WebServiceInfo, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Check if already exists
 	if _, exists := m.webservices[name]; exists {
-		return fmt.Errorf("webservice %s already enabled", name)
-	}
-
-	// Create nginx configuration
-	confPath := filepath.Join(nginxConfDir, fmt.Sprintf("lr_%s.conf", name))
-	nginxConf := fmt.Sprintf(`
-server {
-    listen %d;
-    server_name _;
-
-    location / {
-        proxy_pass http://127.0.0.1:%d;
-        proxy_set_header Host $host;
-        proxy_set_header X-Real-IP $remote_addr;
-        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
-        proxy_set_header X-Forwarded-Proto $scheme;
-    }
-}
-`, publicPort, localPort)
-
-	if err := os.WriteFile(confPath, []byte(nginxConf), 0644); err != nil {
-		return fmt.Errorf("failed to write nginx config: %w", err)
+		return nil, fmt.Errorf("webservice %s already enabled", name)
+	}
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if localPort < 1 || localPort > 65535 {
+		return nil, fmt.Errorf("invalid local_port %d: %w", localPort, ErrInvalidPort)
+	}
+	// A public port of 0 is allocated below
+	if publicPort < 0 || publicPort > 65535 {
+		return nil, fmt.Errorf("invalid public_port %d: %w", publicPort, ErrInvalidPort)
+	}
+	if !staged && len(m.staged) > 0 {
+		return nil, ErrStagedPending
 	}
 
-	// Reload nginx
-	if err := m.reloadNginx(); err != nil {
-		os.Remove(confPath)
-		return fmt.Errorf("failed to reload nginx: %w", err)
+	if m.mode == ModePort && publicPort == 0 {
+		port, err := m.allocatePublicPort()
+		if err != nil {
+			return nil, err
+		}
+		publicPort = port
+	}
+	if m.mode == ModePath && publicPort != 0 && publicPort != m.cfg.WebServices.SharedPort {
+		return nil, fmt.Errorf("%w: every webservice is served on shared_port %d, got %d",
+			ErrPublicPortInPathMode, m.cfg.WebServices.SharedPort, publicPort)
 	}
 
-	// Store webservice info
-	m.webservices[name] = &WebServiceInfo{
+	ws := &WebServiceInfo{
 		Name:       name,
 		LocalPort:  localPort,
 		PublicPort: publicPort,
 		Status:     "enabled",
 	}
 
-	log.Infof("Webservice %s enabled (local:%d -> public:%d)", name, localPort, publicPort)
+	if auth != nil {
+		authFile, err := m.writeHtpasswd(name, auth)
+		if err != nil {
+			return nil, err
+		}
+		ws.BasicAuth = true
+		ws.authFile = authFile
+	}
+
+	var err error
+	if m.mode == ModePath {
+		err = m.enablePathWebService(ctx, ws, !staged)
+	} else {
+		err = m.enablePortWebService(ctx, ws, !staged)
+	}
+	if err != nil {
+		if ws.authFile != "" {
+			os.Remove(ws.authFile)
+		}
+		return nil, err
+	}
+
+	if staged {
+		ws.Status = "staged"
+		m.staged = append(m.staged, stagedOp{enable: true, ws: ws})
+		log.Infof("Webservice %s staged for enable (local:%d -> public:%d%s)", name, localPort, ws.PublicPort, ws.Path)
+		return ws, nil
+	}
+
+	log.Infof("Webservice %s enabled (local:%d -> public:%d%s)", name, localPort, ws.PublicPort, ws.Path)
+
+	return ws, nil
+}
+
+// enablePortWebService writes a dedicated server block for ws and
+// optionally reloads nginx (lock held)
+func (m *Manager) enablePortWebService(ctx context.Context, ws *WebServiceInfo, reload bool) error {
+	confPath := serviceConfPath(ws.Name)
+	if err := os.WriteFile(confPath, []byte(portServerConf(ws)), 0644); err != nil {
+		return fmt.Errorf("failed to write nginx config: %w", err)
+	}
+
+	// Reload nginx
+	if reload {
+		if err := m.reloadNginx(ctx); err != nil {
+			os.Remove(confPath)
+			return fmt.Errorf("failed to reload nginx: %w", err)
+		}
+	}
+
+	m.webservices[ws.Name] = ws
+	return nil
+}
+
+// enablePathWebService adds ws as a location on the shared server and
+// optionally reloads nginx (lock held)
+func (m *Manager) enablePathWebService(ctx context.Context, ws *WebServiceInfo, reload bool) error {
+	ws.Path = locationPath(ws.Name)
+	ws.PublicPort = m.cfg.WebServices.SharedPort
+
+	for _, other := range m.webservices {
+		if other.Path == ws.Path {
+			return fmt.Errorf("path %s already used by webservice %s", ws.Path, other.Name)
+		}
+	}
+
+	m.webservices[ws.Name] = ws
+	if err := m.writeSharedConf(ctx, reload); err != nil {
+		delete(m.webservices, ws.Name)
+		// Restore the previous config even if the caller gave up
+		m.writeSharedConf(context.WithoutCancel(ctx), reload)
+		return err
+	}
+
+	return nil
+}
+
+// writeSharedConf regenerates the shared server config from the path-mode
+// webservices and optionally reloads nginx (must be called with lock held)
+func (m *Manager) writeSharedConf(ctx context.Context, reload bool) error {
+	var services []*WebServiceInfo
+	for _, ws := range m.webservices {
+		if ws.Path != "" {
+			services = append(services, ws)
+		}
+	}
+
+	confPath := sharedConfPath()
+	if len(services) == 0 {
+		if err := os.Remove(confPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove nginx config: %w", err)
+		}
+	} else {
+		conf := sharedServerConf(m.cfg.WebServices.SharedPort, services)
+		if err := os.WriteFile(confPath, []byte(conf), 0644); err != nil {
+			return fmt.Errorf("failed to write nginx config: %w", err)
+		}
+	}
+
+	if !reload {
+		return nil
+	}
+
+	if err := m.reloadNginx(ctx); err != nil {
+		return fmt.Errorf("failed to reload nginx: %w", err)
+	}
 
 	return nil
 }
 
 // disableWebService disables a webservice
-func (m *Manager) disableWebService(name string) error {
+func (m *Manager) disableWebService(ctx context.Context, name string, staged bool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.removeWebService(name)
+	if !staged && len(m.staged) > 0 {
+		return ErrStagedPending
+	}
+	return m.removeWebService(ctx, name, staged)
 }
 
-// removeWebService removes a webservice (must be called with lock held)
-func (m *Manager) removeWebService(name string) error {
-	if _, exists := m.webservices[name]; !exists {
+// removeWebService removes a webservice. If staged, nginx is not reloaded
+// and the credentials are kept until the change is committed (must be
+// called with lock held).
+func (m *Manager) removeWebService(ctx context.Context, name string, staged bool) error {
+	ws, exists := m.webservices[name]
+	if !exists {
 		return fmt.Errorf("webservice %s not found", name)
 	}
 
-	// Remove nginx configuration
-	confPath := filepath.Join(nginxConfDir, fmt.Sprintf("lr_%s.conf", name))
-	if err := os.Remove(confPath); err != nil && !os.IsNotExist(err) {
-		log.Warnf("Failed to remove nginx config: %v", err)
+	// Remove from map
+	delete(m.webservices, name)
+
+	if ws.Path != "" {
+		// Drop its location from the shared server
+		if err := m.writeSharedConf(ctx, !staged); err != nil {
+			log.Warnf("Failed to update shared nginx config: %v", err)
+		}
+	} else {
+		// Remove nginx configuration
+		if err := os.Remove(serviceConfPath(name)); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove nginx config: %v", err)
+		}
+
+		// Reload nginx
+		if !staged {
+			if err := m.reloadNginx(ctx); err != nil {
+				log.Warnf("Failed to reload nginx: %v", err)
+			}
+		}
 	}
 
-	// Reload nginx
-	if err := m.reloadNginx(); err != nil {
-		log.Warnf("Failed to reload nginx: %v", err)
+	if staged {
+		m.staged = append(m.staged, stagedOp{enable: false, ws: ws})
+		log.Infof("Webservice %s staged for disable", name)
+		return nil
 	}
 
-	// Remove from map
-	delete(m.webservices, name)
+	// Remove basic auth credentials
+	if ws.authFile != "" {
+		if err := os.Remove(ws.authFile); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove htpasswd file: %v", err)
+		}
+	}
 
 	log.Infof("Webservice %s disabled", name)
 
@@ -322,16 +614,14 @@ func (m *Manager) removeWebService(name string) error {
 }
 
 // reloadNginx reloads the nginx configuration
-func (m *Manager) reloadNginx() error {
+func (m *Manager) reloadNginx(ctx context.Context) error {
 	// Test nginx configuration first
-	cmd := exec.Command("nginx", "-t")
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if output, err := runNginx(ctx, "-t"); err != nil {
 		return fmt.Errorf("nginx config test failed: %s", output)
 	}
 
 	// Reload nginx
-	cmd = exec.Command("nginx", "-s", "reload")
-	if output, err := cmd.CombinedOutput(); err != nil {
+	if output, err := runNginx(ctx, "-s", "reload"); err != nil {
 		return fmt.Errorf("nginx reload failed: %s", output)
 	}
 
@@ -0,0 +1,239 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package webservice
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	gammazero "github.com/gammazero/nexus/v3/client"
+	nexuswamp "github.com/gammazero/nexus/v3/wamp"
+)
+
+// resultOf returns the result map of an RPC reply
+func resultOf(t *testing.T, res gammazero.InvokeResult) map[string]any {
+	t.Helper()
+	if len(res.Args) != 1 {
+		t.Fatalf("result has %d args, want 1", len(res.Args))
+	}
+	out, ok := res.Args[0].(map[string]any)
+	if !ok {
+		t.Fatalf("result is %T, want map", res.Args[0])
+	}
+	return out
+}
+
+// newProxyTestManager builds a manager whose nginx lookup succeeds only if
+// installed is set
+func newProxyTestManager(t *testing.T, installed bool) *Manager {
+	t.Helper()
+
+	orig := lookPath
+	lookPath = func(file string) (string, error) {
+		if !installed {
+			return "", errors.New("executable file not found in $PATH")
+		}
+		return "/usr/sbin/" + file, nil
+	}
+	t.Cleanup(func() { lookPath = orig })
+
+	cfg := &config.Config{}
+	cfg.WebServices.Proxy = "nginx"
+	m, err := NewManager(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.checkProxy()
+	return m
+}
+
+func TestProxyUnavailable(t *testing.T) {
+	m := newProxyTestManager(t, false)
+
+	if ready, reason := m.Ready(); ready || reason != "nginx not installed" {
+		t.Errorf("Ready() = %v, %q, want false, %q", ready, reason, "nginx not installed")
+	}
+
+	inv := &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"web", float64(8080), float64(8100)},
+		ArgumentsKw: nexuswamp.Dict{},
+	}
+	handlers := map[string]func(context.Context, *nexuswamp.Invocation) gammazero.InvokeResult{
+		"EnableWebService":  m.handleEnableWebService,
+		"DisableWebService": m.handleDisableWebService,
+		"CommitWebServices": m.handleCommitWebServices,
+	}
+	for name, handler := range handlers {
+		res := resultOf(t, m.requireReady(handler)(context.Background(), inv))
+		if res["result"] != rpc.ResultError || res["code"] != "PROXY_UNAVAILABLE" {
+			t.Errorf("%s = %v, want PROXY_UNAVAILABLE error", name, res)
+		}
+	}
+	if len(m.webservices) != 0 {
+		t.Errorf("webservices = %v, want none", m.webservices)
+	}
+
+	res := resultOf(t, m.handleProxyInfo(context.Background(), inv))
+	data, _ := res["data"].(map[string]any)
+	if data["available"] != false || data["status"] != "unavailable" || data["reason"] != "nginx not installed" {
+		t.Errorf("ProxyInfo data = %v, want unavailable", data)
+	}
+}
+
+func TestProxyAvailable(t *testing.T) {
+	m := newProxyTestManager(t, true)
+
+	if ready, reason := m.Ready(); !ready {
+		t.Fatalf("Ready() = false, %q, want true", reason)
+	}
+
+	called := false
+	handler := m.requireReady(func(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+		called = true
+		return rpc.Success("ok", nil)
+	})
+	res := resultOf(t, handler(context.Background(), &nexuswamp.Invocation{}))
+	if !called || res["result"] != rpc.ResultSuccess {
+		t.Errorf("handler called = %v, result = %v, want pass-through", called, res)
+	}
+}
+
+func TestProxyInstalledLater(t *testing.T) {
+	m := newProxyTestManager(t, false)
+	handler := m.requireReady(func(ctx context.Context, inv *nexuswamp.Invocation) gammazero.InvokeResult {
+		return rpc.Success("ok", nil)
+	})
+	if res := resultOf(t, handler(context.Background(), &nexuswamp.Invocation{})); res["code"] != "PROXY_UNAVAILABLE" {
+		t.Fatalf("handler = %v before nginx is installed, want PROXY_UNAVAILABLE", res)
+	}
+
+	// Installing nginx makes the manager ready on the next check, without
+	// a restart
+	lookPath = func(file string) (string, error) { return "/usr/sbin/" + file, nil }
+	if ready, reason := m.Ready(); !ready {
+		t.Fatalf("Ready() = false, %q after nginx was installed", reason)
+	}
+	if res := resultOf(t, handler(context.Background(), &nexuswamp.Invocation{})); res["result"] != rpc.ResultSuccess {
+		t.Errorf("handler = %v after nginx was installed, want pass-through", res)
+	}
+}
+
+func TestLastError(t *testing.T) {
+	m := newProxyTestManager(t, true)
+	m.webservices["web"] = &WebServiceInfo{Name: "web", LocalPort: 8080, PublicPort: 8100, Status: "enabled"}
+
+	// Enabling a webservice twice fails and is reported as the last error
+	inv := &nexuswamp.Invocation{
+		Arguments:   nexuswamp.List{"web", float64(8080), float64(8101)},
+		ArgumentsKw: nexuswamp.Dict{},
+	}
+	if res := resultOf(t, m.handleEnableWebService(context.Background(), inv)); res["result"] != rpc.ResultError {
+		t.Fatalf("EnableWebService = %v, want an error", res)
+	}
+	last := m.LastError()
+	if last == nil || last.Operation != "enable web" || last.Message != "webservice web already enabled" {
+		t.Fatalf("LastError() = %+v, want the failed enable", last)
+	}
+
+	// A successful operation clears it
+	commit := &nexuswamp.Invocation{ArgumentsKw: nexuswamp.Dict{}}
+	if res := resultOf(t, m.handleCommitWebServices(context.Background(), commit)); res["result"] != rpc.ResultSuccess {
+		t.Fatalf("CommitWebServices = %v, want success", res)
+	}
+	if last := m.LastError(); last != nil {
+		t.Errorf("LastError() after success = %+v, want nil", last)
+	}
+}
+
+func TestEnableRejectsInvalidPorts(t *testing.T) {
+	tests := []struct {
+		name       string
+		localPort  float64
+		publicPort float64
+	}{
+		{"no local port", 0, 0},
+		{"local port too high", 65536, 0},
+		{"negative public port", 8080, -1},
+		{"public port too high", 8080, 70000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newBatchTestManager(t)
+			ctx := context.Background()
+
+			if _, err := m.enableWebService(ctx, "web", int(tt.localPort), int(tt.publicPort), nil, false); !errors.Is(err, ErrInvalidPort) {
+				t.Errorf("enable = %v, want ErrInvalidPort", err)
+			}
+			res := m.handleEnableWebService(ctx, &nexuswamp.Invocation{Arguments: nexuswamp.List{"web", tt.localPort, tt.publicPort}})
+			if code := resultOf(t, res)["code"]; code != "INVALID_ARGUMENT" {
+				t.Errorf("EnableWebService code = %v, want INVALID_ARGUMENT", code)
+			}
+			if len(m.webservices) != 0 {
+				t.Errorf("webservices = %v after invalid ports", m.webservices)
+			}
+		})
+	}
+}
+
+func TestEnablePathMode(t *testing.T) {
+	m, _ := newBatchTestManager(t)
+	m.mode = ModePath
+	m.cfg.WebServices.SharedPort = 8000
+	ctx := context.Background()
+
+	ws, err := m.enableWebService(ctx, "web", 8080, 0, nil, false)
+	if err != nil {
+		t.Fatalf("enable web: %v", err)
+	}
+	if ws.PublicPort != 8000 || ws.Path != "/web/" {
+		t.Errorf("web served on port %d at %q, want 8000 at /web/", ws.PublicPort, ws.Path)
+	}
+	if _, err := m.enableWebService(ctx, "api", 8081, 8000, nil, false); err != nil {
+		t.Errorf("enable api on the shared port: %v", err)
+	}
+
+	conf, err := os.ReadFile(sharedConfPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(conf), "location /web/") || !strings.Contains(string(conf), "location /api/") {
+		t.Errorf("shared config lacks a location:\n%s", conf)
+	}
+
+	// Any other public port cannot be honoured
+	_, err = m.enableWebService(ctx, "docs", 8082, 18082, nil, false)
+	if !errors.Is(err, ErrPublicPortInPathMode) {
+		t.Errorf("enable on port 18082 = %v, want ErrPublicPortInPathMode", err)
+	}
+	res := m.handleEnableWebService(ctx, &nexuswamp.Invocation{Arguments: nexuswamp.List{"docs", 8082.0, 18082.0}})
+	if code := resultOf(t, res)["code"]; code != "INVALID_ARGUMENT" {
+		t.Errorf("EnableWebService on port 18082 code = %v, want INVALID_ARGUMENT", code)
+	}
+
+	// A path already routed to another webservice is refused
+	m.webservices["legacy"] = &WebServiceInfo{Name: "legacy", LocalPort: 7070, Path: "/docs/"}
+	if _, err := m.enableWebService(ctx, "docs", 8082, 0, nil, false); err == nil || !strings.Contains(err.Error(), "already used by webservice legacy") {
+		t.Errorf("enable on a used path = %v, want a collision", err)
+	}
+	if _, exists := m.webservices["docs"]; exists {
+		t.Error("colliding webservice was registered")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package proclimit starts children with a niceness and resource limits
+// already in place when their program begins. Go cannot set these through
+// SysProcAttr, so the child first runs the agent binary as a shim which
+// applies them to itself and then execs the real program, keeping its PID.
+package proclimit
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// shimArg marks an invocation of the agent binary as the limits shim
+const shimArg = "__lightning-rod-limits"
+
+// executable returns the binary run as the shim, replaceable for testing
+var executable = os.Executable
+
+// Limits are applied to a child before its program starts (0 = unchanged)
+type Limits struct {
+	Nice         int
+	RlimitNofile uint64
+	RlimitAS     uint64
+}
+
+// validate checks that the limits can be applied
+func (l Limits) validate() error {
+	if l.Nice < -20 || l.Nice > 19 {
+		return fmt.Errorf("invalid nice value %d (expected -20..19)", l.Nice)
+	}
+	return nil
+}
+
+// Apply makes cmd start through the shim so l is in place before the
+// program runs. It is a no-op for zero limits and must be called before
+// cmd.Start.
+func Apply(cmd *exec.Cmd, l Limits) error {
+	if l == (Limits{}) || cmd.Err != nil {
+		return nil
+	}
+	if err := l.validate(); err != nil {
+		return err
+	}
+	self, err := executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the agent binary: %w", err)
+	}
+
+	args := []string{
+		self, shimArg,
+		strconv.Itoa(l.Nice),
+		strconv.FormatUint(l.RlimitNofile, 10),
+		strconv.FormatUint(l.RlimitAS, 10),
+		cmd.Path,
+	}
+	cmd.Path = self
+	cmd.Args = append(args, cmd.Args...)
+	return nil
+}
+
+// Main runs the shim when the process was started as one, in which case
+// it never returns. It must be called first thing in main.
+func Main() {
+	if len(os.Args) < 2 || os.Args[1] != shimArg {
+		return
+	}
+	err := shim(os.Args[2:])
+	fmt.Fprintf(os.Stderr, "lightning-rod: %v\n", err)
+	os.Exit(127)
+}
+
+// shim applies the limits in args to the current process and execs the
+// program following them, only returning on failure
+func shim(args []string) error {
+	if len(args) < 5 {
+		return fmt.Errorf("limits shim: missing arguments")
+	}
+	nice, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("limits shim: invalid nice %q", args[0])
+	}
+	l := Limits{Nice: nice}
+	if l.RlimitNofile, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+		return fmt.Errorf("limits shim: invalid rlimit_nofile %q", args[1])
+	}
+	if l.RlimitAS, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+		return fmt.Errorf("limits shim: invalid rlimit_as %q", args[2])
+	}
+	path, argv := args[3], args[4:]
+
+	if err := apply(l); err != nil {
+		return err
+	}
+	if err := syscall.Exec(path, argv, os.Environ()); err != nil {
+		return fmt.Errorf("failed to exec %s: %w", path, err)
+	}
+	return nil
+}
+
+// apply sets l on the current process
+func apply(l Limits) error {
+	if l.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, l.Nice); err != nil {
+			return fmt.Errorf("failed to set nice %d: %w", l.Nice, err)
+		}
+	}
+
+	limits := []struct {
+		resource int
+		name     string
+		value    uint64
+	}{
+		{unix.RLIMIT_NOFILE, "RLIMIT_NOFILE", l.RlimitNofile},
+		{unix.RLIMIT_AS, "RLIMIT_AS", l.RlimitAS},
+	}
+	for _, r := range limits {
+		if r.value == 0 {
+			continue
+		}
+		// syscall.Setrlimit, unlike unix.Setrlimit, stops Go restoring its
+		// own RLIMIT_NOFILE on exec
+		if err := syscall.Setrlimit(r.resource, &syscall.Rlimit{Cur: r.value, Max: r.value}); err != nil {
+			return fmt.Errorf("failed to set %s to %d: %w", r.name, r.value, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package proclimit
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as the shim, as the agent does
+func TestMain(m *testing.M) {
+	Main()
+	os.Exit(m.Run())
+}
+
+// childLimits reports the nice value and open-files limit of the process
+// cmd starts, read from /proc by the child itself
+func childLimits(t *testing.T, cmd *exec.Cmd) (nice int, nofile string) {
+	t.Helper()
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run %v: %v", cmd.Args, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output %q", out)
+	}
+	// Field 19 of /proc/self/stat, counted after the command name
+	fields := strings.Fields(lines[0][strings.LastIndex(lines[0], ")")+2:])
+	nice, err = strconv.Atoi(fields[16])
+	if err != nil {
+		t.Fatalf("stat %q: %v", lines[0], err)
+	}
+	return nice, strings.Fields(lines[1])[3]
+}
+
+const probe = `cat /proc/self/stat; grep "Max open files" /proc/self/limits`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     Limits
+		wantNice   int
+		wantNofile string
+	}{
+		{"nice", Limits{Nice: 7}, 7, ""},
+		{"nofile", Limits{RlimitNofile: 64}, 0, "64"},
+		{"both", Limits{Nice: 3, RlimitNofile: 100, RlimitAS: 1 << 34}, 3, "100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command("sh", "-c", probe)
+			if err := Apply(cmd, tt.limits); err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			nice, nofile := childLimits(t, cmd)
+			if nice != tt.wantNice {
+				t.Errorf("nice = %d, want %d", nice, tt.wantNice)
+			}
+			if tt.wantNofile != "" && nofile != tt.wantNofile {
+				t.Errorf("max open files = %s, want %s", nofile, tt.wantNofile)
+			}
+		})
+	}
+}
+
+func TestApplyZeroLimits(t *testing.T) {
+	cmd := exec.Command("sh", "-c", probe)
+	path := cmd.Path
+	if err := Apply(cmd, Limits{}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if cmd.Path != path || cmd.Args[0] != "sh" {
+		t.Errorf("zero limits rewrote the command to %s %v", cmd.Path, cmd.Args)
+	}
+}
+
+func TestApplyKeepsArgsAndPID(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `echo $$ "$0" "$1"`, "argv0", "a b")
+	if err := Apply(cmd, Limits{Nice: 1}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := strconv.Itoa(cmd.Process.Pid) + " argv0 a b\n"
+	if string(out) != want {
+		t.Errorf("output %q, want %q", out, want)
+	}
+}
+
+func TestApplyInvalidNice(t *testing.T) {
+	cmd := exec.Command("true")
+	if err := Apply(cmd, Limits{Nice: 40}); err == nil {
+		t.Error("Apply accepted nice 40")
+	}
+}
+
+func TestShimFailure(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Path = "/nonexistent/program"
+	if err := Apply(cmd, Limits{Nice: 1}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 127 {
+		t.Fatalf("run = %v, want exit status 127", err)
+	}
+	if !strings.Contains(string(out), "/nonexistent/program") {
+		t.Errorf("output %q does not name the program", out)
+	}
+}
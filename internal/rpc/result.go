@@ -0,0 +1,112 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package rpc builds the result envelope returned by board procedures:
+// {"result": "SUCCESS"|"ERROR", "message": ..., "data": ..., "code": ...}
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gammazero/nexus/v3/client"
+)
+
+// Result values
+const (
+	ResultSuccess = "SUCCESS"
+	ResultError   = "ERROR"
+)
+
+// Success builds a successful result, with data when non-nil
+func Success(message string, data map[string]any) client.InvokeResult {
+	res := map[string]any{
+		"result":  ResultSuccess,
+		"message": message,
+	}
+	if data != nil {
+		res["data"] = data
+	}
+	return client.InvokeResult{Args: []any{res}}
+}
+
+// List builds a successful result carrying items under key in data and,
+// where clients predating the envelope read them, at the top level
+func List(message, key string, items []map[string]any) client.InvokeResult {
+	if items == nil {
+		items = []map[string]any{}
+	}
+	res := Success(message, map[string]any{key: items})
+	res.Args[0].(map[string]any)[key] = items
+	return res
+}
+
+// SuccessObject builds a successful result whose data is v, a struct or
+// typed map, as its JSON encoding describes it. Results travel as JSON, so
+// the wire shape is unchanged.
+func SuccessObject(message string, v any) client.InvokeResult {
+	data, err := object(v)
+	if err != nil {
+		return Error(fmt.Sprintf("Failed to encode result: %v", err))
+	}
+	return Success(message, data)
+}
+
+// object converts v to the map its JSON encoding describes
+func object(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("%T is not an object", v)
+	}
+	return obj, nil
+}
+
+// Error builds an error result
+func Error(message string) client.InvokeResult {
+	return client.InvokeResult{
+		Args: []any{map[string]any{
+			"result":  ResultError,
+			"message": message,
+		}},
+	}
+}
+
+// ErrorCode builds an error result carrying a machine-readable code
+func ErrorCode(code, message string) client.InvokeResult {
+	return client.InvokeResult{
+		Args: []any{map[string]any{
+			"result":  ResultError,
+			"code":    code,
+			"message": message,
+		}},
+	}
+}
+
+// ErrorData builds an error result carrying a machine-readable code and
+// data detailing the error
+func ErrorData(code, message string, data any) client.InvokeResult {
+	return client.InvokeResult{
+		Args: []any{map[string]any{
+			"result":  ResultError,
+			"code":    code,
+			"message": message,
+			"data":    data,
+		}},
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package rpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gammazero/nexus/v3/client"
+)
+
+// envelope returns the JSON keys and values of the single result argument
+func envelope(t *testing.T, res client.InvokeResult) map[string]any {
+	t.Helper()
+	if len(res.Args) != 1 {
+		t.Fatalf("Args = %v, want one envelope", res.Args)
+	}
+	raw, err := json.Marshal(res.Args[0])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var env map[string]any
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatalf("unmarshal %s: %v", raw, err)
+	}
+	return env
+}
+
+func TestEnvelope(t *testing.T) {
+	var nilData map[string]any
+	type point struct {
+		X    int    `json:"x"`
+		Name string `json:"name,omitempty"`
+	}
+
+	tests := []struct {
+		name string
+		res  client.InvokeResult
+		want map[string]any
+	}{
+		{"success", Success("ok", nil), map[string]any{"result": "SUCCESS", "message": "ok"}},
+		{"success nil map", Success("ok", nilData), map[string]any{"result": "SUCCESS", "message": "ok"}},
+		{"success data", Success("ok", map[string]any{"a": 1}),
+			map[string]any{"result": "SUCCESS", "message": "ok", "data": map[string]any{"a": 1.0}}},
+		{"object struct", SuccessObject("ok", point{X: 2}),
+			map[string]any{"result": "SUCCESS", "message": "ok", "data": map[string]any{"x": 2.0}}},
+		{"object typed map", SuccessObject("ok", map[string]string{"site": "lab"}),
+			map[string]any{"result": "SUCCESS", "message": "ok", "data": map[string]any{"site": "lab"}}},
+		{"object nil pointer", SuccessObject("ok", (*point)(nil)), map[string]any{"result": "SUCCESS", "message": "ok"}},
+		{"object not an object", SuccessObject("ok", []int{1}),
+			map[string]any{"result": "ERROR", "message": "Failed to encode result: []int is not an object"}},
+		{"list", List("ok", "services", []map[string]any{{"name": "ssh"}}), map[string]any{
+			"result": "SUCCESS", "message": "ok",
+			"services": []any{map[string]any{"name": "ssh"}},
+			"data":     map[string]any{"services": []any{map[string]any{"name": "ssh"}}},
+		}},
+		{"list nil", List("ok", "webservices", nil), map[string]any{
+			"result": "SUCCESS", "message": "ok",
+			"webservices": []any{},
+			"data":        map[string]any{"webservices": []any{}},
+		}},
+		{"error", Error("boom"), map[string]any{"result": "ERROR", "message": "boom"}},
+		{"error code", ErrorCode("NOT_READY", "later"),
+			map[string]any{"result": "ERROR", "code": "NOT_READY", "message": "later"}},
+		{"error data", ErrorData("INVALID_CONFIG", "bad", map[string]any{"field": "x"}),
+			map[string]any{"result": "ERROR", "code": "INVALID_CONFIG", "message": "bad", "data": map[string]any{"field": "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := envelope(t, tt.res); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("envelope = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package state persists the module state (such as the exposed services)
+// behind a pluggable backend
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// Storage backends
+const (
+	BackendFile   = "file"
+	BackendMemory = "memory"
+)
+
+// ErrNotFound is returned by Load when nothing was saved under a name
+var ErrNotFound = errors.New("state not found")
+
+// Store loads and saves serialized state keyed by name
+type Store interface {
+	Load(name string) ([]byte, error)
+	Save(name string, data []byte) error
+}
+
+// New creates the store selected by lightningrod.state_backend
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.LightningRod.StateBackend {
+	case "", BackendFile:
+		return NewFileStore(cfg.RuntimeDir()), nil
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown state backend %q", cfg.LightningRod.StateBackend)
+	}
+}
+
+// FileStore keeps each name in <dir>/<name>.json
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store writing to dir
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// Load reads the file for name
+func (s *FileStore) Load(name string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Save writes the file for name
+func (s *FileStore) Save(name string, data []byte) error {
+	return os.WriteFile(s.path(name), data, 0644)
+}
+
+// MemoryStore keeps state in memory only, so nothing survives a restart;
+// meant for tests and boards without writable storage
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]byte)}
+}
+
+// Load returns a copy of the data saved under name
+func (s *MemoryStore) Load(name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.data[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Save stores a copy of data under name
+func (s *MemoryStore) Save(name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[name] = append([]byte(nil), data...)
+	return nil
+}
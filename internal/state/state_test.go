@@ -0,0 +1,114 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"file":   func(t *testing.T) Store { return NewFileStore(t.TempDir()) },
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+
+			if _, err := s.Load("services"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load before Save = %v, want ErrNotFound", err)
+			}
+
+			data := []byte(`{"services":{}}`)
+			if err := s.Save("services", data); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			// Callers may reuse their buffer after saving
+			data[0] = 'X'
+
+			got, err := s.Load("services")
+			if err != nil || string(got) != `{"services":{}}` {
+				t.Errorf("Load = %q, %v", got, err)
+			}
+			got[0] = 'Y'
+			if again, _ := s.Load("services"); string(again) != `{"services":{}}` {
+				t.Errorf("Load after changing a loaded copy = %q", again)
+			}
+
+			if err := s.Save("services", []byte(`{}`)); err != nil {
+				t.Fatalf("second Save: %v", err)
+			}
+			if got, _ := s.Load("services"); string(got) != `{}` {
+				t.Errorf("Load after overwrite = %q", got)
+			}
+			if _, err := s.Load("webservices"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load of another name = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		backend string
+		want    string
+		wantErr bool
+	}{
+		{"", "*state.FileStore", false},
+		{BackendFile, "*state.FileStore", false},
+		{BackendMemory, "*state.MemoryStore", false},
+		{"sqlite", "", true},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.LightningRod.Home = t.TempDir()
+		cfg.LightningRod.StateBackend = tt.backend
+
+		s, err := New(cfg)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("New(%q) error = %v, want error %v", tt.backend, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if got := fmt.Sprintf("%T", s); got != tt.want {
+			t.Errorf("New(%q) = %s, want %s", tt.backend, got, tt.want)
+		}
+	}
+}
+
+func TestFileStoreLocation(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = t.TempDir()
+	cfg.LightningRod.RuntimeDir = t.TempDir()
+
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("services", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.LightningRod.RuntimeDir, "services.json")); err != nil {
+		t.Errorf("services.json not in the runtime directory: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sysinfo
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// processRSS returns the resident set size of the agent, replaceable for
+// testing
+var processRSS = func() (uint64, error) {
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0, err
+	}
+	info, err := p.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.RSS, nil
+}
+
+// SelfInfo is the resource footprint of the agent process itself
+type SelfInfo struct {
+	RSS          uint64  `json:"rss,omitempty"`
+	HeapAlloc    uint64  `json:"heap_alloc"`
+	HeapSys      uint64  `json:"heap_sys"`
+	Goroutines   int     `json:"goroutines"`
+	NumGC        uint32  `json:"num_gc"`
+	GCPauseTotal float64 `json:"gc_pause_total_seconds"`
+	GCPauseLast  float64 `json:"gc_pause_last_seconds"`
+}
+
+// Self samples the agent footprint; RSS is left zero where the platform
+// does not report it
+func Self() *SelfInfo {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	self := &SelfInfo{
+		HeapAlloc:    ms.HeapAlloc,
+		HeapSys:      ms.HeapSys,
+		Goroutines:   runtime.NumGoroutine(),
+		NumGC:        ms.NumGC,
+		GCPauseTotal: time.Duration(ms.PauseTotalNs).Seconds(),
+	}
+	if ms.NumGC > 0 {
+		self.GCPauseLast = time.Duration(ms.PauseNs[(ms.NumGC+255)%256]).Seconds()
+	}
+	if rss, err := processRSS(); err == nil {
+		self.RSS = rss
+	}
+
+	return self
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sysinfo
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestSelf(t *testing.T) {
+	runtime.GC()
+
+	self := Self()
+	if self.Goroutines <= 0 {
+		t.Errorf("Goroutines = %d, want > 0", self.Goroutines)
+	}
+	if self.HeapAlloc == 0 || self.HeapSys < self.HeapAlloc {
+		t.Errorf("HeapAlloc = %d, HeapSys = %d", self.HeapAlloc, self.HeapSys)
+	}
+	if self.NumGC == 0 {
+		t.Error("NumGC = 0 after runtime.GC")
+	}
+	if self.GCPauseTotal < self.GCPauseLast {
+		t.Errorf("GCPauseTotal %v < GCPauseLast %v", self.GCPauseTotal, self.GCPauseLast)
+	}
+	if runtime.GOOS == "linux" && self.RSS == 0 {
+		t.Error("RSS = 0 on linux")
+	}
+}
+
+func TestSelfWithoutRSS(t *testing.T) {
+	orig := processRSS
+	defer func() { processRSS = orig }()
+	processRSS = func() (uint64, error) { return 0, errors.New("unsupported") }
+
+	if self := Self(); self.RSS != 0 || self.Goroutines <= 0 {
+		t.Errorf("Self() = %+v", self)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sysinfo
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// SampleInterval is the window used to measure CPU usage
+const SampleInterval = time.Second
+
+// Metric sources, replaceable for testing
+var (
+	cpuPercent    = cpu.PercentWithContext
+	cpuInfo       = cpu.Info
+	cpuCounts     = cpu.Counts
+	virtualMemory = mem.VirtualMemory
+	loadAvg       = load.Avg
+	hostInfo      = host.Info
+
+	// productNameFiles name the board model: the DMI product name on PCs,
+	// the device tree model on ARM boards
+	productNameFiles = []string{
+		"/sys/devices/virtual/dmi/id/product_name",
+		"/sys/firmware/devicetree/base/model",
+	}
+)
+
+// CPUUsage holds the aggregate and per-core CPU usage in percent
+type CPUUsage struct {
+	Percent float64   `json:"percent"`
+	PerCore []float64 `json:"per_core"`
+}
+
+// LoadAverage holds the 1, 5 and 15 minute load averages
+type LoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// CPU samples per-core CPU usage over interval and derives the aggregate;
+// sampling stops early if ctx is cancelled
+func CPU(ctx context.Context, interval time.Duration) (*CPUUsage, error) {
+	perCore, err := cpuPercent(ctx, interval, true)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &CPUUsage{PerCore: perCore}
+	if len(perCore) > 0 {
+		var sum float64
+		for _, p := range perCore {
+			sum += p
+		}
+		usage.Percent = sum / float64(len(perCore))
+	}
+
+	return usage, nil
+}
+
+// Load returns the system load averages, or nil where the platform does
+// not provide them
+func Load() *LoadAverage {
+	avg, err := loadAvg()
+	if err != nil || avg == nil {
+		return nil
+	}
+
+	return &LoadAverage{
+		Load1:  avg.Load1,
+		Load5:  avg.Load5,
+		Load15: avg.Load15,
+	}
+}
+
+// HostInfo describes the host operating system and platform
+type HostInfo struct {
+	Hostname             string `json:"hostname"`
+	Uptime               uint64 `json:"uptime"`
+	BootTime             uint64 `json:"boot_time"`
+	OS                   string `json:"os"`
+	Platform             string `json:"platform"`
+	PlatformFamily       string `json:"platform_family"`
+	PlatformVersion      string `json:"platform_version"`
+	KernelVersion        string `json:"kernel_version"`
+	KernelArch           string `json:"kernel_arch"`
+	VirtualizationSystem string `json:"virtualization_system"`
+	VirtualizationRole   string `json:"virtualization_role"`
+}
+
+// hostCache holds the host info, which only changes across reboots
+var hostCache struct {
+	mu   sync.Mutex
+	info *HostInfo
+}
+
+// Host returns the host info, querying the system only on first use; the
+// uptime is always derived from the current time
+func Host() (*HostInfo, error) {
+	hostCache.mu.Lock()
+	defer hostCache.mu.Unlock()
+
+	if hostCache.info == nil {
+		stat, err := hostInfo()
+		if err != nil {
+			return nil, err
+		}
+		hostCache.info = &HostInfo{
+			Hostname:             stat.Hostname,
+			BootTime:             stat.BootTime,
+			OS:                   stat.OS,
+			Platform:             stat.Platform,
+			PlatformFamily:       stat.PlatformFamily,
+			PlatformVersion:      stat.PlatformVersion,
+			KernelVersion:        stat.KernelVersion,
+			KernelArch:           stat.KernelArch,
+			VirtualizationSystem: stat.VirtualizationSystem,
+			VirtualizationRole:   stat.VirtualizationRole,
+		}
+	}
+
+	info := *hostCache.info
+	if now := uint64(time.Now().Unix()); now > info.BootTime {
+		info.Uptime = now - info.BootTime
+	}
+
+	return &info, nil
+}
+
+// HardwareInfo describes the board hardware; fields the platform does not
+// expose are left empty
+type HardwareInfo struct {
+	CPUModel    string `json:"cpu_model,omitempty"`
+	CPUCount    int    `json:"cpu_count,omitempty"`
+	MemoryTotal uint64 `json:"memory_total,omitempty"`
+	ProductName string `json:"product_name,omitempty"`
+}
+
+// Hardware returns what can be found out about the board hardware
+func Hardware() *HardwareInfo {
+	hw := &HardwareInfo{ProductName: productName()}
+
+	if infos, err := cpuInfo(); err == nil && len(infos) > 0 {
+		hw.CPUModel = infos[0].ModelName
+	}
+	if n, err := cpuCounts(true); err == nil {
+		hw.CPUCount = n
+	}
+	if vmem, err := virtualMemory(); err == nil {
+		hw.MemoryTotal = vmem.Total
+	}
+
+	return hw
+}
+
+// productName returns the first board model found in productNameFiles
+func productName() string {
+	for _, path := range productNameFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// Device tree strings are NUL-terminated
+		if name := strings.TrimSpace(strings.TrimRight(string(data), "\x00")); name != "" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+func TestCPU(t *testing.T) {
+	orig := cpuPercent
+	t.Cleanup(func() { cpuPercent = orig })
+
+	var perCPU bool
+	cpuPercent = func(_ context.Context, _ time.Duration, percpu bool) ([]float64, error) {
+		perCPU = percpu
+		return []float64{10, 20, 30, 40}, nil
+	}
+	usage, err := CPU(context.Background(), time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !perCPU {
+		t.Error("CPU did not sample per core")
+	}
+	if len(usage.PerCore) != 4 || usage.Percent != 25 {
+		t.Errorf("CPU = %+v, want 4 cores averaging 25%%", usage)
+	}
+
+	cpuPercent = func(context.Context, time.Duration, bool) ([]float64, error) {
+		return nil, errors.New("not implemented")
+	}
+	if usage, err := CPU(context.Background(), time.Millisecond); err == nil {
+		t.Errorf("CPU = %+v without a source, want an error", usage)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	orig := loadAvg
+	t.Cleanup(func() { loadAvg = orig })
+
+	loadAvg = func() (*load.AvgStat, error) {
+		return &load.AvgStat{Load1: 0.5, Load5: 0.25, Load15: 0.125}, nil
+	}
+	if got, want := Load(), (&LoadAverage{Load1: 0.5, Load5: 0.25, Load15: 0.125}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+
+	// Platforms without load averages report none
+	loadAvg = func() (*load.AvgStat, error) { return nil, errors.New("not implemented yet") }
+	if got := Load(); got != nil {
+		t.Errorf("Load = %+v without a source, want nil", got)
+	}
+}
+
+func TestHost(t *testing.T) {
+	orig := hostInfo
+	t.Cleanup(func() {
+		hostInfo = orig
+		hostCache.info = nil
+	})
+	hostCache.info = nil
+
+	hostInfo = func() (*host.InfoStat, error) { return nil, errors.New("no host info") }
+	if info, err := Host(); err == nil {
+		t.Fatalf("Host = %+v without a source, want an error", info)
+	}
+
+	calls := 0
+	bootTime := uint64(time.Now().Add(-time.Hour).Unix())
+	hostInfo = func() (*host.InfoStat, error) {
+		calls++
+		return &host.InfoStat{
+			Hostname:             "board-1",
+			Uptime:               1,
+			BootTime:             bootTime,
+			OS:                   "linux",
+			Platform:             "raspbian",
+			PlatformFamily:       "debian",
+			PlatformVersion:      "12",
+			KernelVersion:        "6.1.21-v8+",
+			KernelArch:           "aarch64",
+			VirtualizationSystem: "docker",
+			VirtualizationRole:   "guest",
+		}, nil
+	}
+
+	info, err := Host()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HostInfo{
+		Hostname:             "board-1",
+		Uptime:               info.Uptime,
+		BootTime:             bootTime,
+		OS:                   "linux",
+		Platform:             "raspbian",
+		PlatformFamily:       "debian",
+		PlatformVersion:      "12",
+		KernelVersion:        "6.1.21-v8+",
+		KernelArch:           "aarch64",
+		VirtualizationSystem: "docker",
+		VirtualizationRole:   "guest",
+	}
+	if *info != want {
+		t.Errorf("Host = %+v, want %+v", *info, want)
+	}
+	if info.Uptime < 3600 || info.Uptime > 3660 {
+		t.Errorf("uptime = %d, want about an hour since boot", info.Uptime)
+	}
+
+	if _, err := Host(); err != nil || calls != 1 {
+		t.Errorf("second Host = %v after %d queries, want the cached info", err, calls)
+	}
+}
+
+func TestCPUCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if usage, err := CPU(ctx, 10*time.Second); err == nil {
+		t.Errorf("CPU = %+v after cancellation, want an error", usage)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("CPU sampled for %v after cancellation", elapsed)
+	}
+}
+
+func TestHardware(t *testing.T) {
+	origInfo, origCounts, origMemory, origFiles := cpuInfo, cpuCounts, virtualMemory, productNameFiles
+	t.Cleanup(func() {
+		cpuInfo, cpuCounts, virtualMemory, productNameFiles = origInfo, origCounts, origMemory, origFiles
+	})
+
+	cpuInfo = func() ([]cpu.InfoStat, error) {
+		return []cpu.InfoStat{{ModelName: "ARMv7 Processor rev 4 (v7l)"}}, nil
+	}
+	cpuCounts = func(logical bool) (int, error) {
+		if !logical {
+			t.Error("Hardware counted physical cores, want logical")
+		}
+		return 4, nil
+	}
+	virtualMemory = func() (*mem.VirtualMemoryStat, error) {
+		return &mem.VirtualMemoryStat{Total: 1 << 30}, nil
+	}
+
+	dir := t.TempDir()
+	dmi := filepath.Join(dir, "product_name")
+	model := filepath.Join(dir, "model")
+	if err := os.WriteFile(model, []byte("Raspberry Pi 3 Model B Rev 1.2\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The DMI file is missing, so the device tree model is used
+	productNameFiles = []string{dmi, model}
+	want := &HardwareInfo{
+		CPUModel:    "ARMv7 Processor rev 4 (v7l)",
+		CPUCount:    4,
+		MemoryTotal: 1 << 30,
+		ProductName: "Raspberry Pi 3 Model B Rev 1.2",
+	}
+	if got := Hardware(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Hardware = %+v, want %+v", got, want)
+	}
+
+	// A blank DMI name is skipped, a filled one wins
+	if err := os.WriteFile(dmi, []byte("\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Hardware().ProductName; got != want.ProductName {
+		t.Errorf("ProductName = %q with a blank DMI name, want %q", got, want.ProductName)
+	}
+	if err := os.WriteFile(dmi, []byte("Standard PC (Q35 + ICH9, 2009)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Hardware().ProductName; got != "Standard PC (Q35 + ICH9, 2009)" {
+		t.Errorf("ProductName = %q, want the DMI name", got)
+	}
+
+	// Sources the platform lacks leave their fields empty
+	cpuInfo = func() ([]cpu.InfoStat, error) { return nil, nil }
+	cpuCounts = func(bool) (int, error) { return 0, errors.New("not implemented yet") }
+	virtualMemory = func() (*mem.VirtualMemoryStat, error) { return nil, errors.New("not implemented yet") }
+	productNameFiles = []string{filepath.Join(dir, "missing")}
+	if got := Hardware(); !reflect.DeepEqual(got, &HardwareInfo{}) {
+		t.Errorf("Hardware = %+v without sources, want it empty", got)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+// Package version holds the build metadata, set at link time with
+// -ldflags "-X github.com/MDSLab/iotronic-lightning-rod/internal/version.Version=..."
+package version
+
+import (
+	"runtime"
+)
+
+// Build metadata
+var (
+	Version = "1.0.0"
+	Commit  = "unknown"
+	BuiltAt = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuiltAt   string `json:"built_at"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuiltAt:   BuiltAt,
+		GoVersion: runtime.Version(),
+	}
+}
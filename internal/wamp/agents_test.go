@@ -0,0 +1,155 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"io"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gammazero/nexus/v3/client"
+)
+
+const (
+	agentA = "ws://agent-a.test:8181/"
+	agentB = "ws://agent-b.test:8181/"
+	agentC = "ws://agent-c.test:8181/"
+)
+
+const failoverSettings = `{
+  "iotronic": {
+    "board": {"uuid": "` + testUUID + `", "code": "TESTCODE", "status": "registered"},
+    "wamp": {
+      "main-agent": {"url": "` + agentA + `", "realm": "` + testRealm + `"},
+      "main-agents": [
+        {"url": "` + agentB + `", "realm": "` + testRealm + `"},
+        {"url": "` + agentC + `", "realm": "` + testRealm + `"}
+      ]
+    }
+  }
+}`
+
+// agentDialer records the agents dialed and refuses those in down
+type agentDialer struct {
+	mu     sync.Mutex
+	down   map[string]bool
+	dialed []string
+}
+
+func (d *agentDialer) attempts() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	attempts := d.dialed
+	d.dialed = nil
+	return attempts
+}
+
+func TestConnectPrefersLastGoodAgent(t *testing.T) {
+	r := newTestRouter(t)
+	dialer := &agentDialer{down: map[string]bool{agentA: true}}
+	connectNet = func(ctx context.Context, url string, cfg client.Config) (*client.Client, error) {
+		dialer.mu.Lock()
+		dialer.dialed = append(dialer.dialed, url)
+		down := dialer.down[url]
+		dialer.mu.Unlock()
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		cfg.Logger = stdlog.New(io.Discard, "", 0)
+		return client.ConnectLocal(r, cfg)
+	}
+
+	settings := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(settings, []byte(failoverSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// First start: agent A is down, B takes over
+	cfg, b := loadTestBoard(t, settings)
+	c := NewClient(cfg, b)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if got, want := dialer.attempts(), []string{agentA, agentB}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first start dialed %v, want %v", got, want)
+	}
+	if b.GetWampURL() != agentB {
+		t.Errorf("GetWampURL = %q, want %q", b.GetWampURL(), agentB)
+	}
+	c.Stop()
+
+	// After a restart, with A back up, B is still tried first
+	dialer.down = nil
+	cfg, b = loadTestBoard(t, settings)
+	c = NewClient(cfg, b)
+	t.Cleanup(c.Stop)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect after restart: %v", err)
+	}
+	if got, want := dialer.attempts(), []string{agentB}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restart dialed %v, want %v", got, want)
+	}
+	c.Disconnect()
+
+	// When the last good agent is gone, the list order applies
+	dialer.down = map[string]bool{agentB: true}
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect with B down: %v", err)
+	}
+	if got, want := dialer.attempts(), []string{agentB, agentA}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failover dialed %v, want %v", got, want)
+	}
+}
+
+func TestWampAgentsOrder(t *testing.T) {
+	settings := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(settings, []byte(failoverSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, b := loadTestBoard(t, settings)
+
+	urls := func() []string {
+		var out []string
+		for _, a := range b.WampAgents() {
+			out = append(out, a.URL)
+		}
+		return out
+	}
+	if got, want := urls(), []string{agentA, agentB, agentC}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WampAgents = %v, want %v", got, want)
+	}
+
+	if err := b.SetExtra("last_wamp_agent", agentC); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := urls(), []string{agentC, agentA, agentB}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WampAgents = %v, want %v", got, want)
+	}
+
+	// An agent no longer configured is ignored
+	if err := b.SetExtra("last_wamp_agent", "ws://gone.test:8181/"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := urls(), []string{agentA, agentB, agentC}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WampAgents = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// shortName returns the last dotted segment of a procedure name
+func shortName(procedure string) string {
+	if i := strings.LastIndex(procedure, "."); i >= 0 {
+		return procedure[i+1:]
+	}
+	return procedure
+}
+
+// isAudited reports whether invocations of procedure must be audited
+func (c *Client) isAudited(procedure string) bool {
+	name := shortName(procedure)
+	for _, p := range c.cfg.Audit.Procedures {
+		if p == name || p == procedure {
+			return true
+		}
+	}
+	return false
+}
+
+// auditTopic returns the topic audit events are published to
+func (c *Client) auditTopic() string {
+	return fmt.Sprintf("iotronic.board.%s.audit", c.board.UUID)
+}
+
+// resultOf extracts the outcome of an invocation for the audit record
+func resultOf(res client.InvokeResult) string {
+	if res.Err != "" {
+		return string(res.Err)
+	}
+	if len(res.Args) > 0 {
+		if m, ok := res.Args[0].(map[string]any); ok {
+			if r, ok := m["result"].(string); ok {
+				return r
+			}
+		}
+	}
+	return rpc.ResultSuccess
+}
+
+// auditHandler wraps handler so every invocation publishes an audit event
+func (c *Client) auditHandler(procedure string, handler client.InvocationHandler) client.InvocationHandler {
+	return func(ctx context.Context, inv *wamp.Invocation) client.InvokeResult {
+		res := handler(ctx, inv)
+
+		caller := inv.Details["caller"]
+		event := map[string]any{
+			"procedure": procedure,
+			"caller":    caller,
+			"timestamp": time.Now().Format("2006-01-02T15:04:05.000000"),
+			"result":    resultOf(res),
+		}
+
+		if err := c.Publish(c.auditTopic(), []any{event}, nil); err != nil {
+			log.Warnf("Failed to publish audit event for %s: %v", procedure, err)
+		}
+
+		return res
+	}
+}
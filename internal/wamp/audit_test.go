@@ -0,0 +1,94 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestAuditEvents(t *testing.T) {
+	r := newTestRouter(t)
+	events := subscribe(t, r, "iotronic.board."+testUUID+".audit")
+	c, _ := newTestClient(t)
+	c.cfg.Audit.Procedures = []string{"Write"}
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	if err := c.Register("test.Write", func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Error("denied")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("test.Read", func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("done", nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	call(t, r, "test.Read")
+	call(t, r, "test.Write")
+
+	// The first event is the audited call, the other one published nothing
+	e := nextEvent(t, events)
+	event, _ := e.Arguments[0].(map[string]any)
+	if event["procedure"] != "test.Write" || event["result"] != rpc.ResultError {
+		t.Errorf("audit event = %v, want test.Write with result ERROR", event)
+	}
+	ts, err := time.ParseInLocation("2006-01-02T15:04:05.000000", event["timestamp"].(string), time.Local)
+	if err != nil || ts.Before(start.Truncate(time.Microsecond)) {
+		t.Errorf("timestamp = %v (%v), want the time of the call", event["timestamp"], err)
+	}
+	if _, ok := event["caller"]; !ok {
+		t.Error("audit event has no caller")
+	}
+
+	select {
+	case e := <-events:
+		t.Errorf("unexpected audit event %v", e.Arguments)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestIsAudited(t *testing.T) {
+	c := &Client{cfg: &config.Config{}}
+	c.cfg.Audit.Procedures = []string{"ExecCommand", "iotronic.board.b1.Reboot"}
+
+	tests := []struct {
+		procedure string
+		want      bool
+	}{
+		{"iotronic.board.b1.ExecCommand", true},
+		{"ExecCommand", true},
+		{"iotronic.board.b1.Reboot", true},
+		{"iotronic.board.b2.Reboot", false},
+		{"iotronic.board.b1.GetStatus", false},
+		{"iotronic.board.b1.ExecCommandX", false},
+	}
+	for _, tt := range tests {
+		if got := c.isAudited(tt.procedure); got != tt.want {
+			t.Errorf("isAudited(%q) = %v, want %v", tt.procedure, got, tt.want)
+		}
+	}
+}
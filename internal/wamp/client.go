@@ -17,13 +17,15 @@ package wamp
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
 	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
 	"github.com/gammazero/nexus/v3/client"
 	"github.com/gammazero/nexus/v3/wamp"
 	log "github.com/sirupsen/logrus"
@@ -33,82 +35,197 @@ import (
 type Client struct {
 	mu sync.RWMutex
 
+	// connectMu serializes connect attempts, so mu is not held while
+	// dialing and the connection state stays readable meanwhile
+	connectMu sync.Mutex
+
 	board  *board.Board
 	cfg    *config.Config
 	client *client.Client
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	connected   bool
-	sessionID   wamp.ID
-	reconnTimer *time.Timer
+	connected    bool
+	sessionID    wamp.ID
+	reconnTimer  *time.Timer
+	reconnecting atomic.Bool
+
+	// Connection stability counters, readable without mu
+	connectedAt atomic.Int64
+	connects    atomic.Uint64
+
+	// callStats counts the invocations of each procedure
+	callStats callStats
+
+	// realmRetryAt holds keep-alive reconnects back after realmRejections
+	// consecutive realm rejections
+	realmRejections atomic.Int32
+	realmRetryAt    atomic.Int64
+
+	// diagMu guards diag separately from the connection state
+	diagMu sync.RWMutex
+	diag   Diagnostics
+
+	registry registry
+
+	// maintenance rejects mutating procedures while set
+	maintenance atomic.Bool
+
+	// unsubscribe removes the client's event bus subscriptions
+	unsubscribe func()
+
+	// agent pins a secondary cloud client to one agent, and primary is
+	// the main cloud client it mirrors; both are nil for the main client
+	agent   *config.WampAgent
+	primary *Client
+
+	// fanoutMu guards the secondary cloud clients of the main client and
+	// the registrations replayed on them
+	fanoutMu      sync.Mutex
+	secondaries   []*Client
+	registrations []registration
 }
 
+// connectNet opens the router connection, replaceable for testing
+var connectNet = client.ConnectNet
+
+// ErrReconnectInProgress is returned when a reconnect is already running
+var ErrReconnectInProgress = errors.New("reconnect already in progress")
+
 // NewClient creates a new WAMP client
 func NewClient(cfg *config.Config, board *board.Board) *Client {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Client{
+	c := &Client{
 		board:  board,
 		cfg:    cfg,
 		ctx:    ctx,
 		cancel: cancel,
 	}
+
+	// Board changes are announced off the caller's goroutine, as
+	// publishing waits on the network
+	c.unsubscribe = eventbus.Subscribe(eventbus.Default, eventbus.BoardChanged, func(eventbus.BoardChange) {
+		go c.publishInfo(false)
+	})
+
+	return c
 }
 
 // Connect establishes a connection to the WAMP router
 func (c *Client) Connect() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.connected {
-		return nil
+	connected, err := c.connect()
+	if connected {
+		c.announceState(eventbus.WAMPState{
+			Connected: true,
+			SessionID: uint64(c.GetSessionID()),
+		})
+		if c.primary != nil {
+			c.primary.replayRegistrations(c)
+		} else {
+			c.restoreRegistrations()
+		}
+		c.publishInfo(true)
 	}
+	return err
+}
 
-	wampURL := c.board.GetWampURL()
-	realm := c.board.GetWampRealm()
+// connect establishes the connection and reports whether it opened a new
+// session
+func (c *Client) connect() (bool, error) {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
 
-	if wampURL == "" || realm == "" {
-		return fmt.Errorf("WAMP configuration not available")
+	if c.IsConnected() {
+		return false, nil
 	}
 
-	log.Infof("Connecting to WAMP router: %s (realm: %s)", wampURL, realm)
+	agents := c.board.WampAgents()
+	if c.isSecondary() {
+		agents = []config.WampAgent{*c.agent}
+	}
+	if len(agents) == 0 || agents[0].URL == "" || agents[0].Realm == "" {
+		err := fmt.Errorf("WAMP configuration not available")
+		c.recordAttempt(c.board.GetWampURL(), c.board.GetWampRealm(), err)
+		return false, err
+	}
 
 	// Configure TLS if using wss://
-	cfg := client.Config{
-		Realm: realm,
+	tlsCfg, err := tlsConfig(c.cfg)
+	if err != nil {
+		c.recordAttempt(agents[0].URL, agents[0].Realm, err)
+		return false, err
 	}
 
-	if c.cfg.LightningRod.SkipCertVerify {
-		cfg.TlsCfg = &tls.Config{
-			InsecureSkipVerify: true,
+	// Try the agents in order until one accepts the session
+	var cl *client.Client
+	rejected := true
+	for i, agent := range agents {
+		log.Infof("Connecting to WAMP router: %s (realm: %s)", agent.URL, agent.Realm)
+		cl, err = connectNet(c.ctx, agent.URL, client.Config{Realm: agent.Realm, TlsCfg: tlsCfg, Serialization: serialization})
+		c.recordAttempt(agent.URL, agent.Realm, err)
+		if err == nil {
+			if !c.isSecondary() {
+				c.board.SetActiveAgent(agent)
+			}
+			break
+		}
+		rejected = rejected && isRealmRejection(err)
+		if i < len(agents)-1 {
+			log.Warnf("Failed to connect to WAMP router %s, trying the next agent: %v", agent.URL, err)
 		}
 	}
-
-	// Create client
-	cl, err := client.ConnectNet(c.ctx, wampURL, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WAMP router: %w", err)
+		// Only a rejection by every agent is a configuration problem
+		if rejected {
+			logRealmRejection(agents[len(agents)-1].Realm, c.recordRealmRejection(), err)
+			return false, fmt.Errorf("failed to connect to WAMP router: %w: %v", ErrRealmRejected, err)
+		}
+		return false, fmt.Errorf("failed to connect to WAMP router: %w", err)
+	}
+	c.clearRealmRejection()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Stop may have run while dialing
+	if err := c.ctx.Err(); err != nil {
+		cl.Close()
+		return false, err
 	}
 
 	c.client = cl
 	c.sessionID = cl.ID()
 	c.connected = true
+	c.recordConnect()
+
+	if c.isSecondary() {
+		log.Infof("Connected to secondary cloud %s (session ID: %d)", c.agent.URL, c.sessionID)
+		return true, nil
+	}
 
 	// Update board session ID
 	c.board.SessionID = fmt.Sprintf("%d", c.sessionID)
 
 	log.Infof("Connected to WAMP router (session ID: %d)", c.sessionID)
 
-	return nil
+	return true, nil
 }
 
 // Disconnect closes the WAMP connection
 func (c *Client) Disconnect() error {
+	if c.disconnect() {
+		c.announceState(eventbus.WAMPState{Connected: false})
+	}
+	return nil
+}
+
+// disconnect closes the connection and reports whether one was open
+func (c *Client) disconnect() bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if !c.connected {
-		return nil
+		return false
 	}
 
 	if c.client != nil {
@@ -118,31 +235,113 @@ func (c *Client) Disconnect() error {
 		c.client = nil
 	}
 
+	// Registrations do not survive the session
+	c.registry.reset()
+
 	c.connected = false
+	c.recordDisconnect()
 	log.Info("Disconnected from WAMP router")
 
+	return true
+}
+
+// Register registers an RPC procedure, on the secondary clouds as well
+func (c *Client) Register(procedure string, handler func(context.Context, *wamp.Invocation) client.InvokeResult, opts ...RegisterOption) error {
+	reg, err := c.register(procedure, handler, opts...)
+	if err != nil {
+		return err
+	}
+
+	c.addRegistration(reg)
 	return nil
 }
 
-// Register registers an RPC procedure
-func (c *Client) Register(procedure string, handler func(context.Context, *wamp.Invocation) client.InvokeResult) error {
+// register registers an RPC procedure on this cloud and returns it as
+// wrapped for the secondary clouds
+func (c *Client) register(procedure string, handler func(context.Context, *wamp.Invocation) client.InvokeResult, opts ...RegisterOption) (registration, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	if !c.connected || c.client == nil {
-		return fmt.Errorf("not connected to WAMP router")
+		return registration{}, fmt.Errorf("not connected to WAMP router")
 	}
 
-	if err := c.client.Register(procedure, handler, nil); err != nil {
-		return fmt.Errorf("failed to register procedure %s: %w", procedure, err)
+	var ro registerOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+
+	// Catch duplicates locally instead of relying on the router error
+	if err := c.registry.reserve(procedure, ro.module); err != nil {
+		return registration{}, err
+	}
+
+	if c.cfg.RPC.RecoverPanics {
+		handler = recoverHandler(procedure, handler)
+	}
+
+	if ro.maxConcurrent > 0 {
+		handler = limitHandler(procedure, ro.maxConcurrent, handler)
+	}
+
+	// Reject oversized calls before they take a concurrency slot
+	if c.cfg.RPC.MaxArgumentSize > 0 {
+		handler = sizeLimitHandler(procedure, c.cfg.RPC.MaxArgumentSize, handler)
+	}
+
+	if ro.mutating {
+		handler = c.maintenanceHandler(procedure, handler)
+	}
+
+	var regOpts wamp.Dict
+	if c.isAudited(procedure) {
+		handler = c.auditHandler(procedure, handler)
+		regOpts = wamp.Dict{"disclose_caller": true}
+	}
+
+	handler = c.statsHandler(procedure, handler)
+
+	if err := c.client.Register(procedure, handler, regOpts); err != nil {
+		c.registry.release(procedure)
+		return registration{}, fmt.Errorf("failed to register procedure %s: %w", procedure, err)
 	}
 
 	log.Debugf("Registered RPC procedure: %s", procedure)
-	return nil
+	return registration{
+		procedure: procedure,
+		module:    ro.module,
+		handler:   handler,
+		opts:      regOpts,
+		name:      c.boardProcedure(procedure),
+	}, nil
 }
 
-// Unregister unregisters an RPC procedure
+// Unregister unregisters an RPC procedure, from the secondary clouds as
+// well
 func (c *Client) Unregister(procedure string) error {
+	if err := c.unregister(procedure); err != nil {
+		return err
+	}
+
+	c.removeRegistration(procedure)
+	return nil
+}
+
+// UnregisterModule unregisters every procedure registered by module, on
+// all clouds, so a module that failed to start leaves nothing callable
+// behind
+func (c *Client) UnregisterModule(module string) error {
+	var errs []error
+	for _, procedure := range c.registry.byModule()[module] {
+		if err := c.Unregister(procedure); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unregister unregisters an RPC procedure from this cloud
+func (c *Client) unregister(procedure string) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -153,6 +352,7 @@ func (c *Client) Unregister(procedure string) error {
 	if err := c.client.Unregister(procedure); err != nil {
 		return fmt.Errorf("failed to unregister procedure %s: %w", procedure, err)
 	}
+	c.registry.release(procedure)
 
 	log.Debugf("Unregistered RPC procedure: %s", procedure)
 	return nil
@@ -175,8 +375,22 @@ func (c *Client) Subscribe(topic string, handler func(*wamp.Event)) error {
 	return nil
 }
 
-// Publish publishes a message to a topic
+// Publish publishes a message to a topic, on the secondary clouds as well
 func (c *Client) Publish(topic string, args []any, kwargs map[string]any) error {
+	c.publishSecondaries(topic, wamp.Dict{}, args, kwargs)
+	return c.publish(topic, wamp.Dict{}, args, kwargs)
+}
+
+// PublishAck publishes a message to a topic and waits for the router to
+// acknowledge it; only the main cloud's outcome is returned
+func (c *Client) PublishAck(topic string, args []any, kwargs map[string]any) error {
+	opts := wamp.Dict{wamp.OptAcknowledge: true}
+	c.publishSecondaries(topic, opts, args, kwargs)
+	return c.publish(topic, opts, args, kwargs)
+}
+
+// publish publishes a message to a topic on this cloud only
+func (c *Client) publish(topic string, opts wamp.Dict, args []any, kwargs map[string]any) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -184,7 +398,6 @@ func (c *Client) Publish(topic string, args []any, kwargs map[string]any) error
 		return fmt.Errorf("not connected to WAMP router")
 	}
 
-	opts := wamp.Dict{}
 	if err := c.client.Publish(topic, opts, args, kwargs); err != nil {
 		return fmt.Errorf("failed to publish to topic %s: %w", topic, err)
 	}
@@ -205,7 +418,7 @@ func (c *Client) Call(procedure string, args []any, kwargs map[string]any) (*wam
 	ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
 	defer cancel()
 
-	result, err := c.client.Call(ctx, procedure, nil, args, kwargs, "")
+	result, err := c.client.Call(ctx, procedure, nil, args, kwargs, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call procedure %s: %w", procedure, err)
 	}
@@ -227,9 +440,18 @@ func (c *Client) GetSessionID() wamp.ID {
 	return c.sessionID
 }
 
-// KeepAlive starts a keep-alive routine to monitor connection health
+// IsReconnecting returns whether a reconnect is in progress
+func (c *Client) IsReconnecting() bool {
+	return c.reconnecting.Load()
+}
+
+// newTicker creates the keep-alive ticker, replaceable for testing
+var newTicker = time.NewTicker
+
+// KeepAlive starts a keep-alive routine to monitor connection health,
+// checking every autobahn.keepalive_interval
 func (c *Client) KeepAlive(ctx context.Context) {
-	ticker := time.NewTicker(time.Duration(c.cfg.Autobahn.AliveTimer) * time.Second)
+	ticker := newTicker(time.Duration(c.cfg.Autobahn.KeepaliveInterval) * time.Second)
 	defer ticker.Stop()
 
 	for {
@@ -237,25 +459,62 @@ func (c *Client) KeepAlive(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if !c.IsConnected() {
-				log.Warn("Connection lost, attempting to reconnect...")
-				if err := c.Reconnect(); err != nil {
-					log.Errorf("Reconnection failed: %v", err)
-				}
+			if c.IsConnected() {
+				continue
+			}
+			if wait := c.realmBackoff(); wait > 0 {
+				log.Debugf("Realm rejected, next reconnect attempt in %v", wait.Round(time.Second))
+				continue
 			}
+			log.Warn("Connection lost, attempting to reconnect...")
+			c.ReconnectAsync()
 		}
 	}
 }
 
-// Reconnect attempts to reconnect to the WAMP router
+// ReconnectAsync starts a reconnect in the background and reports whether
+// it did; it returns false if a reconnect is already in progress
+func (c *Client) ReconnectAsync() bool {
+	if !c.reconnecting.CompareAndSwap(false, true) {
+		log.Debug("Reconnect already in progress")
+		return false
+	}
+
+	go func() {
+		defer c.reconnecting.Store(false)
+		if err := c.reconnect(); err != nil {
+			log.Errorf("Reconnection failed: %v", err)
+		}
+	}()
+
+	return true
+}
+
+// Reconnect attempts to reconnect to the WAMP router, failing with
+// ErrReconnectInProgress if another reconnect is already running
 func (c *Client) Reconnect() error {
+	if !c.reconnecting.CompareAndSwap(false, true) {
+		return ErrReconnectInProgress
+	}
+	defer c.reconnecting.Store(false)
+
+	return c.reconnect()
+}
+
+// reconnect performs a reconnect; callers must hold the reconnecting flag
+func (c *Client) reconnect() error {
 	log.Info("Attempting to reconnect to WAMP router...")
 
 	if err := c.Disconnect(); err != nil {
 		log.Warnf("Error during disconnect before reconnect: %v", err)
 	}
 
-	time.Sleep(time.Duration(c.cfg.Autobahn.ConnectionTimer) * time.Second)
+	// Wait before reconnecting, unless the client is being stopped
+	select {
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	case <-time.After(time.Duration(c.cfg.Autobahn.ConnectionTimer) * time.Second):
+	}
 
 	if err := c.Connect(); err != nil {
 		return fmt.Errorf("reconnection failed: %w", err)
@@ -268,5 +527,6 @@ func (c *Client) Reconnect() error {
 // Stop stops the WAMP client
 func (c *Client) Stop() {
 	c.cancel()
+	c.unsubscribe()
 	c.Disconnect()
 }
@@ -0,0 +1,205 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"io"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/router"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+const (
+	testRealm = "s4t"
+	testUUID  = "8a6ce9e4-3c8d-4b44-9a86-0b4e8a8f9c11"
+)
+
+const testSettings = `{
+  "iotronic": {
+    "board": {
+      "uuid": "` + testUUID + `",
+      "code": "TESTCODE",
+      "name": "board-1",
+      "status": "registered",
+      "type": "server",
+      "agent": "agent-1",
+      "location": {"latitude": "38.19", "longitude": "15.55"}
+    },
+    "wamp": {
+      "main-agent": {"url": "ws://router.test:8181/", "realm": "` + testRealm + `"}
+    }
+  }
+}`
+
+// newTestBoard loads a board from a temporary settings.json
+func newTestBoard(t *testing.T) (*config.Config, *board.Board) {
+	t.Helper()
+
+	dir := t.TempDir()
+	settings := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(settings, []byte(testSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return loadTestBoard(t, settings)
+}
+
+// loadTestBoard loads a board from the settings file at settings
+func loadTestBoard(t *testing.T, settings string) (*config.Config, *board.Board) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.LightningRod.Home = filepath.Dir(settings)
+	cfg.Board.SettingsFile = settings
+	cfg.Board.SettingsReadAttempts = 1
+
+	b, err := board.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to load board: %v", err)
+	}
+	return cfg, b
+}
+
+// newTestRouter starts an in-process router and makes the clients created
+// by the test connect to it
+func newTestRouter(t *testing.T) router.Router {
+	t.Helper()
+
+	r, err := router.NewRouter(&router.Config{
+		RealmConfigs: []*router.RealmConfig{{
+			URI:           wamp.URI(testRealm),
+			AnonymousAuth: true,
+			AllowDisclose: true,
+		}},
+	}, stdlog.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+
+	orig := connectNet
+	connectNet = func(ctx context.Context, _ string, cfg client.Config) (*client.Client, error) {
+		cfg.Logger = stdlog.New(io.Discard, "", 0)
+		return client.ConnectLocal(r, cfg)
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	return r
+}
+
+// newTestClient creates a connected client for a test board
+func newTestClient(t *testing.T) (*Client, *board.Board) {
+	t.Helper()
+
+	cfg, b := newTestBoard(t)
+	c := NewClient(cfg, b)
+	t.Cleanup(c.Stop)
+	return c, b
+}
+
+// subscribe listens to topic on r from a separate session
+func subscribe(t *testing.T, r router.Router, topic string) <-chan *wamp.Event {
+	t.Helper()
+
+	sub, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sub.Close() })
+
+	events := make(chan *wamp.Event, 16)
+	if err := sub.Subscribe(topic, func(e *wamp.Event) { events <- e }, nil); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+// nextEvent waits for an event on events
+func nextEvent(t *testing.T, events <-chan *wamp.Event) *wamp.Event {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return nil
+	}
+}
+
+func TestInfoPublishedOnConnect(t *testing.T) {
+	r := newTestRouter(t)
+	events := subscribe(t, r, "iotronic.board."+testUUID+".info")
+
+	c, _ := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	e := nextEvent(t, events)
+	if len(e.Arguments) != 1 {
+		t.Fatalf("got %d arguments, want 1", len(e.Arguments))
+	}
+	info, ok := e.Arguments[0].(map[string]any)
+	if !ok {
+		t.Fatalf("unexpected payload %T", e.Arguments[0])
+	}
+
+	want := map[string]string{
+		"uuid":   testUUID,
+		"name":   "board-1",
+		"type":   "server",
+		"status": "registered",
+		"agent":  "agent-1",
+	}
+	for k, v := range want {
+		if info[k] != v {
+			t.Errorf("%s = %v, want %q", k, info[k], v)
+		}
+	}
+	location, _ := info["location"].(map[string]any)
+	if location["latitude"] != "38.19" {
+		t.Errorf("location = %v, want the board location", info["location"])
+	}
+}
+
+func TestInfoPublishedOnChange(t *testing.T) {
+	r := newTestRouter(t)
+	events := subscribe(t, r, "iotronic.board."+testUUID+".info")
+
+	c, b := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	nextEvent(t, events)
+
+	if _, err := b.SetName("board-2"); err != nil {
+		t.Fatalf("SetName: %v", err)
+	}
+
+	info, _ := nextEvent(t, events).Arguments[0].(map[string]any)
+	if info["name"] != "board-2" {
+		t.Errorf("name = %v, want board-2", info["name"])
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/board"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+	"github.com/MDSLab/iotronic-lightning-rod/internal/eventbus"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// registration is a procedure registered on the main cloud, kept so it
+// can be registered on the secondary clouds as they connect
+type registration struct {
+	procedure string
+	module    string
+	handler   client.InvocationHandler
+	opts      wamp.Dict
+
+	// name is the short name of a board procedure, renamed after each
+	// cloud's session; empty for procedures registered verbatim
+	name string
+}
+
+// NewSecondaryClient creates a client reporting the board to the
+// secondary cloud behind agent. It connects and reconnects independently
+// of the main cloud client it is attached to with AddSecondary.
+func NewSecondaryClient(cfg *config.Config, board *board.Board, agent config.WampAgent) *Client {
+	c := NewClient(cfg, board)
+	c.agent = &agent
+	return c
+}
+
+// AddSecondary attaches the secondary cloud client s to c: procedures
+// registered and events published on c are mirrored on s while it is
+// connected, and every procedure registered so far is registered on s
+// each time it connects
+func (c *Client) AddSecondary(s *Client) {
+	s.primary = c
+
+	c.fanoutMu.Lock()
+	c.secondaries = append(c.secondaries, s)
+	c.fanoutMu.Unlock()
+}
+
+// Secondaries returns the secondary cloud clients attached to c
+func (c *Client) Secondaries() []*Client {
+	c.fanoutMu.Lock()
+	defer c.fanoutMu.Unlock()
+	return append([]*Client(nil), c.secondaries...)
+}
+
+// Agent returns the agent a secondary cloud client is pinned to, or nil
+// for the main cloud client
+func (c *Client) Agent() *config.WampAgent {
+	return c.agent
+}
+
+// isSecondary reports whether c reports to a secondary cloud
+func (c *Client) isSecondary() bool {
+	return c.agent != nil
+}
+
+// addRegistration records reg, replacing an earlier registration of the
+// same procedure, and registers it on the connected secondary clouds
+func (c *Client) addRegistration(reg registration) {
+	c.fanoutMu.Lock()
+	replaced := false
+	for i := range c.registrations {
+		if c.registrations[i].procedure == reg.procedure {
+			c.registrations[i] = reg
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		c.registrations = append(c.registrations, reg)
+	}
+	secondaries := append([]*Client(nil), c.secondaries...)
+	c.fanoutMu.Unlock()
+
+	for _, s := range secondaries {
+		if !s.IsConnected() {
+			continue
+		}
+		if err := s.registerMirrored(reg); err != nil {
+			log.Warnf("Failed to register %s on secondary cloud %s: %v", reg.procedure, s.agent.URL, err)
+		}
+	}
+}
+
+// removeRegistration forgets procedure and unregisters it from the
+// connected secondary clouds
+func (c *Client) removeRegistration(procedure string) {
+	c.fanoutMu.Lock()
+	var reg *registration
+	for i := range c.registrations {
+		if c.registrations[i].procedure == procedure {
+			r := c.registrations[i]
+			reg = &r
+			c.registrations = append(c.registrations[:i], c.registrations[i+1:]...)
+			break
+		}
+	}
+	secondaries := append([]*Client(nil), c.secondaries...)
+	c.fanoutMu.Unlock()
+
+	if reg == nil {
+		return
+	}
+	for _, s := range secondaries {
+		if !s.IsConnected() {
+			continue
+		}
+		if err := s.Unregister(s.mirroredName(*reg)); err != nil {
+			log.Warnf("Failed to unregister %s on secondary cloud %s: %v", procedure, s.agent.URL, err)
+		}
+	}
+}
+
+// replayRegistrations registers on the secondary cloud client s every
+// procedure registered on c so far
+func (c *Client) replayRegistrations(s *Client) {
+	c.fanoutMu.Lock()
+	regs := append([]registration(nil), c.registrations...)
+	c.fanoutMu.Unlock()
+
+	for _, reg := range regs {
+		// A registration fanned out concurrently is already in place
+		if err := s.registerMirrored(reg); err != nil && !errors.Is(err, ErrAlreadyRegistered) {
+			log.Warnf("Failed to register %s on secondary cloud %s: %v", reg.procedure, s.agent.URL, err)
+		}
+	}
+	if len(regs) > 0 {
+		log.Infof("Registered %d procedures on secondary cloud %s", len(regs), s.agent.URL)
+	}
+}
+
+// restoreRegistrations registers again every procedure recorded on the
+// main cloud client, as registrations do not survive a session; board
+// procedures move to the new session's name
+func (c *Client) restoreRegistrations() {
+	c.fanoutMu.Lock()
+	regs := append([]registration(nil), c.registrations...)
+	c.fanoutMu.Unlock()
+
+	restored := 0
+	for _, reg := range regs {
+		procedure := c.mirroredName(reg)
+		// A registration made concurrently is already in place
+		if err := c.registerMirrored(reg); err != nil && !errors.Is(err, ErrAlreadyRegistered) {
+			log.Warnf("Failed to restore registration of %s: %v", procedure, err)
+			continue
+		}
+		restored++
+		if procedure != reg.procedure {
+			c.renameRegistration(reg.procedure, procedure)
+		}
+	}
+	if restored > 0 {
+		log.Infof("Restored %d procedure registrations", restored)
+	}
+}
+
+// renameRegistration records that the procedure registered as from is now
+// registered as to
+func (c *Client) renameRegistration(from, to string) {
+	c.fanoutMu.Lock()
+	defer c.fanoutMu.Unlock()
+
+	for i := range c.registrations {
+		if c.registrations[i].procedure == from {
+			c.registrations[i].procedure = to
+			return
+		}
+	}
+}
+
+// mirroredName returns the name reg is registered under on this cloud:
+// board procedures carry this cloud's session ID
+func (c *Client) mirroredName(reg registration) string {
+	if reg.name == "" {
+		return reg.procedure
+	}
+	return procedureName(c.cfg.Autobahn.RPCPrefix, fmt.Sprintf("%d", c.GetSessionID()), c.board.UUID, reg.name)
+}
+
+// registerMirrored registers the already wrapped handler of reg, so
+// concurrency limits, maintenance mode and call statistics are shared
+// with the main cloud and across its sessions
+func (c *Client) registerMirrored(reg registration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.connected || c.client == nil {
+		return fmt.Errorf("not connected to WAMP router")
+	}
+
+	procedure := c.mirroredName(reg)
+	if err := c.registry.reserve(procedure, reg.module); err != nil {
+		return err
+	}
+	if err := c.client.Register(procedure, reg.handler, reg.opts); err != nil {
+		c.registry.release(procedure)
+		return fmt.Errorf("failed to register procedure %s: %w", procedure, err)
+	}
+
+	if c.isSecondary() {
+		log.Debugf("Registered RPC procedure on secondary cloud: %s", procedure)
+	} else {
+		log.Debugf("Registered RPC procedure: %s", procedure)
+	}
+	return nil
+}
+
+// boardProcedure returns the short name of procedure if it is a board
+// procedure of the current session, or ""
+func (c *Client) boardProcedure(procedure string) string {
+	prefix := c.Procedure("")
+	if !strings.HasPrefix(procedure, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(procedure, prefix)
+}
+
+// publishSecondaries publishes to every connected secondary cloud; a
+// cloud that is down is skipped so it does not hold up the others
+func (c *Client) publishSecondaries(topic string, opts wamp.Dict, args []any, kwargs map[string]any) {
+	for _, s := range c.Secondaries() {
+		if !s.IsConnected() {
+			continue
+		}
+		if err := s.publish(topic, opts, args, kwargs); err != nil {
+			log.Warnf("Failed to publish to secondary cloud %s: %v", s.agent.URL, err)
+		}
+	}
+}
+
+// announceState publishes the connection state of the main cloud on the
+// event bus; secondary clouds do not change the board's WAMP state
+func (c *Client) announceState(state eventbus.WAMPState) {
+	if c.isSecondary() {
+		return
+	}
+	eventbus.Publish(eventbus.Default, eventbus.WAMPStateChanged, state)
+}
+
+// ConnectSecondaries connects every attached secondary cloud, logging
+// the ones that are down; keep-alive keeps retrying them
+func (c *Client) ConnectSecondaries() {
+	for _, s := range c.Secondaries() {
+		if err := s.Connect(); err != nil {
+			log.Warnf("Secondary cloud %s unavailable: %v", s.agent.URL, err)
+		}
+	}
+}
+
+// KeepAliveSecondaries runs keep-alive for every attached secondary
+// cloud until ctx is done
+func (c *Client) KeepAliveSecondaries(ctx context.Context) {
+	for _, s := range c.Secondaries() {
+		go s.KeepAlive(ctx)
+	}
+}
+
+// StopSecondaries stops every attached secondary cloud client
+func (c *Client) StopSecondaries() {
+	for _, s := range c.Secondaries() {
+		s.Stop()
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/router"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+const (
+	mainCloud      = "ws://main-cloud.test:8181/"
+	secondaryCloud = "ws://secondary-cloud.test:8181/"
+)
+
+const cloudsSettings = `{
+  "iotronic": {
+    "board": {"uuid": "` + testUUID + `", "code": "TESTCODE", "status": "registered"},
+    "wamp": {
+      "main-agent": {"url": "` + mainCloud + `", "realm": "` + testRealm + `"},
+      "secondary-agents": [
+        {"url": "` + secondaryCloud + `", "realm": "` + testRealm + `"}
+      ]
+    }
+  }
+}`
+
+// cloudDialer routes every agent URL to its own router and refuses the
+// clouds marked down
+type cloudDialer struct {
+	mu      sync.Mutex
+	routers map[string]router.Router
+	down    map[string]bool
+}
+
+func (d *cloudDialer) setDown(url string, down bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.down[url] = down
+}
+
+func (d *cloudDialer) connect(ctx context.Context, url string, cfg client.Config) (*client.Client, error) {
+	d.mu.Lock()
+	r, down := d.routers[url], d.down[url]
+	d.mu.Unlock()
+	if down || r == nil {
+		return nil, errors.New("connection refused")
+	}
+	cfg.Logger = stdlog.New(io.Discard, "", 0)
+	return client.ConnectLocal(r, cfg)
+}
+
+// tryCall calls procedure on r, returning the router error if any
+func tryCall(t *testing.T, r router.Router, procedure string) error {
+	t.Helper()
+
+	caller, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+
+	_, err = caller.Call(context.Background(), procedure, nil, nil, nil, nil)
+	return err
+}
+
+func TestSecondaryCloud(t *testing.T) {
+	mainRouter := newTestRouter(t)
+	secondaryRouter := newTestRouter(t)
+	dialer := &cloudDialer{
+		routers: map[string]router.Router{mainCloud: mainRouter, secondaryCloud: secondaryRouter},
+		down:    map[string]bool{},
+	}
+	connectNet = dialer.connect
+
+	settings := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(settings, []byte(cloudsSettings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, b := loadTestBoard(t, settings)
+
+	agents := b.SecondaryAgents()
+	if len(agents) != 1 || agents[0].URL != secondaryCloud {
+		t.Fatalf("SecondaryAgents = %+v, want %s", agents, secondaryCloud)
+	}
+
+	c := NewClient(cfg, b)
+	s := NewSecondaryClient(cfg, b, agents[0])
+	c.AddSecondary(s)
+	t.Cleanup(c.Stop)
+	t.Cleanup(c.StopSecondaries)
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	c.ConnectSecondaries()
+	if !s.IsConnected() {
+		t.Fatal("secondary cloud not connected")
+	}
+	if b.GetWampURL() != mainCloud {
+		t.Errorf("GetWampURL = %q, want the main cloud %q", b.GetWampURL(), mainCloud)
+	}
+
+	ok := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("pong", nil)
+	}
+	secondaryName := func(name string) string {
+		return procedureName("", fmt.Sprintf("%d", s.GetSessionID()), testUUID, name)
+	}
+
+	// Registrations reach both clouds, each under its own session
+	if err := c.Register(c.Procedure("Ping"), ok, WithModule("device")); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if res := call(t, mainRouter, c.Procedure("Ping")); res["result"] != rpc.ResultSuccess {
+		t.Errorf("main cloud Ping = %v", res)
+	}
+	if res := call(t, secondaryRouter, secondaryName("Ping")); res["result"] != rpc.ResultSuccess {
+		t.Errorf("secondary cloud Ping = %v", res)
+	}
+
+	// Publications reach both clouds
+	mainEvents := subscribe(t, mainRouter, "iotronic.test.topic")
+	secondaryEvents := subscribe(t, secondaryRouter, "iotronic.test.topic")
+	if err := c.Publish("iotronic.test.topic", []any{"hello"}, nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	nextEvent(t, mainEvents)
+	nextEvent(t, secondaryEvents)
+
+	// The secondary cloud goes down: the main cloud is unaffected
+	dialer.setDown(secondaryCloud, true)
+	s.Disconnect()
+	if err := c.Register(c.Procedure("Status"), ok); err != nil {
+		t.Fatalf("Register with secondary cloud down: %v", err)
+	}
+	if err := c.Publish("iotronic.test.topic", []any{"again"}, nil); err != nil {
+		t.Fatalf("Publish with secondary cloud down: %v", err)
+	}
+	nextEvent(t, mainEvents)
+	if res := call(t, mainRouter, c.Procedure("Status")); res["result"] != rpc.ResultSuccess {
+		t.Errorf("main cloud Status = %v", res)
+	}
+	if err := s.Connect(); err == nil {
+		t.Fatal("secondary cloud connected while down")
+	}
+	if !c.IsConnected() {
+		t.Fatal("main cloud disconnected by the secondary cloud outage")
+	}
+
+	// Back up, the secondary cloud gets every registration made meanwhile
+	dialer.setDown(secondaryCloud, false)
+	if err := s.Connect(); err != nil {
+		t.Fatalf("secondary reconnect: %v", err)
+	}
+	for _, name := range []string{"Ping", "Status"} {
+		if res := call(t, secondaryRouter, secondaryName(name)); res["result"] != rpc.ResultSuccess {
+			t.Errorf("secondary cloud %s after reconnect = %v", name, res)
+		}
+	}
+
+	// The main cloud goes down: the secondary cloud keeps serving
+	mainPing := c.Procedure("Ping")
+	c.Disconnect()
+	if err := tryCall(t, mainRouter, mainPing); err == nil {
+		t.Error("main cloud still serving Ping after disconnect")
+	}
+	if res := call(t, secondaryRouter, secondaryName("Ping")); res["result"] != rpc.ResultSuccess {
+		t.Errorf("secondary cloud Ping with main cloud down = %v", res)
+	}
+
+	// Unregistering removes the procedure from the secondary cloud too
+	if err := c.Connect(); err != nil {
+		t.Fatalf("main reconnect: %v", err)
+	}
+	if err := c.Register(c.Procedure("Temp"), ok); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := c.Unregister(c.Procedure("Temp")); err != nil {
+		t.Fatalf("Unregister: %v", err)
+	}
+	if err := tryCall(t, secondaryRouter, secondaryName("Temp")); err == nil {
+		t.Error("unregistered procedure still callable on the secondary cloud")
+	}
+}
+
+func TestNoSecondaryAgentsByDefault(t *testing.T) {
+	_, b := newTestBoard(t)
+	if agents := b.SecondaryAgents(); len(agents) != 0 {
+		t.Errorf("SecondaryAgents = %+v, want none", agents)
+	}
+}
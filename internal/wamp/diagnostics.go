@@ -0,0 +1,101 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/gammazero/nexus/v3/transport/serialize"
+)
+
+// serialization is the WAMP serialization offered to the router. Results are
+// built as JSON, so it is fixed rather than negotiated
+var serialization = serialize.JSON
+
+// serializerName names s as in the WAMP subprotocols
+func serializerName(s serialize.Serialization) string {
+	switch s {
+	case serialize.JSON:
+		return "json"
+	case serialize.MSGPACK:
+		return "msgpack"
+	case serialize.CBOR:
+		return "cbor"
+	}
+	return "auto"
+}
+
+// Diagnostics describes the last attempt to connect to the WAMP router
+type Diagnostics struct {
+	URL        string `json:"url"`
+	Realm      string `json:"realm"`
+	Serializer string `json:"serializer"`
+	TLSMode    string `json:"tls_mode"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+
+	// RealmRejected marks a router refusing the realm, a configuration
+	// problem rather than an outage; RetryAt is when keep-alive tries again
+	RealmRejected bool       `json:"realm_rejected,omitempty"`
+	RetryAt       *time.Time `json:"retry_at,omitempty"`
+	Timestamp     time.Time  `json:"timestamp"`
+}
+
+// tlsMode describes how the connection to wampURL is secured
+func tlsMode(wampURL string, skipVerify bool) string {
+	u, err := url.Parse(wampURL)
+	if err != nil || (u.Scheme != "wss" && u.Scheme != "https") {
+		return "none"
+	}
+	if skipVerify {
+		return "insecure"
+	}
+	return "verified"
+}
+
+// recordAttempt stores the outcome of a connect attempt
+func (c *Client) recordAttempt(wampURL, realm string, err error) {
+	d := Diagnostics{
+		URL:        wampURL,
+		Realm:      realm,
+		Serializer: serializerName(serialization),
+		TLSMode:    tlsMode(wampURL, c.cfg.LightningRod.SkipCertVerify),
+		Success:    err == nil,
+		Timestamp:  time.Now(),
+	}
+	if err != nil {
+		d.Error = err.Error()
+		d.RealmRejected = isRealmRejection(err)
+	}
+
+	c.diagMu.Lock()
+	c.diag = d
+	c.diagMu.Unlock()
+}
+
+// Diagnostics returns the details of the last connect attempt
+func (c *Client) Diagnostics() Diagnostics {
+	c.diagMu.RLock()
+	d := c.diag
+	c.diagMu.RUnlock()
+
+	if at := c.realmRetryAt.Load(); d.RealmRejected && at != 0 {
+		retry := time.Unix(0, at)
+		d.RetryAt = &retry
+	}
+	return d
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/transport/serialize"
+)
+
+func TestDiagnosticsFailedConnect(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	var dialed client.Config
+	orig := connectNet
+	connectNet = func(_ context.Context, _ string, cfg client.Config) (*client.Client, error) {
+		dialed = cfg
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	start := time.Now()
+	if err := c.Connect(); err == nil {
+		t.Fatal("Connect succeeded against a refusing router")
+	}
+
+	d := c.Diagnostics()
+	if d.URL != c.board.GetWampURL() || d.Realm != dialed.Realm {
+		t.Errorf("diagnostics target = %s %s, want %s %s", d.URL, d.Realm, c.board.GetWampURL(), dialed.Realm)
+	}
+	if d.Success || d.Error != "dial tcp: connection refused" {
+		t.Errorf("diagnostics outcome = success %v, error %q", d.Success, d.Error)
+	}
+	if d.Serializer != serializerName(dialed.Serialization) || d.Serializer != "json" {
+		t.Errorf("serializer = %q, connection used %q", d.Serializer, serializerName(dialed.Serialization))
+	}
+	if d.TLSMode != "none" {
+		t.Errorf("tls_mode = %q for %s", d.TLSMode, d.URL)
+	}
+	if d.Timestamp.Before(start) {
+		t.Errorf("timestamp %v predates the attempt", d.Timestamp)
+	}
+}
+
+func TestTLSMode(t *testing.T) {
+	tests := []struct {
+		url        string
+		skipVerify bool
+		want       string
+	}{
+		{"ws://router:8181/", false, "none"},
+		{"wss://router:8181/", false, "verified"},
+		{"wss://router:8181/", true, "insecure"},
+		{"://bad", false, "none"},
+	}
+	for _, tt := range tests {
+		if got := tlsMode(tt.url, tt.skipVerify); got != tt.want {
+			t.Errorf("tlsMode(%q, %v) = %q, want %q", tt.url, tt.skipVerify, got, tt.want)
+		}
+	}
+}
+
+func TestSerializerName(t *testing.T) {
+	for s, want := range map[serialize.Serialization]string{
+		serialize.AUTO:    "auto",
+		serialize.JSON:    "json",
+		serialize.MSGPACK: "msgpack",
+		serialize.CBOR:    "cbor",
+	} {
+		if got := serializerName(s); got != want {
+			t.Errorf("serializerName(%d) = %q, want %q", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// RegisterOption customizes how a procedure is registered
+type RegisterOption func(*registerOptions)
+
+type registerOptions struct {
+	maxConcurrent int
+	module        string
+	mutating      bool
+}
+
+// WithConcurrencyLimit bounds the in-flight invocations of a procedure;
+// invocations over the limit get a BUSY error result. 0 means unlimited.
+func WithConcurrencyLimit(n int) RegisterOption {
+	return func(o *registerOptions) {
+		o.maxConcurrent = n
+	}
+}
+
+// WithModule records the module a procedure belongs to, for discovery
+func WithModule(name string) RegisterOption {
+	return func(o *registerOptions) {
+		o.module = name
+	}
+}
+
+// limitHandler wraps handler so at most n invocations run concurrently
+func limitHandler(procedure string, n int, handler client.InvocationHandler) client.InvocationHandler {
+	sem := make(chan struct{}, n)
+
+	return func(ctx context.Context, inv *wamp.Invocation) client.InvokeResult {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			return handler(ctx, inv)
+		default:
+			log.Warnf("Rejecting invocation of %s: %d invocations already in flight", procedure, n)
+			return rpc.ErrorCode("BUSY", fmt.Sprintf("Too many concurrent invocations of %s, retry later", shortName(procedure)))
+		}
+	}
+}
+
+// recoverHandler wraps handler so a panic is logged with its stack and
+// turned into an INTERNAL error result instead of escaping to nexus
+func recoverHandler(procedure string, handler client.InvocationHandler) client.InvocationHandler {
+	return func(ctx context.Context, inv *wamp.Invocation) (res client.InvokeResult) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Errorf("RPC handler %s panicked: %v\n%s", procedure, r, debug.Stack())
+				res = rpc.ErrorCode("INTERNAL", fmt.Sprintf("Internal error while handling %s", shortName(procedure)))
+			}
+		}()
+
+		return handler(ctx, inv)
+	}
+}
+
+// argumentSize returns the JSON-serialized size of the invocation arguments
+func argumentSize(inv *wamp.Invocation) (int, error) {
+	args, err := json.Marshal(inv.Arguments)
+	if err != nil {
+		return 0, err
+	}
+	kwargs, err := json.Marshal(inv.ArgumentsKw)
+	if err != nil {
+		return 0, err
+	}
+	return len(args) + len(kwargs), nil
+}
+
+// sizeLimitHandler wraps handler so invocations whose arguments serialize
+// to more than limit bytes get a TOO_LARGE error result
+func sizeLimitHandler(procedure string, limit int, handler client.InvocationHandler) client.InvocationHandler {
+	return func(ctx context.Context, inv *wamp.Invocation) client.InvokeResult {
+		size, err := argumentSize(inv)
+		if err != nil {
+			return rpc.ErrorCode("INVALID_ARGUMENT", fmt.Sprintf("Unserializable arguments for %s: %v", shortName(procedure), err))
+		}
+		if size > limit {
+			log.Warnf("Rejecting invocation of %s: arguments are %d bytes (max %d)", procedure, size, limit)
+			return rpc.ErrorCode("TOO_LARGE", fmt.Sprintf("Arguments of %s are %d bytes, limit is %d", shortName(procedure), size, limit))
+		}
+		return handler(ctx, inv)
+	}
+}
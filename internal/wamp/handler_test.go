@@ -0,0 +1,227 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"io"
+	stdlog "log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+// codeOf returns the code of an error result, empty for a success
+func codeOf(res client.InvokeResult) string {
+	envelope, _ := res.Args[0].(map[string]any)
+	code, _ := envelope["code"].(string)
+	return code
+}
+
+func TestLimitHandler(t *testing.T) {
+	const calls = 8
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	slow := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		entered <- struct{}{}
+		<-release
+		return rpc.Success("done", nil)
+	}
+	handler := limitHandler("iotronic.board.b1.ExecCommand", 1, slow)
+
+	// The first invocation takes the only slot and holds it
+	first := make(chan client.InvokeResult, 1)
+	go func() { first <- handler(context.Background(), &wamp.Invocation{}) }()
+	<-entered
+
+	var wg sync.WaitGroup
+	results := make(chan client.InvokeResult, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- handler(context.Background(), &wamp.Invocation{})
+		}()
+	}
+	wg.Wait()
+	close(results)
+	for res := range results {
+		if code := codeOf(res); code != "BUSY" {
+			t.Errorf("invocation over the limit: code %q, want BUSY", code)
+		}
+	}
+
+	close(release)
+	if code := codeOf(<-first); code != "" {
+		t.Errorf("first invocation: code %q, want success", code)
+	}
+
+	// The slot is free again once the invocation returns
+	go func() { <-entered }()
+	if code := codeOf(handler(context.Background(), &wamp.Invocation{})); code != "" {
+		t.Errorf("invocation after release: code %q, want success", code)
+	}
+}
+
+func TestConcurrencyLimitRegistered(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	if err := c.Register("test.Slow", func(context.Context, *wamp.Invocation) client.InvokeResult {
+		entered <- struct{}{}
+		<-release
+		return rpc.Success("done", nil)
+	}, WithConcurrencyLimit(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	caller, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+	first := make(chan *wamp.Result, 1)
+	go func() {
+		res, _ := caller.Call(context.Background(), "test.Slow", nil, nil, nil, nil)
+		first <- res
+	}()
+	<-entered
+
+	if res := call(t, r, "test.Slow"); res["code"] != "BUSY" {
+		t.Errorf("second call = %v, want BUSY", res)
+	}
+	close(release)
+	if res := <-first; res == nil || res.Arguments[0].(map[string]any)["result"] != rpc.ResultSuccess {
+		t.Errorf("first call = %v, want success", res)
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	var m map[string]any
+	panicking := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		m["type"] = "server" // assignment to a nil map
+		return rpc.Success("unreachable", nil)
+	}
+
+	res := recoverHandler("iotronic.board.b1.GetInfo", panicking)(context.Background(), &wamp.Invocation{})
+	envelope, _ := res.Args[0].(map[string]any)
+	if envelope["result"] != rpc.ResultError || envelope["code"] != "INTERNAL" {
+		t.Fatalf("result = %v, want an INTERNAL error", envelope)
+	}
+	if envelope["message"] != "Internal error while handling GetInfo" {
+		t.Errorf("message = %q", envelope["message"])
+	}
+}
+
+func TestPanicRecoveredOverRouter(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	c.cfg.RPC.RecoverPanics = true
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	if err := c.Register("test.Panic", func(context.Context, *wamp.Invocation) client.InvokeResult {
+		panic("boom")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("test.Ping", func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("pong", nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := call(t, r, "test.Panic"); res["code"] != "INTERNAL" {
+		t.Errorf("test.Panic = %v, want an INTERNAL error", res)
+	}
+	// The session survives the panic
+	if !c.IsConnected() {
+		t.Error("client disconnected by a panicking handler")
+	}
+	if res := call(t, r, "test.Ping"); res["result"] != rpc.ResultSuccess {
+		t.Errorf("test.Ping after the panic = %v", res)
+	}
+}
+
+func TestSizeLimitHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     wamp.List
+		kwargs   wamp.Dict
+		wantCode string
+	}{
+		{"no arguments", nil, nil, ""},
+		{"small arguments", wamp.List{"ssh", 22}, wamp.Dict{"target_host": "127.0.0.1"}, ""},
+		{"oversized argument", wamp.List{strings.Repeat("x", 2048)}, nil, "TOO_LARGE"},
+		{"oversized kwarg", nil, wamp.Dict{"config": strings.Repeat("x", 2048)}, "TOO_LARGE"},
+		{"unserializable argument", wamp.List{func() {}}, nil, "INVALID_ARGUMENT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := sizeLimitHandler("iotronic.board.b1.ConfigUpdate", 1024, func(context.Context, *wamp.Invocation) client.InvokeResult {
+				called = true
+				return rpc.Success("done", nil)
+			})
+			res := handler(context.Background(), &wamp.Invocation{Arguments: tt.args, ArgumentsKw: tt.kwargs})
+			if code := codeOf(res); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			if called != (tt.wantCode == "") {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCode == "")
+			}
+		})
+	}
+}
+
+func TestArgumentSizeLimitRegistered(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	c.cfg.RPC.MaxArgumentSize = 1024
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := c.Register("test.Echo", func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("done", nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	caller, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+	for size, want := range map[int]string{16: rpc.ResultSuccess, 4096: rpc.ResultError} {
+		res, err := caller.Call(context.Background(), "test.Echo", nil, wamp.List{strings.Repeat("x", size)}, nil, nil)
+		if err != nil {
+			t.Fatalf("Call with %d bytes: %v", size, err)
+		}
+		if envelope := res.Arguments[0].(map[string]any); envelope["result"] != want {
+			t.Errorf("call with %d bytes = %v, want %s", size, envelope, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"fmt"
+
+	"github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// InfoTopic returns the topic the board info is published to
+func (c *Client) InfoTopic() string {
+	return fmt.Sprintf("iotronic.board.%s.info", c.board.UUID)
+}
+
+// infoEvent returns the board info announced to the cloud
+func (c *Client) infoEvent() map[string]any {
+	info := c.board.Info()
+	return map[string]any{
+		"uuid":     info.UUID,
+		"name":     info.Name,
+		"type":     info.Type,
+		"status":   info.Status,
+		"agent":    info.Agent,
+		"location": info.Location,
+	}
+}
+
+// publishInfo publishes the board info to this cloud, waiting for the
+// router to acknowledge it if ack is set; nothing is sent while
+// disconnected. Every cloud client announces board changes on its own.
+func (c *Client) publishInfo(ack bool) {
+	if !c.IsConnected() {
+		return
+	}
+
+	opts := wamp.Dict{}
+	if ack {
+		opts[wamp.OptAcknowledge] = true
+	}
+	if err := c.publish(c.InfoTopic(), opts, []any{c.infoEvent()}, nil); err != nil {
+		log.Warnf("Failed to publish board info: %v", err)
+	}
+}
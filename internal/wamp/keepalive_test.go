@@ -0,0 +1,83 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gammazero/nexus/v3/client"
+)
+
+func TestKeepAliveInterval(t *testing.T) {
+	cfg, b := newTestBoard(t)
+	cfg.Autobahn.AliveTimer = 600
+	cfg.Autobahn.KeepaliveInterval = 7
+
+	// Record the interval asked for and tick fast instead
+	intervals := make(chan time.Duration, 1)
+	newTicker = func(d time.Duration) *time.Ticker {
+		intervals <- d
+		return time.NewTicker(5 * time.Millisecond)
+	}
+	t.Cleanup(func() { newTicker = time.NewTicker })
+
+	var dials atomic.Int32
+	orig := connectNet
+	connectNet = func(ctx context.Context, url string, cfg client.Config) (*client.Client, error) {
+		dials.Add(1)
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	c := NewClient(cfg, b)
+	t.Cleanup(c.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.KeepAlive(ctx)
+		close(done)
+	}()
+
+	select {
+	case d := <-intervals:
+		if d != 7*time.Second {
+			t.Errorf("keep-alive interval = %v, want 7s from keepalive_interval", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("KeepAlive did not start its ticker")
+	}
+
+	// Each tick on a dropped connection starts a reconnect
+	deadline := time.Now().Add(2 * time.Second)
+	for dials.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d reconnect attempts after 2s, want at least 3", dials.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Let the loop and its last reconnect finish before the hooks are restored
+	cancel()
+	<-done
+	for c.IsReconnecting() {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+	log "github.com/sirupsen/logrus"
+)
+
+// Mutating marks a procedure as changing board state, so it is rejected
+// with a MAINTENANCE error result while maintenance mode is on
+func Mutating() RegisterOption {
+	return func(o *registerOptions) {
+		o.mutating = true
+	}
+}
+
+// SetMaintenance turns maintenance mode on or off and reports whether it
+// was on before
+func (c *Client) SetMaintenance(on bool) bool {
+	was := c.maintenance.Swap(on)
+	switch {
+	case on && !was:
+		log.Info("Maintenance mode on, mutating procedures are rejected")
+	case !on && was:
+		log.Info("Maintenance mode off")
+	}
+	return was
+}
+
+// Maintenance reports whether maintenance mode is on
+func (c *Client) Maintenance() bool {
+	return c.maintenance.Load()
+}
+
+// maintenanceHandler wraps handler so it is rejected while maintenance
+// mode is on
+func (c *Client) maintenanceHandler(procedure string, handler client.InvocationHandler) client.InvocationHandler {
+	return func(ctx context.Context, inv *wamp.Invocation) client.InvokeResult {
+		if c.maintenance.Load() {
+			log.Warnf("Rejecting invocation of %s: board in maintenance mode", procedure)
+			return rpc.ErrorCode("MAINTENANCE", fmt.Sprintf("Board in maintenance mode, %s is unavailable", shortName(procedure)))
+		}
+		return handler(ctx, inv)
+	}
+}
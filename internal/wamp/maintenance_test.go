@@ -0,0 +1,106 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"io"
+	stdlog "log"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/router"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+// call invokes procedure on r from a separate session and returns the
+// result envelope
+func call(t *testing.T, r router.Router, procedure string) map[string]any {
+	t.Helper()
+
+	caller, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+
+	res, err := caller.Call(context.Background(), procedure, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Call %s: %v", procedure, err)
+	}
+	envelope, _ := res.Arguments[0].(map[string]any)
+	return envelope
+}
+
+func TestMaintenanceBlocksMutatingProcedures(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	ok := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("done", nil)
+	}
+	if err := c.Register("test.Write", ok, Mutating()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("test.Read", ok); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		maintenance bool
+		procedure   string
+		wantCode    string
+	}{
+		{false, "test.Write", ""},
+		{true, "test.Write", "MAINTENANCE"},
+		{true, "test.Read", ""},
+		{false, "test.Write", ""},
+	}
+	for _, tt := range tests {
+		c.SetMaintenance(tt.maintenance)
+		if c.Maintenance() != tt.maintenance {
+			t.Fatalf("Maintenance() = %v, want %v", c.Maintenance(), tt.maintenance)
+		}
+
+		res := call(t, r, tt.procedure)
+		if tt.wantCode == "" {
+			if res["result"] != rpc.ResultSuccess {
+				t.Errorf("maintenance=%v %s: %v, want success", tt.maintenance, tt.procedure, res)
+			}
+			continue
+		}
+		if res["result"] != rpc.ResultError || res["code"] != tt.wantCode {
+			t.Errorf("maintenance=%v %s: %v, want %s", tt.maintenance, tt.procedure, res, tt.wantCode)
+		}
+	}
+}
+
+func TestSetMaintenanceReportsPrevious(t *testing.T) {
+	c, _ := newTestClient(t)
+	if c.SetMaintenance(true) {
+		t.Error("maintenance reported on initially")
+	}
+	if !c.SetMaintenance(true) {
+		t.Error("previous state lost")
+	}
+	if !c.SetMaintenance(false) || c.Maintenance() {
+		t.Error("maintenance not turned off")
+	}
+}
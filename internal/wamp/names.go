@@ -0,0 +1,37 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// procedureName builds the full name of a board procedure, prepending
+// prefix when set
+func procedureName(prefix, sessionID, uuid, name string) string {
+	procedure := fmt.Sprintf("iotronic.%s.%s.%s", sessionID, uuid, name)
+	if prefix = strings.Trim(prefix, "."); prefix != "" {
+		procedure = prefix + "." + procedure
+	}
+	return procedure
+}
+
+// Procedure returns the full name under which the board registers the
+// procedure called name
+func (c *Client) Procedure(name string) string {
+	return procedureName(c.cfg.Autobahn.RPCPrefix, c.board.SessionID, c.board.UUID, name)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestProcedureName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", "iotronic.42.uuid-1.Reboot"},
+		{"tenant1", "tenant1.iotronic.42.uuid-1.Reboot"},
+		{".tenant1.", "tenant1.iotronic.42.uuid-1.Reboot"},
+		{"org.tenant1", "org.tenant1.iotronic.42.uuid-1.Reboot"},
+	}
+	for _, tt := range tests {
+		if got := procedureName(tt.prefix, "42", "uuid-1", "Reboot"); got != tt.want {
+			t.Errorf("procedureName(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestProcedurePrefixRegistered(t *testing.T) {
+	r := newTestRouter(t)
+	c, b := newTestClient(t)
+	c.cfg.Autobahn.RPCPrefix = "tenant1"
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	proc := c.Procedure("Ping")
+	if want := "tenant1.iotronic." + b.SessionID + "." + testUUID + ".Ping"; proc != want {
+		t.Fatalf("Procedure(Ping) = %q, want %q", proc, want)
+	}
+	ok := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("ok", nil)
+	}
+	if err := c.Register(proc, ok); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if res := call(t, r, proc); res["result"] != rpc.ResultSuccess {
+		t.Errorf("%s = %v", proc, res)
+	}
+	for _, name := range c.ListRegistered() {
+		if !strings.HasPrefix(name, "tenant1.") {
+			t.Errorf("registered %q without the prefix", name)
+		}
+	}
+}
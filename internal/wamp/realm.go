@@ -0,0 +1,83 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrRealmRejected is returned when every router aborted the session
+// instead of welcoming it: the realm is unknown or the board is not
+// authorized to join it. Retrying does not help until the configuration
+// changes.
+var ErrRealmRejected = errors.New("WAMP realm rejected")
+
+// Back-off applied to keep-alive reconnects after a realm rejection,
+// doubling on each rejection
+const (
+	realmRetryBase = 5 * time.Minute
+	realmRetryMax  = time.Hour
+)
+
+// isRealmRejection reports whether err is the router aborting the join,
+// as opposed to a transport failure. nexus reports both as plain errors,
+// so the ABORT message is recognised by its text.
+func isRealmRejection(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "unexpected ABORT message") ||
+		strings.Contains(msg, "wamp.error.no_such_realm") ||
+		strings.Contains(msg, "wamp.error.not_authorized")
+}
+
+// recordRealmRejection backs off keep-alive reconnects after a realm
+// rejection
+func (c *Client) recordRealmRejection() time.Duration {
+	n := c.realmRejections.Add(1)
+	delay := realmRetryMax
+	if n <= 8 {
+		delay = min(realmRetryBase<<(n-1), realmRetryMax)
+	}
+	c.realmRetryAt.Store(time.Now().Add(delay).UnixNano())
+	return delay
+}
+
+// clearRealmRejection forgets past rejections once a session is welcomed
+func (c *Client) clearRealmRejection() {
+	c.realmRejections.Store(0)
+	c.realmRetryAt.Store(0)
+}
+
+// realmBackoff returns how long keep-alive reconnects are still held back
+// by a realm rejection
+func (c *Client) realmBackoff() time.Duration {
+	at := c.realmRetryAt.Load()
+	if at == 0 {
+		return 0
+	}
+	return max(time.Until(time.Unix(0, at)), 0)
+}
+
+// logRealmRejection explains a rejection, which needs an operator
+func logRealmRejection(realm string, retry time.Duration, err error) {
+	log.Errorf("The WAMP router rejected realm %q, check the board settings (next automatic attempt in %v): %v", realm, retry, err)
+}
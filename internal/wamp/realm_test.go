@@ -0,0 +1,145 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"io"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gammazero/nexus/v3/client"
+)
+
+// newRejectedClient returns a client for a board configured with a realm
+// the test router does not serve
+func newRejectedClient(t *testing.T) *Client {
+	t.Helper()
+
+	newTestRouter(t)
+	settings := filepath.Join(t.TempDir(), "settings.json")
+	data := strings.Replace(testSettings, `"realm": "`+testRealm+`"`, `"realm": "unknown"`, 1)
+	if err := os.WriteFile(settings, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, b := loadTestBoard(t, settings)
+	c := NewClient(cfg, b)
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func TestConnectRealmRejected(t *testing.T) {
+	c := newRejectedClient(t)
+
+	err := c.Connect()
+	if !errors.Is(err, ErrRealmRejected) {
+		t.Fatalf("Connect error = %v, want ErrRealmRejected", err)
+	}
+
+	d := c.Diagnostics()
+	if !d.RealmRejected || d.Success || d.Realm != "unknown" {
+		t.Errorf("diagnostics = %+v, want a realm rejection", d)
+	}
+	if d.RetryAt == nil || time.Until(*d.RetryAt) < realmRetryBase-time.Minute {
+		t.Errorf("retry_at = %v, want about %v from now", d.RetryAt, realmRetryBase)
+	}
+
+	// Repeated rejections back off further
+	first := c.realmBackoff()
+	c.Connect()
+	if second := c.realmBackoff(); second < first+realmRetryBase-time.Minute {
+		t.Errorf("backoff after two rejections = %v, after one %v", second, first)
+	}
+}
+
+func TestConnectTransportFailure(t *testing.T) {
+	c, _ := newTestClient(t)
+	orig := connectNet
+	connectNet = func(context.Context, string, client.Config) (*client.Client, error) {
+		return nil, errors.New("dial tcp: connection refused")
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	err := c.Connect()
+	if err == nil || errors.Is(err, ErrRealmRejected) {
+		t.Fatalf("Connect error = %v, want a transport failure", err)
+	}
+	if d := c.Diagnostics(); d.RealmRejected || d.RetryAt != nil {
+		t.Errorf("diagnostics = %+v, want no realm rejection", d)
+	}
+	if wait := c.realmBackoff(); wait != 0 {
+		t.Errorf("transport failure holds reconnects back for %v", wait)
+	}
+}
+
+func TestKeepAliveHoldsBackAfterRealmRejection(t *testing.T) {
+	c := newRejectedClient(t)
+	c.Connect()
+
+	var dials atomic.Int32
+	orig := connectNet
+	connectNet = func(ctx context.Context, url string, cfg client.Config) (*client.Client, error) {
+		dials.Add(1)
+		return orig(ctx, url, cfg)
+	}
+	t.Cleanup(func() { connectNet = orig })
+	newTicker = func(time.Duration) *time.Ticker { return time.NewTicker(2 * time.Millisecond) }
+	t.Cleanup(func() { newTicker = time.NewTicker })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.KeepAlive(ctx)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	if n := dials.Load(); n != 0 {
+		t.Errorf("keep-alive retried a rejected realm %d times during the back-off", n)
+	}
+}
+
+func TestConnectClearsRealmRejection(t *testing.T) {
+	c := newRejectedClient(t)
+	c.Connect()
+
+	// The realm gets fixed on the router side
+	orig := connectNet
+	connectNet = func(ctx context.Context, url string, cfg client.Config) (*client.Client, error) {
+		cfg.Realm = testRealm
+		cfg.Logger = stdlog.New(io.Discard, "", 0)
+		return orig(ctx, url, cfg)
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if wait := c.realmBackoff(); wait != 0 {
+		t.Errorf("back-off of %v left after a successful connect", wait)
+	}
+	if d := c.Diagnostics(); d.RealmRejected || !d.Success {
+		t.Errorf("diagnostics = %+v, want a success", d)
+	}
+}
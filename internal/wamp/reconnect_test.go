@@ -0,0 +1,177 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+// waitReconnected waits for the reconnect in progress on c to finish
+func waitReconnected(t *testing.T, c *Client) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.IsReconnecting() {
+		if time.Now().After(deadline) {
+			t.Fatal("reconnect still running after 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReconnectSingleFlight(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.cfg.Autobahn.ConnectionTimer = 0
+
+	// Dials block until released, keeping the first reconnect in flight
+	var dials atomic.Int32
+	release := make(chan struct{})
+	orig := connectNet
+	connectNet = func(context.Context, string, client.Config) (*client.Client, error) {
+		dials.Add(1)
+		<-release
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	var started atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.ReconnectAsync() {
+				started.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := started.Load(); n != 1 {
+		t.Fatalf("%d reconnects started, want 1", n)
+	}
+	if !c.IsReconnecting() {
+		t.Fatal("IsReconnecting = false with a reconnect in flight")
+	}
+	if err := c.Reconnect(); !errors.Is(err, ErrReconnectInProgress) {
+		t.Errorf("Reconnect during a reconnect = %v, want ErrReconnectInProgress", err)
+	}
+
+	// KeepAlive keeps ticking, and returns, while the reconnect hangs
+	newTicker = func(time.Duration) *time.Ticker { return time.NewTicker(time.Millisecond) }
+	t.Cleanup(func() { newTicker = time.NewTicker })
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		c.KeepAlive(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("KeepAlive blocked behind the reconnect")
+	}
+
+	close(release)
+	waitReconnected(t, c)
+	if n := dials.Load(); n != 1 {
+		t.Errorf("%d dials, want 1", n)
+	}
+
+	// Once finished, a new reconnect can start
+	if !c.ReconnectAsync() {
+		t.Error("reconnect refused after the previous one finished")
+	}
+	waitReconnected(t, c)
+}
+
+func TestStopCancelsReconnect(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.cfg.Autobahn.ConnectionTimer = 600
+
+	var dials atomic.Int32
+	orig := connectNet
+	connectNet = func(context.Context, string, client.Config) (*client.Client, error) {
+		dials.Add(1)
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { connectNet = orig })
+
+	if !c.ReconnectAsync() {
+		t.Fatal("reconnect not started")
+	}
+	c.Stop()
+
+	waitReconnected(t, c)
+	if n := dials.Load(); n != 0 {
+		t.Errorf("%d dials after Stop, want the wait to be cancelled", n)
+	}
+}
+
+func TestReconnectRestoresRegistrations(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	c.cfg.Autobahn.ConnectionTimer = 0
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	pong := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("pong", nil)
+	}
+	if err := c.Register("s4t.test.ping", pong, WithModule("test")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register(c.Procedure("Ping"), pong, WithModule("test")); err != nil {
+		t.Fatal(err)
+	}
+	oldBoardProc := c.Procedure("Ping")
+
+	if !c.ReconnectAsync() {
+		t.Fatal("reconnect not started")
+	}
+	waitReconnected(t, c)
+	if !c.IsConnected() {
+		t.Fatal("not connected after the reconnect")
+	}
+
+	// Board procedures follow the new session
+	boardProc := c.Procedure("Ping")
+	if boardProc == oldBoardProc {
+		t.Fatalf("session unchanged after the reconnect: %s", boardProc)
+	}
+	for _, procedure := range []string{"s4t.test.ping", boardProc} {
+		if res := call(t, r, procedure); res["result"] != rpc.ResultSuccess {
+			t.Errorf("%s after reconnect = %v, want success", procedure, res)
+		}
+	}
+	if got := c.ListRegisteredByModule()["test"]; len(got) != 2 {
+		t.Errorf("test module procedures = %v, want both restored", got)
+	}
+
+	// The restored registration can be removed under its new name
+	if err := c.Unregister(boardProc); err != nil {
+		t.Errorf("Unregister %s: %v", boardProc, err)
+	}
+}
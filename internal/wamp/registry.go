@@ -0,0 +1,113 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrAlreadyRegistered is returned when a procedure is registered twice
+var ErrAlreadyRegistered = errors.New("already registered")
+
+// ungroupedModule groups procedures registered without WithModule
+const ungroupedModule = "other"
+
+// registry tracks the procedures registered by this client and the module
+// each one belongs to
+type registry struct {
+	mu    sync.Mutex
+	procs map[string]string
+}
+
+// reserve claims procedure for module, failing if it is already registered
+func (r *registry) reserve(procedure, module string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.procs == nil {
+		r.procs = make(map[string]string)
+	}
+	if _, exists := r.procs[procedure]; exists {
+		return fmt.Errorf("procedure %s %w", procedure, ErrAlreadyRegistered)
+	}
+
+	if module == "" {
+		module = ungroupedModule
+	}
+	r.procs[procedure] = module
+	return nil
+}
+
+// release forgets procedure
+func (r *registry) release(procedure string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.procs, procedure)
+}
+
+// reset forgets all procedures
+func (r *registry) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.procs = nil
+}
+
+// list returns the registered procedures in sorted order
+func (r *registry) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	procs := make([]string, 0, len(r.procs))
+	for p := range r.procs {
+		procs = append(procs, p)
+	}
+	sort.Strings(procs)
+
+	return procs
+}
+
+// byModule returns the registered procedures grouped by module, each group
+// in sorted order
+func (r *registry) byModule() map[string][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	groups := make(map[string][]string)
+	for p, module := range r.procs {
+		groups[module] = append(groups[module], p)
+	}
+	for _, procs := range groups {
+		sort.Strings(procs)
+	}
+
+	return groups
+}
+
+// ListRegistered returns the procedures currently registered by this client
+func (c *Client) ListRegistered() []string {
+	return c.registry.list()
+}
+
+// ListRegisteredByModule returns the procedures currently registered by
+// this client grouped by the module that registered them
+func (c *Client) ListRegisteredByModule() map[string][]string {
+	return c.registry.byModule()
+}
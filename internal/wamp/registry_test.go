@@ -0,0 +1,135 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"errors"
+	"io"
+	stdlog "log"
+	"reflect"
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestUnregisterModule(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	ok := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("ok", nil)
+	}
+	for proc, module := range map[string]string{"Reboot": "device", "Ping": "device", "ExposeService": "service"} {
+		if err := c.Register(c.Procedure(proc), ok, WithModule(module)); err != nil {
+			t.Fatalf("Register %s: %v", proc, err)
+		}
+	}
+
+	if err := c.UnregisterModule("device"); err != nil {
+		t.Fatalf("UnregisterModule() = %v", err)
+	}
+	want := map[string][]string{"service": {c.Procedure("ExposeService")}}
+	if got := c.ListRegisteredByModule(); !reflect.DeepEqual(got, want) {
+		t.Errorf("registered = %v, want %v", got, want)
+	}
+
+	caller, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caller.Close()
+	if _, err := caller.Call(context.Background(), c.Procedure("Reboot"), nil, nil, nil, nil); err == nil {
+		t.Error("Reboot still callable after its module was unregistered")
+	}
+	if res := call(t, r, c.Procedure("ExposeService")); res["result"] != rpc.ResultSuccess {
+		t.Errorf("ExposeService = %v", res)
+	}
+
+	// Nothing left to withdraw
+	if err := c.UnregisterModule("device"); err != nil {
+		t.Errorf("second UnregisterModule() = %v", err)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	first := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("first", nil)
+	}
+	second := func(context.Context, *wamp.Invocation) client.InvokeResult {
+		return rpc.Success("second", nil)
+	}
+	if err := c.Register(c.Procedure("GetStatus"), first, WithModule("device")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The duplicate is refused locally, whichever module asks
+	err := c.Register(c.Procedure("GetStatus"), second, WithModule("service"))
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Fatalf("second Register = %v, want ErrAlreadyRegistered", err)
+	}
+	if want := "procedure " + c.Procedure("GetStatus") + " already registered"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+	if res := call(t, r, c.Procedure("GetStatus")); res["message"] != "first" {
+		t.Errorf("GetStatus answered by %v, want the first handler", res["message"])
+	}
+	if got, want := c.ListRegistered(), []string{c.Procedure("GetStatus")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRegistered = %v, want %v", got, want)
+	}
+
+	// A procedure the router refuses is not left reserved
+	other, err := client.ConnectLocal(r, client.Config{Realm: testRealm, Logger: stdlog.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := other.Register(c.Procedure("Reboot"), first, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register(c.Procedure("Reboot"), second); err == nil || errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Register of a procedure owned by another session = %v, want a router error", err)
+	}
+	if got := c.ListRegistered(); len(got) != 1 {
+		t.Errorf("ListRegistered after a router refusal = %v", got)
+	}
+
+	// Registrations are restored under the new session
+	c.Disconnect()
+	if err := c.Connect(); err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	if got, want := c.ListRegistered(), []string{c.Procedure("GetStatus")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRegistered after reconnect = %v, want %v", got, want)
+	}
+	if err := c.Register(c.Procedure("GetStatus"), second); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Register after reconnect = %v, want ErrAlreadyRegistered", err)
+	}
+	if res := call(t, r, c.Procedure("GetStatus")); res["message"] != "first" {
+		t.Errorf("GetStatus after reconnect answered by %v, want the first handler", res["message"])
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+// ProcedureStats counts the invocations of a procedure since the agent
+// started; they survive reconnects
+type ProcedureStats struct {
+	Procedure  string     `json:"procedure"`
+	Calls      uint64     `json:"calls"`
+	Errors     uint64     `json:"errors"`
+	LastCalled *time.Time `json:"last_called,omitempty"`
+}
+
+// callStats holds the invocation counters of every procedure
+type callStats struct {
+	mu    sync.Mutex
+	procs map[string]*ProcedureStats
+}
+
+// record counts an invocation of procedure that ended with res
+func (s *callStats) record(procedure string, at time.Time, res client.InvokeResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.procs == nil {
+		s.procs = make(map[string]*ProcedureStats)
+	}
+	ps, ok := s.procs[procedure]
+	if !ok {
+		ps = &ProcedureStats{Procedure: procedure}
+		s.procs[procedure] = ps
+	}
+	ps.Calls++
+	if resultOf(res) == rpc.ResultError {
+		ps.Errors++
+	}
+	ps.LastCalled = &at
+}
+
+// list returns a copy of the counters sorted by procedure
+func (s *callStats) list() []ProcedureStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]ProcedureStats, 0, len(s.procs))
+	for _, ps := range s.procs {
+		stats = append(stats, *ps)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Procedure < stats[j].Procedure })
+	return stats
+}
+
+// statsHandler wraps handler so every invocation is counted, including
+// the ones rejected by the other wrappers
+func (c *Client) statsHandler(procedure string, handler client.InvocationHandler) client.InvocationHandler {
+	return func(ctx context.Context, inv *wamp.Invocation) client.InvokeResult {
+		at := time.Now()
+		res := handler(ctx, inv)
+		c.callStats.record(procedure, at, res)
+		return res
+	}
+}
+
+// RPCStats returns the invocation counters of the procedures called so far
+func (c *Client) RPCStats() []ProcedureStats {
+	return c.callStats.list()
+}
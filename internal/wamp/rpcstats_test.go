@@ -0,0 +1,86 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/rpc"
+	"github.com/gammazero/nexus/v3/client"
+	"github.com/gammazero/nexus/v3/wamp"
+)
+
+func TestRPCStats(t *testing.T) {
+	r := newTestRouter(t)
+	c, _ := newTestClient(t)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	echo := func(context.Context, *wamp.Invocation) client.InvokeResult { return rpc.Success("done", nil) }
+	fail := func(context.Context, *wamp.Invocation) client.InvokeResult { return rpc.Error("failed") }
+	if err := c.Register("test.Echo", echo); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("test.Fail", fail); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := c.RPCStats(); len(stats) != 0 {
+		t.Fatalf("stats before any call = %+v", stats)
+	}
+
+	start := time.Now()
+	call(t, r, "test.Echo")
+	first := c.RPCStats()
+	if len(first) != 1 || first[0].Procedure != "test.Echo" || first[0].Calls != 1 || first[0].LastCalled == nil {
+		t.Fatalf("stats after one call = %+v", first)
+	}
+	if first[0].LastCalled.Before(start) {
+		t.Errorf("last_called %v before the call at %v", first[0].LastCalled, start)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	call(t, r, "test.Echo")
+	call(t, r, "test.Echo")
+	call(t, r, "test.Fail")
+
+	stats := c.RPCStats()
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v, want two procedures", stats)
+	}
+	echoStats, failStats := stats[0], stats[1]
+	if echoStats.Calls != 3 || echoStats.Errors != 0 {
+		t.Errorf("test.Echo stats = %+v, want 3 calls without errors", echoStats)
+	}
+	if !echoStats.LastCalled.After(*first[0].LastCalled) {
+		t.Errorf("test.Echo last_called %v not after %v", echoStats.LastCalled, first[0].LastCalled)
+	}
+	if failStats.Procedure != "test.Fail" || failStats.Calls != 1 || failStats.Errors != 1 {
+		t.Errorf("test.Fail stats = %+v, want 1 call, 1 error", failStats)
+	}
+
+	// Counters survive a reconnect
+	c.Disconnect()
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.RPCStats(); len(got) != 2 || got[0].Calls != 3 {
+		t.Errorf("stats after reconnect = %+v", got)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import "time"
+
+// Stats describes the stability of the connection to the WAMP router
+type Stats struct {
+	Connected      bool       `json:"connected"`
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	UptimeSeconds  float64    `json:"uptime_seconds"`
+	Connects       uint64     `json:"connects"`
+	Reconnects     uint64     `json:"reconnects"`
+}
+
+// recordConnect notes a successful connect, restarting the connection
+// uptime
+func (c *Client) recordConnect() {
+	c.connectedAt.Store(time.Now().UnixNano())
+	c.connects.Add(1)
+}
+
+// recordDisconnect notes that the connection went away
+func (c *Client) recordDisconnect() {
+	c.connectedAt.Store(0)
+}
+
+// Stats returns the connection uptime and how often the client connected;
+// every connect after the first counts as a reconnect
+func (c *Client) Stats() Stats {
+	s := Stats{Connects: c.connects.Load()}
+	if s.Connects > 0 {
+		s.Reconnects = s.Connects - 1
+	}
+
+	if at := c.connectedAt.Load(); at != 0 {
+		since := time.Unix(0, at)
+		s.Connected = true
+		s.ConnectedSince = &since
+		s.UptimeSeconds = time.Since(since).Seconds()
+	}
+
+	return s
+}
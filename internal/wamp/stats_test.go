@@ -0,0 +1,63 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	newTestRouter(t)
+	c, _ := newTestClient(t)
+
+	if s := c.Stats(); s != (Stats{}) {
+		t.Errorf("Stats before connecting = %+v", s)
+	}
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	first := c.Stats()
+	if !first.Connected || first.ConnectedSince == nil || first.Connects != 1 || first.Reconnects != 0 {
+		t.Fatalf("Stats after connecting = %+v", first)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if up := c.Stats().UptimeSeconds; up < 0.02 {
+		t.Errorf("uptime = %vs, want it to grow while connected", up)
+	}
+
+	// Reconnects restart the uptime but keep counting
+	for i := uint64(1); i <= 2; i++ {
+		if err := c.Reconnect(); err != nil {
+			t.Fatalf("Reconnect: %v", err)
+		}
+		s := c.Stats()
+		if !s.Connected || s.Connects != i+1 || s.Reconnects != i {
+			t.Errorf("Stats after reconnect %d = %+v", i, s)
+		}
+		if !s.ConnectedSince.After(*first.ConnectedSince) {
+			t.Errorf("reconnect %d kept the old uptime: %+v", i, s)
+		}
+	}
+
+	if err := c.Disconnect(); err != nil {
+		t.Fatalf("Disconnect: %v", err)
+	}
+	if s := c.Stats(); s.Connected || s.ConnectedSince != nil || s.UptimeSeconds != 0 || s.Reconnects != 2 {
+		t.Errorf("Stats after disconnecting = %+v", s)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+// tlsConfig builds the TLS settings for the router connection, or nil if
+// the defaults apply. A client certificate is loaded whenever one is
+// configured, whether or not server certificates are verified.
+func tlsConfig(cfg *config.Config) (*tls.Config, error) {
+	certFile, keyFile := cfg.Autobahn.ClientCert, cfg.Autobahn.ClientKey
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("autobahn.client_cert and autobahn.client_key must be set together")
+	}
+
+	if !cfg.LightningRod.SkipCertVerify && certFile == "" {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: cfg.LightningRod.SkipCertVerify,
+	}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 MDSLAB - University of Messina
+// All Rights Reserved.
+//
+//    Licensed under the Apache License, Version 2.0 (the "License"); you may
+//    not use this file except in compliance with the License. You may obtain
+//    a copy of the License at
+//
+//         http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//    WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//    License for the specific language governing permissions and limitations
+//    under the License.
+
+package wamp
+
+import (
+	"testing"
+
+	"github.com/MDSLab/iotronic-lightning-rod/internal/config"
+)
+
+func TestTLSConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		skipVerify bool
+		cert, key  string
+		wantNil    bool
+		wantCerts  int
+		wantErr    bool
+	}{
+		{name: "defaults", wantNil: true},
+		{name: "skip verify only", skipVerify: true},
+		{name: "client certificate", cert: "testdata/client.crt", key: "testdata/client.key", wantCerts: 1},
+		{name: "client certificate with skip verify", skipVerify: true, cert: "testdata/client.crt", key: "testdata/client.key", wantCerts: 1},
+		{name: "cert without key", cert: "testdata/client.crt", wantErr: true},
+		{name: "key without cert", key: "testdata/client.key", wantErr: true},
+		{name: "missing files", cert: "testdata/missing.crt", key: "testdata/missing.key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.LightningRod.SkipCertVerify = tt.skipVerify
+			cfg.Autobahn.ClientCert = tt.cert
+			cfg.Autobahn.ClientKey = tt.key
+
+			tlsCfg, err := tlsConfig(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if tlsCfg != nil {
+					t.Fatalf("expected no TLS config, got %+v", tlsCfg)
+				}
+				return
+			}
+
+			if tlsCfg.InsecureSkipVerify != tt.skipVerify {
+				t.Errorf("InsecureSkipVerify = %v, want %v", tlsCfg.InsecureSkipVerify, tt.skipVerify)
+			}
+			if len(tlsCfg.Certificates) != tt.wantCerts {
+				t.Errorf("got %d certificates, want %d", len(tlsCfg.Certificates), tt.wantCerts)
+			}
+		})
+	}
+}